@@ -0,0 +1,85 @@
+package main
+
+import (
+	"collyDemo/config"
+	"collyDemo/core"
+	"collyDemo/mongodb"
+	"collyDemo/pkg/utils"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// runAccountCommand 账号管理命令
+//
+//	collyDemo accounts encrypt -in accounts.json -out config/accounts.enc
+//	collyDemo accounts list
+func runAccountCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("用法: collyDemo accounts <encrypt|list> [参数]")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "encrypt":
+		encryptAccounts(args[1:])
+	case "list":
+		listAccounts(args[1:])
+	default:
+		fmt.Printf("未知的账号命令: %s\n", args[0])
+		os.Exit(2)
+	}
+}
+
+// encryptAccounts 将明文账号文件加密，密钥从环境变量读取
+func encryptAccounts(args []string) {
+	fs := flag.NewFlagSet("accounts encrypt", flag.ExitOnError)
+	in := fs.String("in", "", "明文账号JSON文件")
+	out := fs.String("out", "config/accounts.enc", "加密后的输出文件")
+	keyEnv := fs.String("key-env", "COLLY_ACCOUNT_KEY", "保存密钥的环境变量名")
+	fs.Parse(args)
+
+	if *in == "" {
+		log.Fatal("请通过 -in 指定明文账号文件")
+	}
+	key := os.Getenv(*keyEnv)
+	if key == "" {
+		log.Fatalf("未设置密钥环境变量 %s", *keyEnv)
+	}
+
+	plaintext, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatalf("读取账号文件失败: %v", err)
+	}
+	encrypted, err := core.EncryptAccountFile(plaintext, utils.ParseKey(key))
+	if err != nil {
+		log.Fatalf("加密账号文件失败: %v", err)
+	}
+	if err := os.WriteFile(*out, []byte(encrypted), 0600); err != nil {
+		log.Fatalf("写入加密文件失败: %v", err)
+	}
+	log.Printf("账号文件已加密: %s，请删除明文文件 %s", *out, *in)
+}
+
+// listAccounts 按配置加载账号并输出脱敏信息
+func listAccounts(args []string) {
+	fs := flag.NewFlagSet("accounts list", flag.ExitOnError)
+	configPath := fs.String("config", "", "配置文件路径")
+	fs.Parse(args)
+
+	scheduleConfig := loadConfig(config.ResolveConfigPath(*configPath))
+	var db *mongo.Database
+	if scheduleConfig.Accounts.Source == config.AccountSourceMongo {
+		db = connectMongo(scheduleConfig)
+		defer mongodb.Disconnect(db)
+	}
+
+	accountPool := loadAccountPool(scheduleConfig, db)
+	for _, acc := range accountPool.Status() {
+		fmt.Printf("%s\t%s\t代理=%v\t限速=%v/分钟\t延迟=%v~%v\n",
+			acc["id"], acc["username"], acc["proxy"], acc["rate_limit"], acc["min_delay"], acc["max_delay"])
+	}
+}
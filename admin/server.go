@@ -0,0 +1,182 @@
+// Package admin 本地管理接口，用于查看定时任务状态以及手动触发、暂停、恢复任务、修改执行频率和调整并发数
+package admin
+
+import (
+	"collyDemo/core"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Server 管理接口服务
+//
+//	GET  /tasks                  定时任务状态
+//	POST /tasks/{id}/trigger     立即执行一次
+//	POST /tasks/{id}/pause       暂停
+//	POST /tasks/{id}/resume      恢复
+//	PUT  /tasks/{id}/schedule    修改执行频率，请求体 {"schedule": "30m"}
+//	GET  /runs                   执行中的采集批次
+//	GET  /concurrency            并发数及自适应状态
+//	PUT  /concurrency            调整并发数或开关自适应，请求体 {"limit": 5, "adaptive": true}，字段均可省略
+type Server struct {
+	scheduler  *core.Scheduler
+	dispatcher *core.TaskDispatcher
+	token      string
+	server     *http.Server
+}
+
+// New 创建管理接口服务，token 不为空时请求需携带 Authorization: Bearer <token>
+func New(addr, token string, scheduler *core.Scheduler, dispatcher *core.TaskDispatcher) *Server {
+	s := &Server{
+		scheduler:  scheduler,
+		dispatcher: dispatcher,
+		token:      token,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tasks", s.listTasks)
+	mux.HandleFunc("POST /tasks/{id}/trigger", s.control(scheduler.TriggerNow, "已触发"))
+	mux.HandleFunc("POST /tasks/{id}/pause", s.control(scheduler.Pause, "已暂停"))
+	mux.HandleFunc("POST /tasks/{id}/resume", s.control(scheduler.Resume, "已恢复"))
+	mux.HandleFunc("PUT /tasks/{id}/schedule", s.updateSchedule)
+	mux.HandleFunc("GET /runs", s.listRuns)
+	mux.HandleFunc("GET /concurrency", s.getConcurrency)
+	mux.HandleFunc("PUT /concurrency", s.updateConcurrency)
+
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           s.authorize(mux),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Start 监听地址并在后台处理请求，地址被占用时返回错误
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("管理接口已启动: http://%s", listener.Addr())
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("管理接口异常退出: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop 停止管理接口
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// authorize 校验访问令牌
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+				writeError(w, http.StatusUnauthorized, errors.New("未授权"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"leader": s.scheduler.IsLeader(),
+		"tasks":  s.scheduler.GetTaskStatus(),
+	})
+}
+
+func (s *Server) listRuns(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.dispatcher.Runs().Status())
+}
+
+func (s *Server) getConcurrency(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.dispatcher.ConcurrencyStatus())
+}
+
+func (s *Server) updateConcurrency(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Limit    *int  `json:"limit"`
+		Adaptive *bool `json:"adaptive"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("请求体格式应为 {\"limit\": 5, \"adaptive\": true}"))
+		return
+	}
+
+	if body.Limit != nil {
+		if err := s.dispatcher.SetConcurrency(*body.Limit); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if body.Adaptive != nil {
+		s.dispatcher.SetAdaptive(*body.Adaptive)
+	}
+	writeJSON(w, http.StatusOK, s.dispatcher.ConcurrencyStatus())
+}
+
+// control 按任务ID执行手动操作
+func (s *Server) control(action func(id string) error, message string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if err := action(id); err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"id": id, "message": message})
+	}
+}
+
+func (s *Server) updateSchedule(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Schedule string `json:"schedule"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("请求体格式应为 {\"schedule\": \"...\"}"))
+		return
+	}
+
+	id := r.PathValue("id")
+	if err := s.scheduler.UpdateSchedule(id, body.Schedule); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"id": id, "schedule": body.Schedule})
+}
+
+// statusOf 将调度器错误转换为 HTTP 状态码
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, core.ErrTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, core.ErrTaskPaused), errors.Is(err, core.ErrNotLeader):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("管理接口写入响应失败: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
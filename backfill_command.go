@@ -0,0 +1,126 @@
+package main
+
+import (
+	"collyDemo/config"
+	"collyDemo/core"
+	"collyDemo/mongodb"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// runBackfillCommand 历史数据回填命令，回填任务由运行中的主节点按窗口执行
+//
+//	collyDemo backfill create -endpoint live -from 2026-01-01 -to 2026-03-31 -step week
+//	collyDemo backfill list
+//	collyDemo backfill cancel -id <任务ID>
+func runBackfillCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("用法: collyDemo backfill <create|list|cancel> [参数]")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "create":
+		createBackfill(args[1:])
+	case "list":
+		listBackfills(args[1:])
+	case "cancel":
+		cancelBackfill(args[1:])
+	default:
+		fmt.Printf("未知的回填命令: %s\n", args[0])
+		os.Exit(2)
+	}
+}
+
+// createBackfill 将回填范围拆分为窗口并保存回填任务
+func createBackfill(args []string) {
+	fs := flag.NewFlagSet("backfill create", flag.ExitOnError)
+	configPath := fs.String("config", "", "配置文件路径")
+	endpoint := fs.String("endpoint", "", "接口名称，必须配置了日期窗口")
+	from := fs.String("from", "", "开始日期 YYYY-MM-DD")
+	to := fs.String("to", "", "结束日期 YYYY-MM-DD")
+	step := fs.String("step", core.BackfillStepDay, "窗口粒度: day 或 week")
+	fs.Parse(args)
+
+	if *endpoint == "" || *from == "" || *to == "" {
+		log.Fatal("请通过 -endpoint、-from 和 -to 指定回填接口和日期范围")
+	}
+
+	scheduleConfig := loadConfig(config.ResolveConfigPath(*configPath))
+	catalog, err := core.LoadCatalog(scheduleConfig.System.EndpointsFile)
+	if err != nil {
+		log.Fatalf("加载接口目录失败: %v", err)
+	}
+	job, err := core.NewBackfillJob(catalog, *endpoint, *from, *to, *step)
+	if err != nil {
+		log.Fatalf("创建回填任务失败: %v", err)
+	}
+
+	db := connectMongo(scheduleConfig)
+	defer mongodb.Disconnect(db)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := mongodb.NewBackfillJobDAO(db).Create(ctx, job); err != nil {
+		log.Fatalf("保存回填任务失败: %v", err)
+	}
+	log.Printf("回填任务已创建: %s, 接口: %s, 范围: %s~%s, 窗口数: %d", job.ID, job.Endpoint, job.From, job.To, len(job.Windows))
+}
+
+// listBackfills 输出所有回填任务及进度
+func listBackfills(args []string) {
+	fs := flag.NewFlagSet("backfill list", flag.ExitOnError)
+	configPath := fs.String("config", "", "配置文件路径")
+	fs.Parse(args)
+
+	scheduleConfig := loadConfig(config.ResolveConfigPath(*configPath))
+	db := connectMongo(scheduleConfig)
+	defer mongodb.Disconnect(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	jobs, err := mongodb.NewBackfillJobDAO(db).List(ctx)
+	if err != nil {
+		log.Fatalf("读取回填任务失败: %v", err)
+	}
+	for _, job := range jobs {
+		failed := 0
+		for _, window := range job.Windows {
+			if window.Status == mongodb.BackfillFailed {
+				failed++
+			}
+		}
+		fmt.Printf("%s\t%s\t%s~%s\t%s\t%s\t进度=%d/%d\t失败=%d\n",
+			job.ID, job.Endpoint, job.From, job.To, job.Step, job.Status, job.Next, len(job.Windows), failed)
+	}
+}
+
+// cancelBackfill 取消回填任务，正在采集的窗口会继续完成
+func cancelBackfill(args []string) {
+	fs := flag.NewFlagSet("backfill cancel", flag.ExitOnError)
+	configPath := fs.String("config", "", "配置文件路径")
+	id := fs.String("id", "", "回填任务ID")
+	fs.Parse(args)
+
+	if *id == "" {
+		log.Fatal("请通过 -id 指定回填任务")
+	}
+
+	scheduleConfig := loadConfig(config.ResolveConfigPath(*configPath))
+	db := connectMongo(scheduleConfig)
+	defer mongodb.Disconnect(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cancelled, err := mongodb.NewBackfillJobDAO(db).Cancel(ctx, *id)
+	if err != nil {
+		log.Fatalf("取消回填任务失败: %v", err)
+	}
+	if !cancelled {
+		log.Fatalf("回填任务不存在或已结束: %s", *id)
+	}
+	log.Printf("回填任务已取消: %s", *id)
+}
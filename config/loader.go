@@ -0,0 +1,441 @@
+package config
+
+import (
+	"collyDemo/pkg/schedule"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultConfigPath 默认配置文件路径
+const DefaultConfigPath = "config/config.json"
+
+// 环境变量覆盖项，均以 COLLY_ 为前缀
+const (
+	EnvConfigPath     = "COLLY_CONFIG"          // 配置文件路径
+	EnvMaxRetries     = "COLLY_MAX_RETRIES"     // system.max_retries
+	EnvRetryDelay     = "COLLY_RETRY_DELAY"     // system.retry_delay
+	EnvTaskTimeout    = "COLLY_TASK_TIMEOUT"    // system.task_timeout
+	EnvRequestTimeout = "COLLY_REQUEST_TIMEOUT" // system.request_timeout
+	EnvMaxConcurrency = "COLLY_MAX_CONCURRENCY" // system.max_concurrency
+	EnvReloadInterval = "COLLY_RELOAD_INTERVAL" // system.reload_interval
+	EnvEndpointsFile  = "COLLY_ENDPOINTS_FILE"  // system.endpoints_file
+	EnvLeaderEnabled  = "COLLY_LEADER_ENABLED"  // leader.enabled
+	EnvInstanceID     = "COLLY_INSTANCE_ID"     // leader.instance_id
+	EnvAdminAddr      = "COLLY_ADMIN_ADDR"      // admin.addr
+	EnvAdminToken     = "COLLY_ADMIN_TOKEN"     // 管理接口访问令牌，不支持写在配置文件中
+	EnvAccountSource  = "COLLY_ACCOUNT_SOURCE"  // accounts.source
+	EnvAccountFile    = "COLLY_ACCOUNT_FILE"    // accounts.file
+	EnvMongoURI       = "COLLY_MONGO_URI"       // mongo.uri
+	EnvMongoDatabase  = "COLLY_MONGO_DATABASE"  // mongo.database
+	EnvMongoUsername  = "COLLY_MONGO_USERNAME"  // mongo.username
+	EnvMongoPassword  = "COLLY_MONGO_PASSWORD"  // mongo 密码，不支持写在配置文件中
+
+	// EnvSchedulePrefix 任务频率覆盖前缀，如 COLLY_SCHEDULE_AUTHOR=4h、COLLY_SCHEDULE_HOT_VIDEO_RANK=30m
+	EnvSchedulePrefix = "COLLY_SCHEDULE_"
+)
+
+// Duration 支持 "5s"、"5m"、"1h30m" 形式的时长，数字按秒解析
+type Duration time.Duration
+
+// UnmarshalJSON 解析时长字符串或秒数
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("无效的时长 %q: %v", v, err)
+		}
+		*d = Duration(parsed)
+	case float64:
+		*d = Duration(v * float64(time.Second))
+	default:
+		return fmt.Errorf("无效的时长: %s", string(data))
+	}
+	return nil
+}
+
+// MarshalJSON 输出为时长字符串
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// String 返回时长字符串
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// ResolveConfigPath 确定配置文件路径，命令行参数优先，其次是环境变量
+func ResolveConfigPath(flagPath string) string {
+	if flagPath != "" {
+		return flagPath
+	}
+	if path := os.Getenv(EnvConfigPath); path != "" {
+		return path
+	}
+	return DefaultConfigPath
+}
+
+// LoadConfig 加载配置文件，未配置的字段使用默认值，随后应用环境变量覆盖并校验
+func LoadConfig(path string) (*ScheduleConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	return parseConfig(path, data)
+}
+
+// parseConfig 解析已读取的配置文件内容，应用环境变量覆盖并校验
+func parseConfig(path string, data []byte) (*ScheduleConfig, error) {
+	config := GetDefaultConfig()
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败 %s: %w", path, err)
+	}
+
+	if err := config.ApplyEnvOverrides(); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("配置校验失败 %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// ApplyEnvOverrides 使用环境变量覆盖配置
+func (c *ScheduleConfig) ApplyEnvOverrides() error {
+	if err := envInt(EnvMaxRetries, &c.System.MaxRetries); err != nil {
+		return err
+	}
+	if err := envDuration(EnvRetryDelay, &c.System.RetryDelay); err != nil {
+		return err
+	}
+	if err := envDuration(EnvTaskTimeout, &c.System.TaskTimeout); err != nil {
+		return err
+	}
+	if err := envDuration(EnvRequestTimeout, &c.System.RequestTimeout); err != nil {
+		return err
+	}
+	if err := envInt(EnvMaxConcurrency, &c.System.MaxConcurrency); err != nil {
+		return err
+	}
+	if err := envDuration(EnvReloadInterval, &c.System.ReloadInterval); err != nil {
+		return err
+	}
+	envString(EnvEndpointsFile, &c.System.EndpointsFile)
+	if err := envBool(EnvLeaderEnabled, &c.Leader.Enabled); err != nil {
+		return err
+	}
+	envString(EnvInstanceID, &c.Leader.InstanceID)
+	envString(EnvAdminAddr, &c.Admin.Addr)
+	if value, ok := os.LookupEnv(EnvAdminToken); ok {
+		c.Admin.Token = value
+	}
+	envString(EnvAccountSource, &c.Accounts.Source)
+	envString(EnvAccountFile, &c.Accounts.File)
+	envString(EnvMongoURI, &c.Mongo.URI)
+	envString(EnvMongoDatabase, &c.Mongo.Database)
+	envString(EnvMongoUsername, &c.Mongo.Username)
+	if value, ok := os.LookupEnv(EnvMongoPassword); ok {
+		c.Mongo.Password = value
+	}
+
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(name, EnvSchedulePrefix) {
+			continue
+		}
+		key := strings.ToLower(strings.TrimPrefix(name, EnvSchedulePrefix))
+		// 任务所属分组以接口目录为准，这里只需保证覆盖值进入某一个分组
+		if _, ok := c.RankTasks[key]; ok {
+			c.RankTasks[key] = strings.TrimSpace(value)
+		} else {
+			if c.MainTasks == nil {
+				c.MainTasks = make(map[string]string)
+			}
+			c.MainTasks[key] = strings.TrimSpace(value)
+		}
+	}
+	return nil
+}
+
+// Validate 校验所有配置项
+func (c *ScheduleConfig) Validate() error {
+	var errs []error
+
+	for _, group := range []struct {
+		name      string
+		schedules map[string]string
+	}{
+		{"main_tasks", c.MainTasks},
+		{"rank_tasks", c.RankTasks},
+	} {
+		for key, schedule := range group.schedules {
+			if err := ValidateSchedule(schedule); err != nil {
+				errs = append(errs, fmt.Errorf("%s.%s: %w", group.name, key, err))
+			}
+		}
+	}
+	for key, policy := range c.TaskPolicies {
+		if err := schedule.ValidateMisfire(policy.Misfire); err != nil {
+			errs = append(errs, fmt.Errorf("task_policies.%s: %w", key, err))
+		}
+		if err := schedule.ValidateOverlap(policy.Overlap); err != nil {
+			errs = append(errs, fmt.Errorf("task_policies.%s: %w", key, err))
+		}
+		if _, err := schedule.ParseConstraints(policy.Windows, policy.Blackouts); err != nil {
+			errs = append(errs, fmt.Errorf("task_policies.%s: %w", key, err))
+		}
+		if policy.Jitter < 0 {
+			errs = append(errs, fmt.Errorf("task_policies.%s.jitter 不能为负数，当前为 %s", key, policy.Jitter))
+		}
+		if policy.DependencyTimeout < 0 {
+			errs = append(errs, fmt.Errorf("task_policies.%s.dependency_timeout 不能为负数，当前为 %s", key, policy.DependencyTimeout))
+		}
+	}
+	for key := range c.MainTasks {
+		if _, ok := c.RankTasks[key]; ok {
+			errs = append(errs, fmt.Errorf("任务 %s 同时出现在 main_tasks 和 rank_tasks 中", key))
+		}
+	}
+
+	if c.System.MaxRetries < 1 {
+		errs = append(errs, fmt.Errorf("system.max_retries 必须大于等于1，当前为 %d", c.System.MaxRetries))
+	}
+	if c.System.RetryDelay < 0 {
+		errs = append(errs, fmt.Errorf("system.retry_delay 不能为负数，当前为 %s", c.System.RetryDelay))
+	}
+	if c.System.RetryMaxDelay < c.System.RetryDelay {
+		errs = append(errs, fmt.Errorf("system.retry_max_delay (%s) 不能小于 system.retry_delay (%s)", c.System.RetryMaxDelay, c.System.RetryDelay))
+	}
+	if c.System.RetryJitter < 0 || c.System.RetryJitter > 1 {
+		errs = append(errs, fmt.Errorf("system.retry_jitter 必须在0到1之间，当前为 %v", c.System.RetryJitter))
+	}
+	if c.System.RetryMaxElapsed < 0 {
+		errs = append(errs, fmt.Errorf("system.retry_max_elapsed 不能为负数，当前为 %s", c.System.RetryMaxElapsed))
+	}
+	if c.System.TaskTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("system.task_timeout 必须大于0，当前为 %s", c.System.TaskTimeout))
+	}
+	if c.System.RequestTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("system.request_timeout 必须大于0，当前为 %s", c.System.RequestTimeout))
+	} else if c.System.TaskTimeout > 0 && c.System.RequestTimeout > c.System.TaskTimeout {
+		errs = append(errs, fmt.Errorf("system.request_timeout (%s) 不能大于 system.task_timeout (%s)", c.System.RequestTimeout, c.System.TaskTimeout))
+	}
+	if c.System.MaxConcurrency < 1 {
+		errs = append(errs, fmt.Errorf("system.max_concurrency 必须大于等于1，当前为 %d", c.System.MaxConcurrency))
+	}
+	if c.System.ReloadInterval < 0 {
+		errs = append(errs, fmt.Errorf("system.reload_interval 不能为负数，当前为 %s", c.System.ReloadInterval))
+	}
+	if c.System.EndpointsFile == "" {
+		errs = append(errs, errors.New("system.endpoints_file 不能为空"))
+	}
+	if c.System.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("system.shutdown_timeout 必须大于0，当前为 %s", c.System.ShutdownTimeout))
+	}
+	for name, weight := range c.System.PriorityWeights {
+		if weight <= 0 {
+			errs = append(errs, fmt.Errorf("system.priority_weights.%s 必须大于0，当前为 %d", name, weight))
+		}
+	}
+	if c.System.MaxQueueWait < 0 {
+		errs = append(errs, fmt.Errorf("system.max_queue_wait 不能为负数，当前为 %s", c.System.MaxQueueWait))
+	}
+	if c.System.RunStallTimeout < 0 {
+		errs = append(errs, fmt.Errorf("system.run_stall_timeout 不能为负数，当前为 %s", c.System.RunStallTimeout))
+	}
+
+	if c.Leader.Enabled {
+		if c.Leader.Name == "" {
+			errs = append(errs, errors.New("leader.name 不能为空"))
+		}
+		if c.Leader.RenewInterval <= 0 {
+			errs = append(errs, fmt.Errorf("leader.renew_interval 必须大于0，当前为 %s", c.Leader.RenewInterval))
+		} else if c.Leader.TTL < 2*c.Leader.RenewInterval {
+			errs = append(errs, fmt.Errorf("leader.ttl (%s) 至少为 leader.renew_interval (%s) 的2倍", c.Leader.TTL, c.Leader.RenewInterval))
+		}
+	}
+
+	if c.Queue.Persistent && c.Queue.VisibilityTimeout < Duration(3*time.Second) {
+		errs = append(errs, fmt.Errorf("queue.visibility_timeout 不能小于3s，当前为 %s", c.Queue.VisibilityTimeout))
+	}
+
+	if c.Concurrency.Max < c.System.MaxConcurrency {
+		errs = append(errs, fmt.Errorf("concurrency.max (%d) 不能小于 system.max_concurrency (%d)", c.Concurrency.Max, c.System.MaxConcurrency))
+	}
+	if c.Concurrency.Min < 1 || c.Concurrency.Min > c.Concurrency.Max {
+		errs = append(errs, fmt.Errorf("concurrency.min 必须在1到 concurrency.max 之间，当前为 %d", c.Concurrency.Min))
+	}
+	if c.Concurrency.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("concurrency.interval 必须大于0，当前为 %s", c.Concurrency.Interval))
+	}
+	if c.Concurrency.TargetLatency < 0 {
+		errs = append(errs, fmt.Errorf("concurrency.target_latency 不能为负数，当前为 %s", c.Concurrency.TargetLatency))
+	}
+	if c.Concurrency.MaxFailureRate < 0 || c.Concurrency.MaxFailureRate > 1 {
+		errs = append(errs, fmt.Errorf("concurrency.max_failure_rate 必须在0到1之间，当前为 %v", c.Concurrency.MaxFailureRate))
+	}
+	if c.Concurrency.DecreaseFactor <= 0 || c.Concurrency.DecreaseFactor >= 1 {
+		errs = append(errs, fmt.Errorf("concurrency.decrease_factor 必须大于0且小于1，当前为 %v", c.Concurrency.DecreaseFactor))
+	}
+
+	if c.Backfill.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("backfill.interval 必须大于0，当前为 %s", c.Backfill.Interval))
+	}
+	if c.Backfill.WindowTimeout < 0 {
+		errs = append(errs, fmt.Errorf("backfill.window_timeout 不能为负数，当前为 %s", c.Backfill.WindowTimeout))
+	}
+	if c.Backfill.MaxQueue < 0 {
+		errs = append(errs, fmt.Errorf("backfill.max_queue 不能为负数，当前为 %d", c.Backfill.MaxQueue))
+	}
+
+	if c.Admin.Addr != "" {
+		host, _, err := net.SplitHostPort(c.Admin.Addr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("admin.addr 无效: %v", err))
+		} else if c.Admin.Token == "" && !isLoopback(host) {
+			errs = append(errs, fmt.Errorf("admin.addr (%s) 监听非本机地址时必须通过环境变量 %s 设置访问令牌", c.Admin.Addr, EnvAdminToken))
+		}
+	}
+
+	switch c.Accounts.Source {
+	case AccountSourceFile:
+		if c.Accounts.File == "" {
+			errs = append(errs, errors.New("accounts.file 不能为空"))
+		}
+		if c.Accounts.KeyEnv == "" {
+			errs = append(errs, errors.New("accounts.key_env 不能为空"))
+		}
+	case AccountSourceMongo:
+		if c.Accounts.Collection == "" {
+			errs = append(errs, errors.New("accounts.collection 不能为空"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("accounts.source 只支持 %s 或 %s，当前为 %q", AccountSourceFile, AccountSourceMongo, c.Accounts.Source))
+	}
+	if c.Accounts.Interval < 0 {
+		errs = append(errs, fmt.Errorf("accounts.interval 不能为负数，当前为 %s", c.Accounts.Interval))
+	}
+
+	errs = append(errs, c.validateMongo()...)
+
+	return errors.Join(errs...)
+}
+
+func (c *ScheduleConfig) validateMongo() []error {
+	var errs []error
+	if !strings.HasPrefix(c.Mongo.URI, "mongodb://") && !strings.HasPrefix(c.Mongo.URI, "mongodb+srv://") {
+		errs = append(errs, fmt.Errorf("mongo.uri 必须以 mongodb:// 或 mongodb+srv:// 开头"))
+	}
+	if c.Mongo.Database == "" {
+		errs = append(errs, errors.New("mongo.database 不能为空"))
+	}
+	if c.Mongo.MaxPoolSize > 0 && c.Mongo.MinPoolSize > c.Mongo.MaxPoolSize {
+		errs = append(errs, fmt.Errorf("mongo.min_pool_size (%d) 不能大于 mongo.max_pool_size (%d)", c.Mongo.MinPoolSize, c.Mongo.MaxPoolSize))
+	}
+	if c.Mongo.ConnectTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("mongo.connect_timeout 必须大于0，当前为 %s", c.Mongo.ConnectTimeout))
+	}
+	if c.Mongo.ServerSelectionTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("mongo.server_selection_timeout 必须大于0，当前为 %s", c.Mongo.ServerSelectionTimeout))
+	}
+	switch c.Mongo.ReadConcern {
+	case "", "local", "majority", "available", "linearizable", "snapshot":
+	default:
+		errs = append(errs, fmt.Errorf("mongo.read_concern 无效: %q", c.Mongo.ReadConcern))
+	}
+	if c.Mongo.WriteConcern != "" && c.Mongo.WriteConcern != "majority" {
+		if w, err := strconv.Atoi(c.Mongo.WriteConcern); err != nil || w < 0 {
+			errs = append(errs, fmt.Errorf("mongo.write_concern 只支持 majority 或非负整数，当前为 %q", c.Mongo.WriteConcern))
+		}
+	}
+	if c.Mongo.TLSCAFile != "" && !c.Mongo.TLS {
+		errs = append(errs, errors.New("mongo.tls_ca_file 需要同时开启 mongo.tls"))
+	}
+	if c.Mongo.ConnectRetries < 0 {
+		errs = append(errs, fmt.Errorf("mongo.connect_retries 不能为负数，当前为 %d", c.Mongo.ConnectRetries))
+	}
+	if c.Mongo.RetryBackoff <= 0 {
+		errs = append(errs, fmt.Errorf("mongo.retry_backoff 必须大于0，当前为 %s", c.Mongo.RetryBackoff))
+	}
+	if c.Mongo.MaxRetryBackoff < c.Mongo.RetryBackoff {
+		errs = append(errs, fmt.Errorf("mongo.max_retry_backoff (%s) 不能小于 mongo.retry_backoff (%s)", c.Mongo.MaxRetryBackoff, c.Mongo.RetryBackoff))
+	}
+	return errs
+}
+
+// ScheduleOff 表示停用该任务
+const ScheduleOff = schedule.Off
+
+// ValidateSchedule 校验任务频率，支持 cron 表达式、hourly/daily/weekly/monthly、时间间隔及 off，格式见 schedule.Parse
+func ValidateSchedule(spec string) error {
+	if spec == ScheduleOff {
+		return nil
+	}
+	_, err := schedule.Parse(spec)
+	return err
+}
+
+// isLoopback 判断监听地址是否只允许本机访问
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func envString(name string, target *string) {
+	if value, ok := os.LookupEnv(name); ok {
+		*target = strings.TrimSpace(value)
+	}
+}
+
+func envInt(name string, target *int) error {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil
+	}
+	parsed, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("环境变量 %s 不是有效整数: %q", name, value)
+	}
+	*target = parsed
+	return nil
+}
+
+func envBool(name string, target *bool) error {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil
+	}
+	parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("环境变量 %s 不是有效布尔值: %q", name, value)
+	}
+	*target = parsed
+	return nil
+}
+
+func envDuration(name string, target *Duration) error {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil
+	}
+	parsed, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("环境变量 %s 不是有效时长: %q", name, value)
+	}
+	*target = Duration(parsed)
+	return nil
+}
@@ -6,114 +6,197 @@ import (
 
 // ScheduleConfig 定时任务配置
 type ScheduleConfig struct {
-	// 主要数据采集任务配置
-	MainTasks struct {
-		Author  string `json:"author"`  // 达人数据采集频率
-		Brand   string `json:"brand"`   // 品牌数据采集频率
-		Live    string `json:"live"`    // 直播数据采集频率
-		Product string `json:"product"` // 商品数据采集频率
-		Store   string `json:"store"`   // 店铺数据采集频率
-		Video   string `json:"video"`   // 视频数据采集频率
-	} `json:"main_tasks"`
-
-	// 排名数据采集任务配置
-	RankTasks struct {
-		AuthorFansIncreaseRank   string `json:"author_fans_increase_rank"`    // 达人涨粉榜
-		AuthorFansDecreaseRank   string `json:"author_fans_decrease_rank"`    // 达人掉粉榜
-		AuthorPotentialRank      string `json:"author_potential_rank"`        // 达人带货潜力榜
-		ProductHotSaleRank       string `json:"product_hot_sale_rank"`        // 商品热销榜
-		ProductRealTimeSalesRank string `json:"product_real_time_sales_rank"` // 商品实时销量榜
-		LiveAuthorSalesRank      string `json:"live_author_sales_rank"`       // 直播达人带货榜
-		LiveHotPushRank          string `json:"live_hot_push_rank"`           // 直播热推榜
-		HotVideoRank             string `json:"hot_video_rank"`               // 热门视频榜
-		EcommerceVideoRank       string `json:"ecommerce_video_rank"`         // 电商视频榜
-		VideoHotPush             string `json:"video_hot_push"`               // 视频热推
-		HotSaleShop              string `json:"hot_sale_shop"`                // 热销小店
-		SiteHourlyRank           string `json:"site_hourly_rank"`             // 全站小时榜
-		SalesHourlyRank          string `json:"sales_hourly_rank"`            // 带货小时榜
-		RealTimeHotSpot          string `json:"real_time_hot_spot"`           // 实时热点
-		SoaringHotSpot           string `json:"soaring_hot_spot"`             // 飙升热点
-		ExploreHotBurst          string `json:"explore_hot_burst"`            // 探测爆款
-	} `json:"rank_tasks"`
+	// 主要数据采集任务执行频率，键为接口目录中的接口名称，未配置的任务使用目录中的默认频率
+	MainTasks map[string]string `json:"main_tasks"`
+
+	// 排名数据采集任务执行频率
+	RankTasks map[string]string `json:"rank_tasks"`
+
+	// 任务调度策略，键为接口名称
+	TaskPolicies map[string]TaskPolicy `json:"task_policies"`
 
 	// 系统配置
 	System struct {
-		MaxRetries     int           `json:"max_retries"`     // 最大重试次数
-		RetryDelay     time.Duration `json:"retry_delay"`     // 重试延迟
-		TaskTimeout    time.Duration `json:"task_timeout"`    // 任务超时时间
-		MaxConcurrency int           `json:"max_concurrency"` // 最大并发数
+		MaxRetries      int      `json:"max_retries"`       // 单个任务最多尝试次数
+		RetryDelay      Duration `json:"retry_delay"`       // 第一次重试前的等待时间，之后每次翻倍
+		RetryMaxDelay   Duration `json:"retry_max_delay"`   // 单次重试等待时间上限
+		RetryJitter     float64  `json:"retry_jitter"`      // 重试等待时间随机减少的比例，0 到 1
+		RetryMaxElapsed Duration `json:"retry_max_elapsed"` // 任务从第一次尝试开始超过该时间后不再重试，0 表示不限制
+		TaskTimeout     Duration `json:"task_timeout"`      // 任务超时时间
+		RequestTimeout  Duration `json:"request_timeout"`   // 单次请求超时时间
+		MaxConcurrency  int      `json:"max_concurrency"`   // 最大并发数
+		ReloadInterval  Duration `json:"reload_interval"`   // 配置文件检查间隔，0 表示不热加载
+		EndpointsFile   string   `json:"endpoints_file"`    // 接口目录文件
+		ShutdownTimeout Duration `json:"shutdown_timeout"`  // 关闭时等待执行中任务结束的最长时间，超时后取消
+
+		PriorityWeights map[string]int `json:"priority_weights"` // 任务优先级权重，键为 rank、list、detail、backfill，未配置的使用默认权重
+		MaxQueueWait    Duration       `json:"max_queue_wait"`   // 任务排队超过该时间时不论优先级立即执行，0 表示不限制
+
+		RunStallTimeout Duration `json:"run_stall_timeout"` // 采集批次超过该时间没有进度时标记为 stalled，不再等待剩余任务，0 表示不限制
 	} `json:"system"`
+
+	// 主节点选举配置，多实例部署时只有主节点触发定时任务
+	Leader struct {
+		Enabled       bool     `json:"enabled"`        // 是否开启选举，单实例部署无需开启
+		Name          string   `json:"name"`           // 租约名称，同一组实例使用相同名称
+		InstanceID    string   `json:"instance_id"`    // 实例标识，为空时使用主机名和进程号
+		TTL           Duration `json:"ttl"`            // 租约有效期，主节点异常退出后备用节点最迟在该时间后接管
+		RenewInterval Duration `json:"renew_interval"` // 续约及备用节点尝试接管的间隔
+	} `json:"leader"`
+
+	// 持久化任务队列配置
+	Queue struct {
+		Persistent        bool     `json:"persistent"`         // 是否将任务队列保存到 task_queue 集合，停止或崩溃后恢复未完成的任务
+		VisibilityTimeout Duration `json:"visibility_timeout"` // 任务租约有效期，实例停止续约超过该时间后任务由其他实例或重启后的进程接管
+	} `json:"queue"`
+
+	// 并发配置，初始并发数为 system.max_concurrency，运行中可通过管理接口调整
+	Concurrency struct {
+		Max            int      `json:"max"`              // 运行时调整并发数的上限
+		Adaptive       bool     `json:"adaptive"`         // 是否开启自适应并发
+		Min            int      `json:"min"`              // 自适应调整的下限
+		Interval       Duration `json:"interval"`         // 自适应调整周期
+		TargetLatency  Duration `json:"target_latency"`   // 平均每次请求耗时超过该值时不再增加并发，0 表示不限制
+		MaxFailureRate float64  `json:"max_failure_rate"` // 失败率超过该值时减少并发
+		DecreaseFactor float64  `json:"decrease_factor"`  // 出现限流、超时或失败率过高时并发数乘以该值
+	} `json:"concurrency"`
+
+	// 历史数据回填配置，回填任务通过 backfill 命令创建，由主节点逐个窗口执行
+	Backfill struct {
+		Interval      Duration `json:"interval"`       // 相邻两个窗口之间的最短间隔
+		WindowTimeout Duration `json:"window_timeout"` // 单个窗口的最长采集时间，超过后标记为失败并继续下一个窗口，0 表示不限制
+		MaxQueue      int      `json:"max_queue"`      // 任务队列长度超过该值时暂停加入新窗口，避免影响定时任务
+	} `json:"backfill"`
+
+	// 管理接口配置
+	Admin struct {
+		Addr  string `json:"addr"` // 监听地址，如 127.0.0.1:8089，为空表示不启动
+		Token string `json:"-"`    // 访问令牌，只能通过环境变量 COLLY_ADMIN_TOKEN 设置
+	} `json:"admin"`
+
+	// 账号配置
+	Accounts struct {
+		Source     string   `json:"source"`     // 账号来源: file 或 mongo
+		File       string   `json:"file"`       // 加密账号文件路径
+		KeyEnv     string   `json:"key_env"`    // 保存账号文件密钥的环境变量名
+		Collection string   `json:"collection"` // 账号集合名
+		Interval   Duration `json:"interval"`   // 账号轮换间隔
+	} `json:"accounts"`
+
+	// MongoDB 配置
+	Mongo struct {
+		URI                    string   `json:"uri"`                      // 连接地址
+		Database               string   `json:"database"`                 // 数据库名
+		Username               string   `json:"username"`                 // 用户名，为空表示不认证
+		Password               string   `json:"-"`                        // 密码，只能通过环境变量 COLLY_MONGO_PASSWORD 设置
+		AuthSource             string   `json:"auth_source"`              // 认证数据库
+		MaxPoolSize            uint64   `json:"max_pool_size"`            // 最大连接数
+		MinPoolSize            uint64   `json:"min_pool_size"`            // 最小连接数
+		ConnectTimeout         Duration `json:"connect_timeout"`          // 连接超时时间
+		ServerSelectionTimeout Duration `json:"server_selection_timeout"` // 选择节点超时时间
+		ReadConcern            string   `json:"read_concern"`             // 读关注级别，为空使用服务端默认
+		WriteConcern           string   `json:"write_concern"`            // 写关注，majority 或确认节点数
+		TLS                    bool     `json:"tls"`                      // 是否启用TLS
+		TLSCAFile              string   `json:"tls_ca_file"`              // TLS CA证书文件
+		TLSInsecure            bool     `json:"tls_insecure"`             // 跳过证书校验，仅用于测试
+		ConnectRetries         int      `json:"connect_retries"`          // 启动时最大连接尝试次数，0 表示一直重试
+		RetryBackoff           Duration `json:"retry_backoff"`            // 首次重试等待时间
+		MaxRetryBackoff        Duration `json:"max_retry_backoff"`        // 重试等待时间上限
+	} `json:"mongo"`
+}
+
+// TaskPolicy 单个任务的调度策略
+type TaskPolicy struct {
+	Misfire string `json:"misfire"` // 重启等原因错过执行时间后的处理: run_once（默认，立即补跑一次）、run_all（逐次补跑）、skip（不补跑）
+	Overlap string `json:"overlap"` // 上一次执行未结束时再次到期的处理: forbid（默认，跳过）、queue（排队一次）、replace（取消上一次）
+
+	Windows   []string `json:"windows"`   // 每天允许执行的时间段（Asia/Shanghai），如 ["08:00-24:00"]，为空表示全天
+	Blackouts []string `json:"blackouts"` // 不执行的日期，如 ["2026-02-17", "2026-02-15~2026-02-21"]
+	Jitter    Duration `json:"jitter"`    // 每次执行随机推迟的最长时间，避免大量任务同时开始
+
+	DependsOn         []string `json:"depends_on"`         // 上游任务名称，设置后上游任务本轮均成功完成时触发，不再按执行频率执行
+	DependencyTimeout Duration `json:"dependency_timeout"` // 第一个上游任务完成后等待其余上游任务的最长时间，0 表示不限制
 }
 
+// 账号来源
+const (
+	AccountSourceFile  = "file"
+	AccountSourceMongo = "mongo"
+)
+
 // GetDefaultConfig 获取默认配置
 func GetDefaultConfig() *ScheduleConfig {
 	config := &ScheduleConfig{}
 
-	// 主要数据采集任务默认配置
-	config.MainTasks.Author = "6h"  // 每6小时采集一次达人数据
-	config.MainTasks.Brand = "12h"  // 每12小时采集一次品牌数据
-	config.MainTasks.Live = "2h"    // 每2小时采集一次直播数据
-	config.MainTasks.Product = "4h" // 每4小时采集一次商品数据
-	config.MainTasks.Store = "8h"   // 每8小时采集一次店铺数据
-	config.MainTasks.Video = "3h"   // 每3小时采集一次视频数据
-
-	// 排名数据采集任务默认配置
-	config.RankTasks.AuthorFansIncreaseRank = "1h"   // 每小时采集一次
-	config.RankTasks.AuthorFansDecreaseRank = "1h"   // 每小时采集一次
-	config.RankTasks.AuthorPotentialRank = "1h"      // 每小时采集一次
-	config.RankTasks.ProductHotSaleRank = "1h"       // 每小时采集一次
-	config.RankTasks.ProductRealTimeSalesRank = "1h" // 每小时采集一次
-	config.RankTasks.LiveAuthorSalesRank = "1h"      // 每小时采集一次
-	config.RankTasks.LiveHotPushRank = "1h"          // 每小时采集一次
-	config.RankTasks.HotVideoRank = "1h"             // 每小时采集一次
-	config.RankTasks.EcommerceVideoRank = "1h"       // 每小时采集一次
-	config.RankTasks.VideoHotPush = "1h"             // 每小时采集一次
-	config.RankTasks.HotSaleShop = "1h"              // 每小时采集一次
-	config.RankTasks.SiteHourlyRank = "1h"           // 每小时采集一次
-	config.RankTasks.SalesHourlyRank = "1h"          // 每小时采集一次
-	config.RankTasks.RealTimeHotSpot = "1h"          // 每小时采集一次
-	config.RankTasks.SoaringHotSpot = "1h"           // 每小时采集一次
-	config.RankTasks.ExploreHotBurst = "1h"          // 每小时采集一次
+	// 任务执行频率默认使用接口目录中的配置
+	config.MainTasks = make(map[string]string)
+	config.RankTasks = make(map[string]string)
+	config.TaskPolicies = make(map[string]TaskPolicy)
 
 	// 系统默认配置
 	config.System.MaxRetries = 3
-	config.System.RetryDelay = 5 * time.Second
-	config.System.TaskTimeout = 5 * time.Minute
+	config.System.RetryDelay = Duration(5 * time.Second)
+	config.System.RetryMaxDelay = Duration(time.Minute)
+	config.System.RetryJitter = 0.2
+	config.System.RetryMaxElapsed = Duration(10 * time.Minute)
+	config.System.TaskTimeout = Duration(5 * time.Minute)
+	config.System.RequestTimeout = Duration(30 * time.Second)
 	config.System.MaxConcurrency = 3
+	config.System.ReloadInterval = Duration(10 * time.Second)
+	config.System.EndpointsFile = "config/endpoints.json"
+	config.System.MaxQueueWait = Duration(10 * time.Minute)
+	config.System.ShutdownTimeout = Duration(30 * time.Second)
+	config.System.RunStallTimeout = Duration(30 * time.Minute)
 
-	return config
-}
+	// 主节点选举默认配置
+	config.Leader.Name = "scheduler"
+	config.Leader.TTL = Duration(15 * time.Second)
+	config.Leader.RenewInterval = Duration(5 * time.Second)
 
-// GetMainTaskSchedules 获取主要任务的调度配置
-func (c *ScheduleConfig) GetMainTaskSchedules() map[string]string {
-	return map[string]string{
-		"author":  c.MainTasks.Author,
-		"brand":   c.MainTasks.Brand,
-		"live":    c.MainTasks.Live,
-		"product": c.MainTasks.Product,
-		"store":   c.MainTasks.Store,
-		"video":   c.MainTasks.Video,
-	}
+	// 持久化任务队列默认配置
+	config.Queue.Persistent = true
+	config.Queue.VisibilityTimeout = Duration(2 * time.Minute)
+
+	// 并发默认配置
+	config.Concurrency.Max = 10
+	config.Concurrency.Min = 1
+	config.Concurrency.Interval = Duration(30 * time.Second)
+	config.Concurrency.TargetLatency = Duration(20 * time.Second)
+	config.Concurrency.MaxFailureRate = 0.2
+	config.Concurrency.DecreaseFactor = 0.5
+
+	// 回填默认配置
+	config.Backfill.Interval = Duration(30 * time.Second)
+	config.Backfill.WindowTimeout = Duration(2 * time.Hour)
+	config.Backfill.MaxQueue = 100
+
+	// 账号默认配置
+	config.Accounts.Source = AccountSourceFile
+	config.Accounts.File = "config/accounts.enc"
+	config.Accounts.KeyEnv = "COLLY_ACCOUNT_KEY"
+	config.Accounts.Collection = "accounts"
+	config.Accounts.Interval = Duration(3 * time.Second)
+
+	// MongoDB 默认配置
+	config.Mongo.URI = "mongodb://127.0.0.1:27017"
+	config.Mongo.Database = "kaogujia"
+	config.Mongo.AuthSource = "admin"
+	config.Mongo.MaxPoolSize = 20
+	config.Mongo.ConnectTimeout = Duration(10 * time.Second)
+	config.Mongo.ServerSelectionTimeout = Duration(10 * time.Second)
+	config.Mongo.RetryBackoff = Duration(time.Second)
+	config.Mongo.MaxRetryBackoff = Duration(30 * time.Second)
+
+	return config
 }
 
-// GetRankTaskSchedules 获取排名任务的调度配置
-func (c *ScheduleConfig) GetRankTaskSchedules() map[string]string {
-	return map[string]string{
-		"author_fans_increase_rank":    c.RankTasks.AuthorFansIncreaseRank,
-		"author_fans_decrease_rank":    c.RankTasks.AuthorFansDecreaseRank,
-		"author_potential_rank":        c.RankTasks.AuthorPotentialRank,
-		"product_hot_sale_rank":        c.RankTasks.ProductHotSaleRank,
-		"product_real_time_sales_rank": c.RankTasks.ProductRealTimeSalesRank,
-		"live_author_sales_rank":       c.RankTasks.LiveAuthorSalesRank,
-		"live_hot_push_rank":           c.RankTasks.LiveHotPushRank,
-		"hot_video_rank":               c.RankTasks.HotVideoRank,
-		"ecommerce_video_rank":         c.RankTasks.EcommerceVideoRank,
-		"video_hot_push":               c.RankTasks.VideoHotPush,
-		"hot_sale_shop":                c.RankTasks.HotSaleShop,
-		"site_hourly_rank":             c.RankTasks.SiteHourlyRank,
-		"sales_hourly_rank":            c.RankTasks.SalesHourlyRank,
-		"real_time_hot_spot":           c.RankTasks.RealTimeHotSpot,
-		"soaring_hot_spot":             c.RankTasks.SoaringHotSpot,
-		"explore_hot_burst":            c.RankTasks.ExploreHotBurst,
+// GetTaskSchedules 获取所有任务的执行频率配置，键为接口名称
+func (c *ScheduleConfig) GetTaskSchedules() map[string]string {
+	schedules := make(map[string]string, len(c.MainTasks)+len(c.RankTasks))
+	for _, group := range []map[string]string{c.MainTasks, c.RankTasks} {
+		for key, schedule := range group {
+			schedules[key] = schedule
+		}
 	}
+	return schedules
 }
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Watcher 定期检查配置文件，内容变化且校验通过时回调 onChange
+type Watcher struct {
+	path     string
+	interval time.Duration
+	onChange func(*ScheduleConfig)
+
+	current  *ScheduleConfig
+	modTime  time.Time // 已生效配置文件的修改时间
+	digest   []byte    // 已生效配置文件的内容摘要
+	rejected []byte    // 最近一次被拒绝的内容摘要，内容不变时不再重复加载
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewWatcher 创建配置文件监听器，current 为当前生效的配置
+func NewWatcher(path string, interval time.Duration, current *ScheduleConfig, onChange func(*ScheduleConfig)) *Watcher {
+	return &Watcher{
+		path:     path,
+		interval: interval,
+		onChange: onChange,
+		current:  current,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 启动监听
+func (w *Watcher) Start() {
+	if info, err := os.Stat(w.path); err == nil {
+		w.modTime = info.ModTime()
+	}
+	if data, err := os.ReadFile(w.path); err == nil {
+		sum := sha256.Sum256(data)
+		w.digest = sum[:]
+	}
+
+	log.Printf("启动配置文件监听: %s, 检查间隔: %v", w.path, w.interval)
+	w.wg.Add(1)
+	go w.run()
+}
+
+// Stop 停止监听
+func (w *Watcher) Stop() {
+	close(w.stop)
+	w.wg.Wait()
+}
+
+func (w *Watcher) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+// check 文件修改时间和内容均发生变化时重新加载。
+// 修改时间和摘要只在加载成功后更新，被拒绝的文件之后即使修改时间不变，内容变化时仍会重新加载
+func (w *Watcher) check() {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return
+	}
+	if info.ModTime().Equal(w.modTime) {
+		return
+	}
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		log.Printf("读取配置文件失败: %s, 错误: %v", w.path, err)
+		return
+	}
+	sum := sha256.Sum256(data)
+	if bytes.Equal(sum[:], w.digest) {
+		w.modTime = info.ModTime()
+		return
+	}
+	if bytes.Equal(sum[:], w.rejected) {
+		return
+	}
+
+	log.Printf("[配置变更] 检测到配置文件变化: %s", w.path)
+	config, err := parseConfig(w.path, data)
+	if err != nil {
+		w.rejected = sum[:]
+		log.Printf("[配置变更] 拒绝无效配置，继续使用当前配置: %v", err)
+		return
+	}
+	w.modTime, w.digest, w.rejected = info.ModTime(), sum[:], nil
+
+	if w.current != nil && !reflect.DeepEqual(w.current.System, config.System) {
+		log.Printf("[配置变更] system 配置已修改，需重启后生效")
+	}
+	if w.current != nil && w.current.Concurrency != config.Concurrency {
+		log.Printf("[配置变更] concurrency 配置已修改，需重启后生效，运行中可通过管理接口调整并发数")
+	}
+	if w.current != nil && w.current.Accounts != config.Accounts {
+		log.Printf("[配置变更] accounts 配置已修改，需重启后生效")
+	}
+	if w.current != nil && w.current.Mongo != config.Mongo {
+		log.Printf("[配置变更] mongo 配置已修改，需重启后生效")
+	}
+	w.current = config
+	w.onChange(config)
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestWatcherRetriesRejectedFile 被拒绝的配置之后以相同的修改时间写入有效内容时仍会重新加载
+func TestWatcherRetriesRejectedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	mtime := time.Now().Add(time.Minute).Truncate(time.Second)
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var applied int
+	w := NewWatcher(path, time.Second, nil, func(*ScheduleConfig) { applied++ })
+	write(`{"system": `)
+	w.check()
+	w.check()
+	if applied != 0 {
+		t.Fatal("无效配置不应生效")
+	}
+
+	write(`{}`)
+	w.check()
+	if applied != 1 {
+		t.Fatalf("修改时间不变但内容变为有效配置时应重新加载，生效 %d 次", applied)
+	}
+	w.check()
+	if applied != 1 {
+		t.Errorf("已生效的配置不应重复加载，生效 %d 次", applied)
+	}
+}
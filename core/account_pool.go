@@ -1,8 +1,9 @@
 package core
 
 import (
+	"collyDemo/pkg/utils"
+	"context"
 	"log"
-	"math/rand"
 	"sync"
 	"time"
 )
@@ -14,6 +15,19 @@ type AccountPool struct {
 	mu         sync.Mutex
 }
 
+// NewAccountPoolFromSource 从账号来源加载账号并创建账号池
+func NewAccountPoolFromSource(source AccountSource, interval time.Duration) (*AccountPool, error) {
+	accounts, err := source.LoadAccounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, acc := range accounts {
+		log.Printf("加载账号: %s, 代理: %s, 限速: %d/分钟, 延迟: %v~%v",
+			acc, utils.RedactURL(acc.Proxy), acc.RateLimit.Limit(), acc.MinDelay, acc.MaxDelay)
+	}
+	return NewAccountPool(accounts, interval), nil
+}
+
 func NewAccountPool(accounts []*Account, interval time.Duration) *AccountPool {
 	return &AccountPool{
 		accounts: accounts,
@@ -21,39 +35,56 @@ func NewAccountPool(accounts []*Account, interval time.Duration) *AccountPool {
 	}
 }
 
-func (p *AccountPool) GetAccount() *Account {
+// GetAccount 获取一个可用账号，所有账号都在冷却中时等待，ctx 取消时返回错误
+func (p *AccountPool) GetAccount(ctx context.Context) (*Account, error) {
+	return p.GetAccountExcluding(ctx, nil)
+}
+
+// GetAccountExcluding 获取一个不在 exclude 中的可用账号，exclude 的键为账号ID；
+// 所有账号都在 exclude 中时忽略 exclude。所有账号都在冷却中时等待，ctx 取消时返回错误
+func (p *AccountPool) GetAccountExcluding(ctx context.Context, exclude map[string]bool) (*Account, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	if len(exclude) > 0 && !p.hasAccountOutside(exclude) {
+		log.Printf("所有账号都已排除，忽略排除条件")
+		exclude = nil
+	}
+
 	startIdx := p.currentIdx
 
 	for {
 		p.currentIdx = (p.currentIdx + 1) % len(p.accounts)
 		acc := p.accounts[p.currentIdx]
 
-		acc.mu.Lock()
-		now := time.Now()
-		elapsed := now.Sub(acc.LastUsed)
+		if !exclude[acc.ID] {
+			acc.mu.Lock()
+			now := time.Now()
+			elapsed := now.Sub(acc.LastUsed)
 
-		// 计算所需延迟时间
-		requiredDelay := acc.MinDelay + time.Duration(rand.Int63n(int64(acc.MaxDelay-acc.MinDelay)))
+			// 计算所需延迟时间
+			requiredDelay := acc.requiredDelay()
 
-		if elapsed >= requiredDelay {
-			acc.LastUsed = now
+			if elapsed >= requiredDelay {
+				acc.LastUsed = now
+				acc.mu.Unlock()
+				log.Printf("获取账号成功: %s, 延迟: %v", acc, elapsed)
+				return acc, nil
+			}
 			acc.mu.Unlock()
-			log.Printf("获取账号成功: %s, 延迟: %v", acc.UserName, elapsed)
-			return acc
 		}
-		acc.mu.Unlock()
 
 		// 如果转了一圈都没找到可用账号
 		if p.currentIdx == startIdx {
 			// 找出最早可用的账号和等待时间
 			var minWaitTime time.Duration = time.Hour
 			for _, acc := range p.accounts {
+				if exclude[acc.ID] {
+					continue
+				}
 				acc.mu.Lock()
 				elapsed := time.Since(acc.LastUsed)
-				requiredDelay := acc.MinDelay + time.Duration(rand.Int63n(int64(acc.MaxDelay-acc.MinDelay)))
+				requiredDelay := acc.requiredDelay()
 				waitTime := requiredDelay - elapsed
 				if waitTime > 0 && waitTime < minWaitTime {
 					minWaitTime = waitTime
@@ -65,15 +96,65 @@ func (p *AccountPool) GetAccount() *Account {
 				log.Printf("所有账号都在冷却中，等待 %v", minWaitTime)
 				// 解锁后再等待，避免长时间持有锁
 				p.mu.Unlock()
-				time.Sleep(minWaitTime)
+				ok := sleepContext(ctx, minWaitTime)
 				p.mu.Lock()
+				if !ok {
+					return nil, ctx.Err()
+				}
 				// 等待后重新开始循环
 				continue
 			}
-			break
+			// 账号已到可用时间，重新检查一圈
 		}
 	}
+}
 
-	// 如果还是没有可用账号，递归重试
-	return p.GetAccount()
+// HasAccountOutside 账号池中是否有不在 exclude 中的账号
+func (p *AccountPool) HasAccountOutside(exclude map[string]bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.hasAccountOutside(exclude)
+}
+
+func (p *AccountPool) hasAccountOutside(exclude map[string]bool) bool {
+	for _, acc := range p.accounts {
+		if !exclude[acc.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// Accounts 返回账号池中的所有账号
+func (p *AccountPool) Accounts() []*Account {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]*Account(nil), p.accounts...)
+}
+
+// Status 获取账号状态，敏感字段已脱敏
+func (p *AccountPool) Status() []map[string]interface{} {
+	p.mu.Lock()
+	accounts := append([]*Account(nil), p.accounts...)
+	p.mu.Unlock()
+
+	status := make([]map[string]interface{}, 0, len(accounts))
+	for _, acc := range accounts {
+		acc.mu.Lock()
+		item := map[string]interface{}{
+			"id":        acc.ID,
+			"username":  utils.MaskSecret(acc.UserName),
+			"proxy":     utils.RedactURL(acc.Proxy),
+			"last_used": acc.LastUsed,
+			"min_delay": acc.MinDelay,
+			"max_delay": acc.MaxDelay,
+		}
+		if acc.RateLimit != nil {
+			item["rate_limit"] = acc.RateLimit.Limit()
+		}
+		acc.mu.Unlock()
+		status = append(status, item)
+	}
+	return status
 }
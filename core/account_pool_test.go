@@ -0,0 +1,42 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestAccountWaitCanceled 等待账号冷却和速率限制时 ctx 取消立即返回
+func TestAccountWaitCanceled(t *testing.T) {
+	acc := &Account{ID: "a", LastUsed: time.Now(), MinDelay: time.Minute, MaxDelay: time.Minute}
+	pool := NewAccountPool([]*Account{acc}, 0)
+	limiter := NewRateLimiter(1)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		wait func(ctx context.Context) error
+	}{
+		{"账号冷却", func(ctx context.Context) error {
+			_, err := pool.GetAccount(ctx)
+			return err
+		}},
+		{"速率限制", limiter.Wait},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			if err := tt.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("返回 %v，期望 %v", err, context.DeadlineExceeded)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("ctx 取消后等待了 %v", elapsed)
+			}
+		})
+	}
+}
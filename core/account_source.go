@@ -0,0 +1,201 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"collyDemo/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// 账号默认参数
+const (
+	DefaultAccountRateLimit = 60
+	DefaultAccountMinDelay  = 2 * time.Second
+	DefaultAccountMaxDelay  = 5 * time.Second
+)
+
+// AccountSource 账号来源
+type AccountSource interface {
+	LoadAccounts() ([]*Account, error)
+}
+
+// AccountConfig 账号配置，账号文件和数据库中的存储格式
+type AccountConfig struct {
+	ID        string `json:"id"`
+	UserName  string `json:"username"`
+	Password  string `json:"password"`
+	Token     string `json:"token"`
+	Proxy     string `json:"proxy,omitempty"`
+	RateLimit int    `json:"rate_limit,omitempty"` // 每分钟最大请求数，默认60
+	MinDelay  string `json:"min_delay,omitempty"`  // 两次使用之间的最小间隔，默认 "2s"
+	MaxDelay  string `json:"max_delay,omitempty"`  // 两次使用之间的最大间隔，默认 "5s"
+	Disabled  bool   `json:"disabled,omitempty"`
+}
+
+// NewAccount 根据配置创建账号，未填写的参数使用默认值
+func NewAccount(cfg AccountConfig) (*Account, error) {
+	if cfg.ID == "" {
+		return nil, errors.New("账号ID不能为空")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("账号 %s 缺少token", cfg.ID)
+	}
+
+	minDelay, err := parseAccountDelay(cfg.MinDelay, DefaultAccountMinDelay)
+	if err != nil {
+		return nil, fmt.Errorf("账号 %s min_delay 无效: %v", cfg.ID, err)
+	}
+	maxDelay, err := parseAccountDelay(cfg.MaxDelay, DefaultAccountMaxDelay)
+	if err != nil {
+		return nil, fmt.Errorf("账号 %s max_delay 无效: %v", cfg.ID, err)
+	}
+	if maxDelay < minDelay {
+		return nil, fmt.Errorf("账号 %s max_delay (%v) 小于 min_delay (%v)", cfg.ID, maxDelay, minDelay)
+	}
+
+	rateLimit := cfg.RateLimit
+	if rateLimit == 0 {
+		rateLimit = DefaultAccountRateLimit
+	}
+	if rateLimit < 0 {
+		return nil, fmt.Errorf("账号 %s rate_limit 不能为负数", cfg.ID)
+	}
+
+	return &Account{
+		ID:        cfg.ID,
+		UserName:  cfg.UserName,
+		Password:  cfg.Password,
+		Proxy:     cfg.Proxy,
+		Token:     cfg.Token,
+		LastUsed:  time.Now().Add(-maxDelay),
+		RateLimit: NewRateLimiter(rateLimit),
+		MinDelay:  minDelay,
+		MaxDelay:  maxDelay,
+	}, nil
+}
+
+func parseAccountDelay(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	delay, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if delay < 0 {
+		return 0, errors.New("不能为负数")
+	}
+	return delay, nil
+}
+
+// newAccounts 批量创建账号，跳过停用的账号，账号ID重复时返回错误
+func newAccounts(configs []AccountConfig) ([]*Account, error) {
+	accounts := make([]*Account, 0, len(configs))
+	seen := make(map[string]bool, len(configs))
+	for _, cfg := range configs {
+		if cfg.ID != "" && seen[cfg.ID] {
+			return nil, fmt.Errorf("账号ID重复: %s", cfg.ID)
+		}
+		seen[cfg.ID] = true
+		if cfg.Disabled {
+			continue
+		}
+		acc, err := NewAccount(cfg)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, acc)
+	}
+	if len(accounts) == 0 {
+		return nil, errors.New("没有可用账号")
+	}
+	return accounts, nil
+}
+
+// FileAccountSource 从加密文件加载账号，文件内容为 AES-256-GCM 加密的 []AccountConfig JSON
+type FileAccountSource struct {
+	Path string
+	Key  []byte
+}
+
+// NewFileAccountSource 创建加密文件账号来源，密钥从环境变量 keyEnv 读取
+func NewFileAccountSource(path, keyEnv string) (*FileAccountSource, error) {
+	key := os.Getenv(keyEnv)
+	if key == "" {
+		return nil, fmt.Errorf("未设置账号文件密钥环境变量 %s", keyEnv)
+	}
+	return &FileAccountSource{
+		Path: path,
+		Key:  utils.ParseKey(key),
+	}, nil
+}
+
+// LoadAccounts 解密并加载账号
+func (s *FileAccountSource) LoadAccounts() ([]*Account, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("读取账号文件失败: %w", err)
+	}
+	plaintext, err := utils.DecryptWithKey(s.Key, string(data))
+	if err != nil {
+		return nil, fmt.Errorf("解密账号文件失败 %s: %w", s.Path, err)
+	}
+
+	var configs []AccountConfig
+	if err := json.Unmarshal(plaintext, &configs); err != nil {
+		return nil, fmt.Errorf("解析账号文件失败 %s: %w", s.Path, err)
+	}
+	return newAccounts(configs)
+}
+
+// EncryptAccountFile 加密明文账号文件，用于生成 FileAccountSource 读取的文件
+func EncryptAccountFile(plaintext, key []byte) (string, error) {
+	var configs []AccountConfig
+	if err := json.Unmarshal(plaintext, &configs); err != nil {
+		return "", fmt.Errorf("解析账号文件失败: %w", err)
+	}
+	if _, err := newAccounts(configs); err != nil {
+		return "", err
+	}
+	return utils.EncryptWithKey(key, plaintext)
+}
+
+// MongoAccountSource 从 MongoDB 集合加载账号
+type MongoAccountSource struct {
+	dao *mongodb.AccountDAO
+}
+
+// NewMongoAccountSource 创建数据库账号来源
+func NewMongoAccountSource(dao *mongodb.AccountDAO) *MongoAccountSource {
+	return &MongoAccountSource{dao: dao}
+}
+
+// LoadAccounts 加载所有启用的账号
+func (s *MongoAccountSource) LoadAccounts() ([]*Account, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	records, err := s.dao.ListEnabled(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("读取账号集合失败: %w", err)
+	}
+
+	configs := make([]AccountConfig, 0, len(records))
+	for _, record := range records {
+		configs = append(configs, AccountConfig{
+			ID:        record.ID,
+			UserName:  record.UserName,
+			Password:  record.Password,
+			Token:     record.Token,
+			Proxy:     record.Proxy,
+			RateLimit: record.RateLimit,
+			MinDelay:  record.MinDelay,
+			MaxDelay:  record.MaxDelay,
+		})
+	}
+	return newAccounts(configs)
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewAccounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []AccountConfig
+		want    int    // 创建的账号数
+		err     string // 错误信息中应包含的内容，为空表示创建成功
+	}{
+		{
+			name:    "跳过停用的账号",
+			configs: []AccountConfig{{ID: "a", Token: "t"}, {ID: "b", Token: "t", Disabled: true}},
+			want:    1,
+		},
+		{
+			name:    "账号ID重复",
+			configs: []AccountConfig{{ID: "a", Token: "t"}, {ID: "a", Token: "t2"}},
+			err:     "账号ID重复: a",
+		},
+		{
+			name:    "与停用的账号ID重复",
+			configs: []AccountConfig{{ID: "a", Token: "t", Disabled: true}, {ID: "a", Token: "t"}},
+			err:     "账号ID重复: a",
+		},
+		{
+			name:    "全部停用",
+			configs: []AccountConfig{{ID: "a", Token: "t", Disabled: true}},
+			err:     "没有可用账号",
+		},
+		{
+			name:    "缺少ID",
+			configs: []AccountConfig{{Token: "t"}},
+			err:     "账号ID不能为空",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts, err := newAccounts(tt.configs)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("newAccounts 错误为 %v，应包含 %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newAccounts 返回错误: %v", err)
+			}
+			if len(accounts) != tt.want {
+				t.Errorf("创建 %d 个账号，期望 %d 个", len(accounts), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"collyDemo/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 回填窗口粒度
+const (
+	BackfillStepDay  = "day"  // 按天
+	BackfillStepWeek = "week" // 按自然周（周一至周日），首尾按回填范围截断
+)
+
+// backfillJobPrefix 回填采集批次的定时任务ID前缀
+const backfillJobPrefix = "backfill_"
+
+// backfillDateLayout 回填任务中日期的格式
+const backfillDateLayout = "2006-01-02"
+
+// SplitBackfillWindows 将 [from, to] 按天或按自然周拆分为窗口
+func SplitBackfillWindows(from, to time.Time, step string) ([]DateWindow, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("结束日期 %s 早于开始日期 %s", to.Format(backfillDateLayout), from.Format(backfillDateLayout))
+	}
+
+	windows := make([]DateWindow, 0)
+	for start := from; !start.After(to); {
+		var end time.Time
+		switch step {
+		case BackfillStepDay:
+			end = start
+		case BackfillStepWeek:
+			end = weekStart(start).AddDate(0, 0, 6)
+		default:
+			return nil, fmt.Errorf("窗口粒度只支持 %s 或 %s，当前为 %q", BackfillStepDay, BackfillStepWeek, step)
+		}
+		if end.After(to) {
+			end = to
+		}
+		windows = append(windows, DateWindow{Start: start, End: end})
+		start = end.AddDate(0, 0, 1)
+	}
+	return windows, nil
+}
+
+// NewBackfillJob 创建回填任务，接口必须按日期窗口采集，日期格式为 YYYY-MM-DD 且不晚于今天
+func NewBackfillJob(catalog *Catalog, endpoint, from, to, step string) (*mongodb.BackfillJob, error) {
+	ep, ok := catalog.Endpoint(endpoint)
+	if !ok {
+		return nil, fmt.Errorf("接口目录中不存在接口: %s", endpoint)
+	}
+	if ep.Window == "" {
+		return nil, fmt.Errorf("接口 %s 未配置日期窗口，不支持回填", endpoint)
+	}
+
+	loc := utils.ShanghaiLocation()
+	start, err := time.ParseInLocation(backfillDateLayout, from, loc)
+	if err != nil {
+		return nil, fmt.Errorf("开始日期 %q 格式应为 YYYY-MM-DD", from)
+	}
+	end, err := time.ParseInLocation(backfillDateLayout, to, loc)
+	if err != nil {
+		return nil, fmt.Errorf("结束日期 %q 格式应为 YYYY-MM-DD", to)
+	}
+	now := time.Now().In(loc)
+	if end.After(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)) {
+		return nil, fmt.Errorf("结束日期 %s 不能晚于今天", to)
+	}
+
+	windows, err := SplitBackfillWindows(start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	job := &mongodb.BackfillJob{
+		ID:       primitive.NewObjectID().Hex(),
+		Endpoint: endpoint,
+		From:     from,
+		To:       to,
+		Step:     step,
+		Status:   mongodb.BackfillPending,
+		Windows:  make([]mongodb.BackfillWindow, len(windows)),
+	}
+	for i, window := range windows {
+		job.Windows[i] = mongodb.BackfillWindow{
+			Start:  window.Start.Format(backfillDateLayout),
+			End:    window.End.Format(backfillDateLayout),
+			Status: mongodb.BackfillPending,
+		}
+	}
+	return job, nil
+}
+
+// BackfillRunner 在主节点上逐个执行回填窗口：
+// 同一时间只采集一个窗口，窗口的采集批次结束后保存断点，间隔 interval 后才开始下一个窗口；
+// 窗口超过 timeout 仍未结束时标记为失败并保存断点，不再等待剩余任务；
+// 窗口任务使用 backfill 优先级，任务队列长度超过 maxQueue 时暂停加入新窗口，保证定时任务优先执行。
+// 进程重启或主节点切换后从断点处继续，未完成的窗口重新采集。
+type BackfillRunner struct {
+	jobs       *mongodb.BackfillJobDAO
+	tasks      *TaskScheduler
+	dispatcher *TaskDispatcher
+	isLeader   func() bool
+	interval   time.Duration
+	timeout    time.Duration // 单个窗口的最长采集时间，0 表示不限制
+	maxQueue   int
+
+	job     *mongodb.BackfillJob        // 正在采集窗口的回填任务，只在主循环中读写
+	runID   string                      // 正在采集窗口的批次ID
+	started time.Time                   // 正在采集窗口的开始时间
+	results map[string]mongodb.CrawlRun // 已结束的回填批次
+	mu      sync.Mutex
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewBackfillRunner 创建回填执行器，isLeader 返回当前实例是否负责执行，timeout 为单个窗口的最长采集时间，0 表示不限制
+func NewBackfillRunner(jobs *mongodb.BackfillJobDAO, tasks *TaskScheduler, dispatcher *TaskDispatcher, isLeader func() bool, interval, timeout time.Duration, maxQueue int) *BackfillRunner {
+	r := &BackfillRunner{
+		jobs:       jobs,
+		tasks:      tasks,
+		dispatcher: dispatcher,
+		isLeader:   isLeader,
+		interval:   interval,
+		timeout:    timeout,
+		maxQueue:   maxQueue,
+		results:    make(map[string]mongodb.CrawlRun),
+		stop:       make(chan struct{}),
+	}
+	dispatcher.Runs().OnFinish(r.onRunFinished)
+	return r
+}
+
+// Start 启动回填执行器
+func (r *BackfillRunner) Start() {
+	log.Printf("启动回填执行器: 窗口间隔=%v, 窗口超时=%v, 队列上限=%d", r.interval, r.timeout, r.maxQueue)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.step()
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止回填执行器，正在采集的窗口在下次启动时重新采集
+func (r *BackfillRunner) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+}
+
+// onRunFinished 记录结束的回填批次
+func (r *BackfillRunner) onRunFinished(run mongodb.CrawlRun) {
+	if !strings.HasPrefix(run.Job, backfillJobPrefix) {
+		return
+	}
+	r.mu.Lock()
+	r.results[run.ID] = run
+	r.mu.Unlock()
+}
+
+// step 检查正在采集的窗口是否结束，空闲时开始下一个窗口
+func (r *BackfillRunner) step() {
+	if !r.isLeader() {
+		if r.job != nil {
+			r.abandonWindow()
+		}
+		return
+	}
+
+	if r.job != nil {
+		if r.timeout > 0 && time.Since(r.started) > r.timeout {
+			r.expireWindow()
+			return
+		}
+		r.mu.Lock()
+		run, finished := r.results[r.runID]
+		delete(r.results, r.runID)
+		r.mu.Unlock()
+		if finished {
+			r.finishWindow(run)
+		}
+		return
+	}
+
+	if queueLen, _, _ := r.dispatcher.TaskStatus(); queueLen > r.maxQueue {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stateTimeout)
+	job, err := r.jobs.NextActive(ctx)
+	cancel()
+	if err != nil || job == nil {
+		return
+	}
+	r.startWindow(job)
+}
+
+// startWindow 将回填任务的下一个窗口加入任务队列
+func (r *BackfillRunner) startWindow(job *mongodb.BackfillJob) {
+	if job.Next >= len(job.Windows) {
+		job.Status = mongodb.BackfillDone
+		r.save(job)
+		return
+	}
+
+	window := &job.Windows[job.Next]
+	runs := r.dispatcher.Runs()
+	runID := runs.Start(backfillJobPrefix+job.ID, job.Endpoint)
+	meta, err := r.windowMeta(job, window)
+	if err == nil {
+		err = r.tasks.AddEndpointTask(job.Endpoint, meta)
+	}
+	if err != nil {
+		runs.Abort(runID, err)
+		log.Printf("回填窗口加入任务队列失败: %s %s~%s, 错误: %v", job.ID, window.Start, window.End, err)
+		window.Status = mongodb.BackfillFailed
+		job.Next++
+		r.save(job)
+		return
+	}
+
+	job.Status = mongodb.BackfillRunning
+	window.Status = mongodb.BackfillRunning
+	window.RunID = runID
+	r.job, r.runID, r.started = job, runID, time.Now()
+	log.Printf("开始回填窗口: %s, 接口: %s, 窗口: %s~%s (%d/%d)", job.ID, job.Endpoint, window.Start, window.End, job.Next+1, len(job.Windows))
+	r.save(job)
+}
+
+// windowMeta 回填窗口写入任务 meta，列表任务使用该窗口而不是接口目录中的窗口表达式
+func (r *BackfillRunner) windowMeta(job *mongodb.BackfillJob, window *mongodb.BackfillWindow) (map[string]interface{}, error) {
+	ep, err := r.tasks.endpoint(job.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	loc := utils.ShanghaiLocation()
+	start, err := time.ParseInLocation(backfillDateLayout, window.Start, loc)
+	if err != nil {
+		return nil, err
+	}
+	end, err := time.ParseInLocation(backfillDateLayout, window.End, loc)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		MetaWindow:    window.Start + "~" + window.End,
+		MetaWindowMin: start.Format(ep.WindowFormat),
+		MetaWindowMax: end.Format(ep.WindowFormat),
+		MetaPriority:  PriorityBackfill,
+	}, nil
+}
+
+// abandonWindow 失去主节点身份，结束正在采集窗口的批次，新的主节点会从断点处重新采集该窗口。
+// 已在队列中的任务继续执行但不再计入该批次
+func (r *BackfillRunner) abandonWindow() {
+	runID := r.runID
+	window := r.job.Windows[r.job.Next]
+	r.job, r.runID = nil, ""
+	log.Printf("失去主节点身份，放弃正在采集的回填窗口: %s~%s, 批次: %s", window.Start, window.End, runID)
+	r.dispatcher.Runs().Fail(runID, errors.New("失去主节点身份，窗口由新的主节点重新采集"))
+
+	r.mu.Lock()
+	delete(r.results, runID)
+	r.mu.Unlock()
+}
+
+// expireWindow 窗口超时，结束采集批次并将窗口标记为失败，已在队列中的任务继续执行但不再计入该窗口
+func (r *BackfillRunner) expireWindow() {
+	runID := r.runID
+	window := r.job.Windows[r.job.Next]
+	log.Printf("回填窗口超过 %v 未完成，标记为失败: %s, 窗口: %s~%s", r.timeout, r.job.ID, window.Start, window.End)
+	r.dispatcher.Runs().Fail(runID, fmt.Errorf("回填窗口超过 %v 未完成", r.timeout))
+
+	r.mu.Lock()
+	run, finished := r.results[runID]
+	delete(r.results, runID)
+	r.mu.Unlock()
+	if !finished {
+		// 批次已不在统计中，例如已因无进度被结束但结果尚未记录
+		run = mongodb.CrawlRun{ID: runID, Status: mongodb.CrawlRunFailed, FinishedAt: time.Now()}
+	}
+	r.finishWindow(run)
+}
+
+// finishWindow 保存窗口结果并推进断点
+func (r *BackfillRunner) finishWindow(run mongodb.CrawlRun) {
+	job := r.job
+	r.job, r.runID = nil, ""
+
+	window := &job.Windows[job.Next]
+	window.Status = mongodb.BackfillDone
+	if run.Status != mongodb.CrawlRunSucceeded {
+		window.Status = mongodb.BackfillFailed
+	}
+	window.Items = run.Items
+	window.FinishedAt = run.FinishedAt
+	job.Next++
+	if job.Next >= len(job.Windows) {
+		job.Status = mongodb.BackfillDone
+	}
+	log.Printf("回填窗口结束: %s, 窗口: %s~%s, 状态: %s, 入库: %d", job.ID, window.Start, window.End, window.Status, window.Items)
+	r.save(job)
+}
+
+// save 保存回填进度，任务已被取消时记录日志
+func (r *BackfillRunner) save(job *mongodb.BackfillJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), stateTimeout)
+	defer cancel()
+	saved, err := r.jobs.SaveProgress(ctx, job)
+	if err != nil {
+		log.Printf("保存回填进度失败: %s, 错误: %v", job.ID, err)
+		return
+	}
+	if !saved {
+		log.Printf("回填任务已取消: %s", job.ID)
+		return
+	}
+	if job.Status == mongodb.BackfillDone {
+		log.Printf("回填任务完成: %s, 接口: %s, 范围: %s~%s", job.ID, job.Endpoint, job.From, job.To)
+	}
+}
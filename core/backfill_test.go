@@ -0,0 +1,107 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"collyDemo/pkg/utils"
+	"testing"
+	"time"
+)
+
+func TestSplitBackfillWindows(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.ParseInLocation(backfillDateLayout, s, utils.ShanghaiLocation())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		step    string
+		want    []string // 各窗口的 "开始~结束"
+		wantErr bool
+	}{
+		{
+			name: "按天",
+			from: "2024-02-28", to: "2024-03-01", step: BackfillStepDay,
+			want: []string{"2024-02-28~2024-02-28", "2024-02-29~2024-02-29", "2024-03-01~2024-03-01"},
+		},
+		{
+			name: "单日",
+			from: "2024-01-03", to: "2024-01-03", step: BackfillStepDay,
+			want: []string{"2024-01-03~2024-01-03"},
+		},
+		{
+			name: "按周，首尾按范围截断",
+			from: "2024-01-03", to: "2024-01-16", step: BackfillStepWeek,
+			want: []string{"2024-01-03~2024-01-07", "2024-01-08~2024-01-14", "2024-01-15~2024-01-16"},
+		},
+		{
+			name: "按周，范围为整周",
+			from: "2024-01-01", to: "2024-01-14", step: BackfillStepWeek,
+			want: []string{"2024-01-01~2024-01-07", "2024-01-08~2024-01-14"},
+		},
+		{
+			name: "按周，从周日开始",
+			from: "2024-01-07", to: "2024-01-08", step: BackfillStepWeek,
+			want: []string{"2024-01-07~2024-01-07", "2024-01-08~2024-01-08"},
+		},
+		{
+			name: "跨年",
+			from: "2024-12-30", to: "2025-01-06", step: BackfillStepWeek,
+			want: []string{"2024-12-30~2025-01-05", "2025-01-06~2025-01-06"},
+		},
+		{name: "结束日期早于开始日期", from: "2024-01-02", to: "2024-01-01", step: BackfillStepDay, wantErr: true},
+		{name: "未知粒度", from: "2024-01-01", to: "2024-01-02", step: "month", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows, err := SplitBackfillWindows(day(tt.from), day(tt.to), tt.step)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("应返回错误，实际返回 %d 个窗口", len(windows))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(windows))
+			for i, w := range windows {
+				got[i] = w.Start.Format(backfillDateLayout) + "~" + w.End.Format(backfillDateLayout)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("窗口为 %v，期望 %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("窗口为 %v，期望 %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+// TestBackfillLeadershipLost 失去主节点身份时结束正在采集窗口的批次，不保留批次结果
+func TestBackfillLeadershipLost(t *testing.T) {
+	d := NewTaskDispatcher(nil, DefaultDispatcherConfig(), nil, nil, nil)
+	r := NewBackfillRunner(nil, nil, d, func() bool { return false }, time.Second, 0, 10)
+	runID := d.Runs().Start(backfillJobPrefix+"job", "endpoint")
+	d.runs.added(&Task{Meta: map[string]interface{}{MetaRunID: runID}})
+	r.job = &mongodb.BackfillJob{ID: "job", Windows: []mongodb.BackfillWindow{{Start: "2024-01-01", End: "2024-01-01"}}}
+	r.runID = runID
+
+	r.step()
+	if r.job != nil || r.runID != "" {
+		t.Error("失去主节点身份后不应再跟踪窗口")
+	}
+	if len(d.Runs().Status()) != 0 {
+		t.Error("正在采集窗口的批次应结束")
+	}
+	if len(r.results) != 0 {
+		t.Errorf("放弃的批次结果不应保留: %v", r.results)
+	}
+}
@@ -0,0 +1,215 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// AdaptiveConcurrency 自适应并发配置：每个周期内没有限流、超时且失败率和平均耗时正常时并发数加 1，
+// 出现 429、请求超时或失败率超过阈值时并发数乘以 DecreaseFactor
+type AdaptiveConcurrency struct {
+	Enabled        bool          // 是否开启，可通过 SetAdaptive 在运行时切换
+	MinConcurrency int           // 自动调整的下限
+	Interval       time.Duration // 调整周期
+	TargetLatency  time.Duration // 平均每次请求耗时超过该值时不再增加并发，0 表示不限制
+	MaxFailureRate float64       // 失败率超过该值时减少并发
+	DecreaseFactor float64       // 减少并发时的乘数，0 到 1
+}
+
+// concurrencyLimiter 可在运行时调整上限的并发限制，工作协程从任务队列取出任务时获取名额
+type concurrencyLimiter struct {
+	limit  int
+	active int
+	notify func() // 归还名额或调整上限后唤醒等待取任务的工作协程
+	mu     sync.Mutex
+}
+
+func newConcurrencyLimiter(limit int) *concurrencyLimiter {
+	return &concurrencyLimiter{limit: limit}
+}
+
+// tryAcquire 获取一个名额，执行中的任务数达到上限时返回 false
+func (l *concurrencyLimiter) tryAcquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active >= l.limit {
+		return false
+	}
+	l.active++
+	return true
+}
+
+// cancel 归还 tryAcquire 获取后未使用的名额，不唤醒工作协程，可在任务队列的锁内调用
+func (l *concurrencyLimiter) cancel() {
+	l.mu.Lock()
+	l.active--
+	l.mu.Unlock()
+}
+
+// release 任务执行结束后归还名额
+func (l *concurrencyLimiter) release() {
+	l.mu.Lock()
+	l.active--
+	l.mu.Unlock()
+	l.wake()
+}
+
+// setLimit 调整上限，减少时已获取名额的工作协程执行完当前任务后才让出
+func (l *concurrencyLimiter) setLimit(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.mu.Unlock()
+	l.wake()
+}
+
+func (l *concurrencyLimiter) wake() {
+	if l.notify != nil {
+		l.notify()
+	}
+}
+
+func (l *concurrencyLimiter) get() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// concurrencyWindow 一个调整周期内的请求统计
+type concurrencyWindow struct {
+	requests   int           // 请求次数，每次重试单独计算
+	failures   int           // 失败次数
+	congestion int           // 429 和请求超时次数
+	latency    time.Duration // 总耗时
+}
+
+// nextConcurrency 根据周期内的请求统计计算新的并发数，backlog 表示是否有排队中的任务
+func nextConcurrency(cfg AdaptiveConcurrency, limit, max int, w concurrencyWindow, backlog bool) (int, string) {
+	if w.requests == 0 {
+		return limit, ""
+	}
+	failureRate := float64(w.failures) / float64(w.requests)
+	switch {
+	case w.congestion > 0 || failureRate > cfg.MaxFailureRate:
+		next := int(float64(limit) * cfg.DecreaseFactor)
+		if next < cfg.MinConcurrency {
+			next = cfg.MinConcurrency
+		}
+		return next, fmt.Sprintf("限流或超时=%d, 失败率=%.0f%%", w.congestion, failureRate*100)
+	case !backlog:
+		return limit, ""
+	case cfg.TargetLatency > 0 && w.latency/time.Duration(w.requests) > cfg.TargetLatency:
+		return limit, ""
+	case limit < max:
+		return limit + 1, fmt.Sprintf("平均耗时=%v, 失败率=%.0f%%", (w.latency / time.Duration(w.requests)).Round(time.Millisecond), failureRate*100)
+	default:
+		return limit, ""
+	}
+}
+
+// observe 记录一次请求的耗时和结果，用于自适应调整并发数
+func (d *TaskDispatcher) observe(duration time.Duration, err error) {
+	if ErrorClass(err) == ErrorCancelled {
+		return
+	}
+	d.concurrencyMu.Lock()
+	defer d.concurrencyMu.Unlock()
+	d.window.requests++
+	d.window.latency += duration
+	if err == nil {
+		return
+	}
+	d.window.failures++
+	if ErrorClass(err) == ErrorRateLimited || isTimeout(err) {
+		d.window.congestion++
+	}
+}
+
+// SetConcurrency 调整并发数，范围为 1 到 MaxConcurrency，开启自适应时从该值继续调整
+func (d *TaskDispatcher) SetConcurrency(limit int) error {
+	if limit < 1 || limit > d.config.MaxConcurrency {
+		return fmt.Errorf("并发数必须在1到%d之间，当前为 %d", d.config.MaxConcurrency, limit)
+	}
+	d.applyConcurrency(limit, "手动调整")
+	return nil
+}
+
+// SetAdaptive 开启或关闭自适应并发
+func (d *TaskDispatcher) SetAdaptive(enabled bool) {
+	d.concurrencyMu.Lock()
+	d.adaptive = enabled
+	d.window = concurrencyWindow{}
+	d.concurrencyMu.Unlock()
+	if enabled {
+		log.Printf("自适应并发已开启")
+	} else {
+		log.Printf("自适应并发已关闭")
+	}
+}
+
+// ConcurrencyStatus 获取当前并发上限、工作协程数、自适应配置及各路径规则的限制状态
+func (d *TaskDispatcher) ConcurrencyStatus() map[string]interface{} {
+	d.concurrencyMu.Lock()
+	defer d.concurrencyMu.Unlock()
+	return map[string]interface{}{
+		"limit":    d.limiter.get(),
+		"max":      d.config.MaxConcurrency,
+		"workers":  d.workers,
+		"adaptive": d.adaptive,
+		"min":      d.config.Adaptive.MinConcurrency,
+		"limits":   d.limits.status(),
+	}
+}
+
+// applyConcurrency 设置并发上限，工作协程不足时启动新的工作协程。
+// 减少上限时不退出工作协程，同时执行的任务数由 limiter 限制，多出的工作协程取不到名额时等待
+func (d *TaskDispatcher) applyConcurrency(limit int, reason string) {
+	d.concurrencyMu.Lock()
+	defer d.concurrencyMu.Unlock()
+	previous := d.limiter.get()
+	d.limiter.setLimit(limit)
+	if d.running {
+		select {
+		case <-d.stop:
+		default:
+			for d.workers < limit {
+				d.wg.Add(1)
+				go d.worker(d.workers)
+				d.workers++
+			}
+		}
+	}
+	if previous != limit {
+		log.Printf("并发数调整: %d -> %d (%s)", previous, limit, reason)
+	}
+}
+
+// runAdaptive 每个周期根据请求统计调整并发数，未开启自适应时只清空统计
+func (d *TaskDispatcher) runAdaptive() {
+	if d.config.Adaptive.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(d.config.Adaptive.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.concurrencyMu.Lock()
+			window, adaptive := d.window, d.adaptive
+			d.window = concurrencyWindow{}
+			d.concurrencyMu.Unlock()
+			if !adaptive {
+				continue
+			}
+
+			_, queueLen := d.queue.lengths()
+			limit := d.limiter.get()
+			if next, reason := nextConcurrency(d.config.Adaptive, limit, d.config.MaxConcurrency, window, queueLen > 0); next != limit {
+				d.applyConcurrency(next, "自适应: "+reason)
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextConcurrency(t *testing.T) {
+	cfg := AdaptiveConcurrency{
+		MinConcurrency: 2,
+		TargetLatency:  time.Second,
+		MaxFailureRate: 0.2,
+		DecreaseFactor: 0.5,
+	}
+	tests := []struct {
+		name    string
+		cfg     AdaptiveConcurrency
+		limit   int
+		window  concurrencyWindow
+		backlog bool
+		want    int
+	}{
+		{
+			name:    "没有请求",
+			limit:   5,
+			backlog: true,
+			want:    5,
+		},
+		{
+			name:    "正常时加 1",
+			limit:   5,
+			window:  concurrencyWindow{requests: 10, latency: 5 * time.Second},
+			backlog: true,
+			want:    6,
+		},
+		{
+			name:   "没有排队任务时不增加",
+			limit:  5,
+			window: concurrencyWindow{requests: 10, latency: 5 * time.Second},
+			want:   5,
+		},
+		{
+			name:    "平均耗时超过目标时不增加",
+			limit:   5,
+			window:  concurrencyWindow{requests: 10, latency: 20 * time.Second},
+			backlog: true,
+			want:    5,
+		},
+		{
+			name:    "不限制耗时",
+			cfg:     AdaptiveConcurrency{MinConcurrency: 2, MaxFailureRate: 0.2, DecreaseFactor: 0.5},
+			limit:   5,
+			window:  concurrencyWindow{requests: 10, latency: time.Hour},
+			backlog: true,
+			want:    6,
+		},
+		{
+			name:    "达到上限时不增加",
+			limit:   8,
+			window:  concurrencyWindow{requests: 10, latency: 5 * time.Second},
+			backlog: true,
+			want:    8,
+		},
+		{
+			name:    "限流或超时时乘以减少系数",
+			limit:   8,
+			window:  concurrencyWindow{requests: 10, failures: 1, congestion: 1, latency: 5 * time.Second},
+			backlog: true,
+			want:    4,
+		},
+		{
+			name:   "失败率超过阈值时减少",
+			limit:  8,
+			window: concurrencyWindow{requests: 10, failures: 3, latency: 5 * time.Second},
+			want:   4,
+		},
+		{
+			name:    "失败率未超过阈值时照常增加",
+			limit:   6,
+			window:  concurrencyWindow{requests: 10, failures: 2, latency: 5 * time.Second},
+			backlog: true,
+			want:    7,
+		},
+		{
+			name:   "减少后不低于下限",
+			limit:  3,
+			window: concurrencyWindow{requests: 10, congestion: 1},
+			want:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cfg
+			if tt.cfg != (AdaptiveConcurrency{}) {
+				c = tt.cfg
+			}
+			if got, _ := nextConcurrency(c, tt.limit, 8, tt.window, tt.backlog); got != tt.want {
+				t.Errorf("并发数为 %d，期望 %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConcurrencyLimiterShrink 减少上限后工作协程数不变，同时执行的任务数由名额限制
+func TestConcurrencyLimiterShrink(t *testing.T) {
+	l := newConcurrencyLimiter(3)
+	for i := 0; i < 3; i++ {
+		if !l.tryAcquire() {
+			t.Fatalf("第 %d 个名额应获取成功", i+1)
+		}
+	}
+
+	l.setLimit(1)
+	l.release()
+	if l.tryAcquire() {
+		t.Error("执行中的任务数仍超过新的上限时不应获取名额")
+	}
+	l.release()
+	l.release()
+	if !l.tryAcquire() {
+		t.Fatal("执行中的任务结束后应按新的上限获取名额")
+	}
+	if l.tryAcquire() {
+		t.Error("同时执行的任务数不应超过新的上限")
+	}
+}
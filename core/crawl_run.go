@@ -0,0 +1,343 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MetaRunID 任务 meta 中保存采集批次ID的键，分页和详情任务沿用父任务的批次
+const MetaRunID = "run_id"
+
+// maxRunFailures 批次记录中保留的失败任务数量
+const maxRunFailures = 20
+
+// runSaveTimeout 保存批次记录的超时时间
+const runSaveTimeout = 5 * time.Second
+
+// crawlRun 执行中的采集批次
+type crawlRun struct {
+	record   mongodb.CrawlRun
+	accounts map[string]bool
+	progress time.Time     // 最近一次新增、结束任务或入库的时间
+	done     chan struct{} // 批次结束后关闭
+}
+
+// RunTracker 统计每个采集批次的任务执行情况，批次内所有任务完成后写入 crawl_runs 集合
+type RunTracker struct {
+	store    *mongodb.CrawlRunDAO
+	runs     map[string]*crawlRun
+	onFinish []func(mongodb.CrawlRun) // 批次结束回调
+	mu       sync.Mutex
+}
+
+// NewRunTracker 创建采集批次统计，store 为 nil 时只输出日志
+func NewRunTracker(store *mongodb.CrawlRunDAO) *RunTracker {
+	return &RunTracker{
+		store: store,
+		runs:  make(map[string]*crawlRun),
+	}
+}
+
+// OnFinish 注册批次结束回调，在批次记录保存后调用
+func (t *RunTracker) OnFinish(fn func(mongodb.CrawlRun)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onFinish = append(t.onFinish, fn)
+}
+
+// Start 开始一个采集批次，返回批次ID
+func (t *RunTracker) Start(job, endpoint string) string {
+	run := &crawlRun{
+		record: mongodb.CrawlRun{
+			ID:        primitive.NewObjectID().Hex(),
+			Job:       job,
+			Endpoint:  endpoint,
+			Status:    mongodb.CrawlRunRunning,
+			StartedAt: time.Now(),
+		},
+		accounts: make(map[string]bool),
+		done:     make(chan struct{}),
+	}
+	run.progress = run.record.StartedAt
+
+	t.mu.Lock()
+	t.runs[run.record.ID] = run
+	record := run.snapshot()
+	t.mu.Unlock()
+
+	log.Printf("开始采集批次: %s, 定时任务: %s", record.ID, job)
+	t.save(record)
+	return record.ID
+}
+
+// watch 获取执行中的批次，批次已结束时返回 nil。需在加入任务前获取，之后通过 wait 等待批次结束
+func (t *RunTracker) watch(runID string) *crawlRun {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.runs[runID]
+}
+
+// wait 等待批次结束并返回最终记录，ctx 取消时返回错误。批次结束后不再修改，无需加锁
+func (r *crawlRun) wait(ctx context.Context) (mongodb.CrawlRun, error) {
+	select {
+	case <-r.done:
+		return r.snapshot(), nil
+	case <-ctx.Done():
+		return mongodb.CrawlRun{}, ctx.Err()
+	}
+}
+
+// Abort 批次未能加入任何任务时直接结束
+func (t *RunTracker) Abort(runID string, err error) {
+	t.mu.Lock()
+	run, ok := t.runs[runID]
+	if !ok || run.record.Tasks > 0 {
+		t.mu.Unlock()
+		return
+	}
+	run.record.Failures = append(run.record.Failures, mongodb.CrawlRunFailure{Error: err.Error(), Time: time.Now()})
+	record := t.finish(run, mongodb.CrawlRunFailed)
+	t.mu.Unlock()
+
+	t.complete(record)
+}
+
+// Skip 批次的任务与去重时间内已有的任务重复，不加入任何任务，批次按成功结束
+func (t *RunTracker) Skip(runID string, reason error) {
+	t.mu.Lock()
+	run, ok := t.runs[runID]
+	if !ok || run.record.Tasks > 0 {
+		t.mu.Unlock()
+		return
+	}
+	log.Printf("采集批次没有新任务: %s, 原因: %v", runID, reason)
+	record := t.finish(run, mongodb.CrawlRunSucceeded)
+	t.mu.Unlock()
+
+	t.complete(record)
+}
+
+// Fail 不再等待批次中未完成的任务，将批次标记为失败，之后结束的任务不再计入该批次
+func (t *RunTracker) Fail(runID string, err error) {
+	t.mu.Lock()
+	run, ok := t.runs[runID]
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+	run.record.Failures = append(run.record.Failures, mongodb.CrawlRunFailure{Error: err.Error(), Time: time.Now()})
+	record := t.finish(run, mongodb.CrawlRunFailed)
+	t.mu.Unlock()
+
+	t.complete(record)
+}
+
+// Interrupt 将所有未完成的批次标记为中断，程序关闭时调用
+func (t *RunTracker) Interrupt() {
+	t.mu.Lock()
+	records := make([]mongodb.CrawlRun, 0, len(t.runs))
+	for _, run := range t.runs {
+		records = append(records, t.finish(run, mongodb.CrawlRunInterrupted))
+	}
+	t.mu.Unlock()
+
+	for _, record := range records {
+		t.complete(record)
+	}
+}
+
+// added 批次内新增一个任务
+func (t *RunTracker) added(task *Task) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if run := t.run(task); run != nil {
+		run.record.Tasks++
+		run.record.Pending++
+		run.progress = time.Now()
+	}
+}
+
+// stored 记录任务入库的数据条数
+func (t *RunTracker) stored(task *Task, n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if run := t.run(task); run != nil {
+		run.record.Items += n
+		run.progress = time.Now()
+	}
+}
+
+// done 批次内一个任务执行结束，accounts 为任务尝试过的账号，批次内没有未完成的任务时保存批次记录
+func (t *RunTracker) done(task *Task, accounts []*Account, err error) {
+	t.mu.Lock()
+	run := t.run(task)
+	if run == nil {
+		t.mu.Unlock()
+		return
+	}
+
+	ids := make([]string, 0, len(accounts))
+	for _, acc := range accounts {
+		run.accounts[acc.ID] = true
+		ids = append(ids, acc.ID)
+	}
+	run.record.Pending--
+	switch {
+	case err != nil:
+		run.record.Failed++
+		if len(run.record.Failures) < maxRunFailures {
+			run.record.Failures = append(run.record.Failures, mongodb.CrawlRunFailure{URL: task.URL, Error: err.Error(), Accounts: ids, Time: time.Now()})
+		}
+	default:
+		run.record.Succeeded++
+		if _, ok := task.Meta["page"]; ok {
+			run.record.Pages++
+		} else {
+			run.record.Details++
+		}
+	}
+
+	t.settle(run)
+}
+
+// handedOff 批次内一个任务已由其他实例接管或已完成，本实例不再执行
+func (t *RunTracker) handedOff(task *Task) {
+	t.mu.Lock()
+	run := t.run(task)
+	if run == nil {
+		t.mu.Unlock()
+		return
+	}
+	run.record.Pending--
+	run.record.HandedOff++
+	t.settle(run)
+}
+
+// settle 记录批次进度，批次内没有未完成的任务时结束批次并保存记录。调用方需持有 t.mu，返回前释放
+func (t *RunTracker) settle(run *crawlRun) {
+	run.progress = time.Now()
+	if run.record.Pending > 0 {
+		t.mu.Unlock()
+		return
+	}
+	status := mongodb.CrawlRunSucceeded
+	if run.record.Failed > 0 {
+		status = mongodb.CrawlRunFailed
+	}
+	record := t.finish(run, status)
+	t.mu.Unlock()
+
+	t.complete(record)
+}
+
+// expire 结束超过 timeout 没有进度的批次，批次中的任务可能已丢失，
+// 不再等待这些任务，避免依赖该批次的任务一直等待
+func (t *RunTracker) expire(timeout time.Duration) {
+	now := time.Now()
+	t.mu.Lock()
+	var records []mongodb.CrawlRun
+	for _, run := range t.runs {
+		if now.Sub(run.progress) <= timeout {
+			continue
+		}
+		run.record.Failures = append(run.record.Failures, mongodb.CrawlRunFailure{
+			Error: fmt.Sprintf("超过 %v 没有任务结束，剩余 %d 个任务不再等待", timeout, run.record.Pending),
+			Time:  now,
+		})
+		records = append(records, t.finish(run, mongodb.CrawlRunStalled))
+	}
+	t.mu.Unlock()
+
+	for _, record := range records {
+		t.complete(record)
+	}
+}
+
+// Status 获取执行中的批次
+func (t *RunTracker) Status() []map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := make([]map[string]interface{}, 0, len(t.runs))
+	for _, run := range t.runs {
+		status = append(status, map[string]interface{}{
+			"id":         run.record.ID,
+			"job":        run.record.Job,
+			"started_at": run.record.StartedAt,
+			"pending":    run.record.Pending,
+			"succeeded":  run.record.Succeeded,
+			"failed":     run.record.Failed,
+			"handed_off": run.record.HandedOff,
+			"items":      run.record.Items,
+		})
+	}
+	return status
+}
+
+// run 获取任务所属的执行中批次，调用方需持有 t.mu
+func (t *RunTracker) run(task *Task) *crawlRun {
+	if task == nil {
+		return nil
+	}
+	runID, _ := task.Meta[MetaRunID].(string)
+	if runID == "" {
+		return nil
+	}
+	return t.runs[runID]
+}
+
+// finish 结束批次并返回最终记录，调用方需持有 t.mu
+func (t *RunTracker) finish(run *crawlRun, status string) mongodb.CrawlRun {
+	delete(t.runs, run.record.ID)
+	run.record.Status = status
+	run.record.FinishedAt = time.Now()
+	record := run.snapshot()
+	close(run.done)
+	log.Printf("采集批次结束: %s, 定时任务: %s, 状态: %s, 耗时: %v, 列表页: %d, 详情: %d, 入库: %d, 失败: %d",
+		record.ID, record.Job, record.Status, record.FinishedAt.Sub(record.StartedAt).Round(time.Second),
+		record.Pages, record.Details, record.Items, record.Failed)
+	return record
+}
+
+// snapshot 复制批次记录，保存时不持有锁
+func (r *crawlRun) snapshot() mongodb.CrawlRun {
+	record := r.record
+	record.Failures = append([]mongodb.CrawlRunFailure(nil), r.record.Failures...)
+	record.Accounts = make([]string, 0, len(r.accounts))
+	for id := range r.accounts {
+		record.Accounts = append(record.Accounts, id)
+	}
+	sort.Strings(record.Accounts)
+	return record
+}
+
+// complete 保存结束的批次记录并调用批次结束回调
+func (t *RunTracker) complete(record mongodb.CrawlRun) {
+	t.save(record)
+
+	t.mu.Lock()
+	callbacks := slices.Clone(t.onFinish)
+	t.mu.Unlock()
+	for _, fn := range callbacks {
+		fn(record)
+	}
+}
+
+func (t *RunTracker) save(record mongodb.CrawlRun) {
+	if t.store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), runSaveTimeout)
+	defer cancel()
+	if err := t.store.Save(ctx, &record); err != nil {
+		log.Printf("保存采集批次失败: %s, 错误: %v", record.ID, err)
+	}
+}
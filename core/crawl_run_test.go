@@ -0,0 +1,127 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"errors"
+	"testing"
+	"time"
+)
+
+// trackRun 开始一个批次并加入 n 个任务，返回批次结束时的记录
+func trackRun(t *testing.T, n int) (*RunTracker, []*Task, <-chan mongodb.CrawlRun) {
+	t.Helper()
+	tracker := NewRunTracker(nil)
+	finished := make(chan mongodb.CrawlRun, 1)
+	tracker.OnFinish(func(record mongodb.CrawlRun) { finished <- record })
+	runID := tracker.Start("job", "endpoint")
+	tasks := make([]*Task, n)
+	for i := range tasks {
+		tasks[i] = &Task{URL: "https://example.com", Meta: map[string]interface{}{MetaRunID: runID, "page": int64(i + 1)}}
+		tracker.added(tasks[i])
+	}
+	return tracker, tasks, finished
+}
+
+func TestRunTrackerFinish(t *testing.T) {
+	tests := []struct {
+		name   string
+		finish func(tracker *RunTracker, tasks []*Task)
+		want   string
+	}{
+		{
+			name: "全部成功",
+			finish: func(tracker *RunTracker, tasks []*Task) {
+				tracker.done(tasks[0], nil, nil)
+				tracker.done(tasks[1], nil, nil)
+			},
+			want: mongodb.CrawlRunSucceeded,
+		},
+		{
+			name: "存在失败",
+			finish: func(tracker *RunTracker, tasks []*Task) {
+				tracker.done(tasks[0], nil, errors.New("请求失败"))
+				tracker.done(tasks[1], nil, nil)
+			},
+			want: mongodb.CrawlRunFailed,
+		},
+		{
+			name: "由其他实例接管的任务不计入失败",
+			finish: func(tracker *RunTracker, tasks []*Task) {
+				tracker.done(tasks[0], nil, nil)
+				tracker.handedOff(tasks[1])
+			},
+			want: mongodb.CrawlRunSucceeded,
+		},
+		{
+			name: "手动结束未完成的批次",
+			finish: func(tracker *RunTracker, tasks []*Task) {
+				tracker.done(tasks[0], nil, nil)
+				tracker.Fail(tasks[0].Meta[MetaRunID].(string), errors.New("回填窗口超时"))
+			},
+			want: mongodb.CrawlRunFailed,
+		},
+		{
+			name: "超过无进度时间",
+			finish: func(tracker *RunTracker, tasks []*Task) {
+				tracker.done(tasks[0], nil, nil)
+				tracker.expire(time.Hour)
+				if len(tracker.Status()) != 1 {
+					t.Fatal("未超时的批次不应结束")
+				}
+				tracker.expire(0)
+			},
+			want: mongodb.CrawlRunStalled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker, tasks, finished := trackRun(t, 2)
+			tt.finish(tracker, tasks)
+			select {
+			case record := <-finished:
+				if record.Status != tt.want {
+					t.Errorf("批次状态为 %s，期望 %s", record.Status, tt.want)
+				}
+			default:
+				t.Fatal("批次未结束")
+			}
+			if len(tracker.Status()) != 0 {
+				t.Error("结束的批次不应再出现在执行中的批次里")
+			}
+		})
+	}
+}
+
+func TestRunTrackerIgnoresFinishedRun(t *testing.T) {
+	tracker, tasks, finished := trackRun(t, 1)
+	tracker.expire(0)
+	<-finished
+	tracker.done(tasks[0], nil, nil)
+	select {
+	case record := <-finished:
+		t.Errorf("已结束的批次不应再次结束: %s", record.Status)
+	default:
+	}
+}
+
+func TestRunTrackerSkip(t *testing.T) {
+	tracker, _, finished := trackRun(t, 0)
+	runID := tracker.Status()[0]["id"].(string)
+	tracker.Skip(runID, ErrTaskDuplicate)
+	select {
+	case record := <-finished:
+		if record.Status != mongodb.CrawlRunSucceeded || record.Tasks != 0 {
+			t.Errorf("没有新任务的批次状态为 %s，任务数 %d，期望 succeeded、0", record.Status, record.Tasks)
+		}
+	default:
+		t.Fatal("没有新任务的批次应直接结束")
+	}
+
+	tracker, tasks, finished := trackRun(t, 1)
+	tracker.Skip(tasks[0].Meta[MetaRunID].(string), ErrTaskDuplicate)
+	select {
+	case record := <-finished:
+		t.Errorf("已有任务的批次不应跳过: %s", record.Status)
+	default:
+	}
+}
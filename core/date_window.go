@@ -0,0 +1,82 @@
+package core
+
+import (
+	"collyDemo/pkg/utils"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultWindowFormat 日期窗口默认格式，与接口 date_code、pub_time 参数一致
+const DefaultWindowFormat = "20060102"
+
+// 任务 meta 中保存日期窗口的键，分页任务沿用同一窗口
+const (
+	MetaWindow    = "window"     // 窗口表达式
+	MetaWindowMin = "window_min" // 窗口起始日期，已按格式化
+	MetaWindowMax = "window_max" // 窗口结束日期，已按格式化
+)
+
+// DateWindow 日期窗口，起止日期均包含在内
+type DateWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+var lastDaysPattern = regexp.MustCompile(`^last_(\d+)_days$`)
+
+// ResolveWindow 将窗口表达式解析为 Asia/Shanghai 时区的日期窗口，支持：
+//
+//	today         今天
+//	yesterday     昨天
+//	last_N_days   截至昨天的 N 个整天，如 last_7_days
+//	current_week  本周一至今天
+//	last_week     上周一至上周日
+//	current_month 本月1日至今天
+//	last_month    上月整月
+//
+// 表达式不区分大小写，单词之间可用空格或下划线分隔，如 "last 7 days"。
+func ResolveWindow(expr string, now time.Time) (DateWindow, error) {
+	now = now.In(utils.ShanghaiLocation())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch normalized := normalizeWindow(expr); normalized {
+	case "today":
+		return DateWindow{Start: today, End: today}, nil
+	case "yesterday":
+		yesterday := today.AddDate(0, 0, -1)
+		return DateWindow{Start: yesterday, End: yesterday}, nil
+	case "current_week", "this_week":
+		return DateWindow{Start: weekStart(today), End: today}, nil
+	case "last_week":
+		start := weekStart(today).AddDate(0, 0, -7)
+		return DateWindow{Start: start, End: start.AddDate(0, 0, 6)}, nil
+	case "current_month", "this_month":
+		return DateWindow{Start: today.AddDate(0, 0, 1-today.Day()), End: today}, nil
+	case "last_month":
+		end := today.AddDate(0, 0, -today.Day())
+		return DateWindow{Start: end.AddDate(0, 0, 1-end.Day()), End: end}, nil
+	default:
+		if match := lastDaysPattern.FindStringSubmatch(normalized); match != nil {
+			days, err := strconv.Atoi(match[1])
+			if err != nil || days < 1 {
+				return DateWindow{}, fmt.Errorf("无效的日期窗口 %q", expr)
+			}
+			end := today.AddDate(0, 0, -1)
+			return DateWindow{Start: end.AddDate(0, 0, 1-days), End: end}, nil
+		}
+		return DateWindow{}, fmt.Errorf("无效的日期窗口 %q", expr)
+	}
+}
+
+func normalizeWindow(expr string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(expr, "_", " "))), "_")
+}
+
+// weekStart 返回所在周的周一
+func weekStart(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"collyDemo/pkg/utils"
+	"testing"
+	"time"
+)
+
+func TestResolveWindow(t *testing.T) {
+	loc := utils.ShanghaiLocation()
+	tests := []struct {
+		name    string
+		expr    string
+		now     time.Time
+		want    string // "开始~结束"
+		wantErr bool
+	}{
+		{name: "今天", expr: "today", now: time.Date(2024, 3, 13, 10, 0, 0, 0, loc), want: "20240313~20240313"},
+		{name: "昨天跨月", expr: "yesterday", now: time.Date(2024, 3, 1, 10, 0, 0, 0, loc), want: "20240229~20240229"},
+		{name: "最近7天", expr: "last_7_days", now: time.Date(2024, 3, 13, 10, 0, 0, 0, loc), want: "20240306~20240312"},
+		{name: "最近1天", expr: "last 1 days", now: time.Date(2024, 3, 13, 10, 0, 0, 0, loc), want: "20240312~20240312"},
+		{name: "最近N天跨年", expr: "LAST_3_DAYS", now: time.Date(2024, 1, 2, 10, 0, 0, 0, loc), want: "20231230~20240101"},
+		{name: "本周从周一开始", expr: "current_week", now: time.Date(2024, 3, 13, 10, 0, 0, 0, loc), want: "20240311~20240313"},
+		{name: "本周当天为周一", expr: "this week", now: time.Date(2024, 3, 11, 0, 0, 0, 0, loc), want: "20240311~20240311"},
+		{name: "本周当天为周日", expr: "current_week", now: time.Date(2024, 3, 17, 23, 59, 0, 0, loc), want: "20240311~20240317"},
+		{name: "上周", expr: "last_week", now: time.Date(2024, 3, 13, 10, 0, 0, 0, loc), want: "20240304~20240310"},
+		{name: "上周当天为周日", expr: "last_week", now: time.Date(2024, 3, 17, 10, 0, 0, 0, loc), want: "20240304~20240310"},
+		{name: "上周跨年", expr: "last_week", now: time.Date(2025, 1, 1, 10, 0, 0, 0, loc), want: "20241223~20241229"},
+		{name: "本月", expr: "current_month", now: time.Date(2024, 3, 13, 10, 0, 0, 0, loc), want: "20240301~20240313"},
+		{name: "本月第一天", expr: "this_month", now: time.Date(2024, 3, 1, 0, 0, 0, 0, loc), want: "20240301~20240301"},
+		{name: "上月闰年二月", expr: "last_month", now: time.Date(2024, 3, 31, 10, 0, 0, 0, loc), want: "20240201~20240229"},
+		{name: "上月跨年", expr: "last_month", now: time.Date(2024, 1, 15, 10, 0, 0, 0, loc), want: "20231201~20231231"},
+		// UTC 时间已是上海时区的第二天
+		{name: "按上海时区计算日期", expr: "today", now: time.Date(2024, 3, 31, 16, 30, 0, 0, time.UTC), want: "20240401~20240401"},
+		{name: "按上海时区计算上月", expr: "last_month", now: time.Date(2024, 3, 31, 16, 30, 0, 0, time.UTC), want: "20240301~20240331"},
+		{name: "按上海时区计算本周", expr: "current_week", now: time.Date(2024, 3, 10, 16, 30, 0, 0, time.UTC), want: "20240311~20240311"},
+		{name: "天数为0", expr: "last_0_days", now: time.Date(2024, 3, 13, 10, 0, 0, 0, loc), wantErr: true},
+		{name: "未知表达式", expr: "next_week", now: time.Date(2024, 3, 13, 10, 0, 0, 0, loc), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := ResolveWindow(tt.expr, tt.now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("应返回错误，实际为 %s~%s", window.Start.Format(DefaultWindowFormat), window.End.Format(DefaultWindowFormat))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := window.Start.Format(DefaultWindowFormat) + "~" + window.End.Format(DefaultWindowFormat); got != tt.want {
+				t.Errorf("窗口为 %s，期望 %s", got, tt.want)
+			}
+			if window.Start.Location() != loc {
+				t.Errorf("窗口时区为 %s，期望 %s", window.Start.Location(), loc)
+			}
+		})
+	}
+}
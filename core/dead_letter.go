@@ -0,0 +1,110 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// deadLetterTimeout 保存死信的超时时间
+const deadLetterTimeout = 5 * time.Second
+
+// deadLetter 将重试后最终失败的任务保存到死信集合
+func (d *TaskDispatcher) deadLetter(task *Task, accounts []*Account, attempts []mongodb.DeadLetterAttempt, err error) {
+	if d.dead == nil {
+		return
+	}
+	statusCode, response := errorResponse(err)
+	letter := &mongodb.DeadLetter{
+		ID:         primitive.NewObjectID().Hex(),
+		TaskID:     task.ID,
+		Handler:    task.HandlerName,
+		Endpoint:   task.Endpoint,
+		URL:        task.URL,
+		Method:     task.Method,
+		Headers:    task.Headers,
+		Body:       task.Body,
+		Priority:   task.Priority,
+		Meta:       task.Meta,
+		DedupTTL:   task.DedupTTL,
+		Retry:      task.Retry.record(),
+		Attempts:   attempts,
+		ErrorClass: ErrorClass(err),
+		LastError:  err.Error(),
+		StatusCode: statusCode,
+		Response:   string(response),
+	}
+	for _, acc := range accounts {
+		letter.Accounts = append(letter.Accounts, acc.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadLetterTimeout)
+	defer cancel()
+	if err := d.dead.Create(ctx, letter); err != nil {
+		log.Printf("保存死信失败: %s, 错误: %v", task.URL, err)
+		return
+	}
+	log.Printf("任务已保存到死信: %s, 死信ID: %s", task.URL, letter.ID)
+}
+
+// deadLetterMarker 记录死信是否已重新入队，由 mongodb.DeadLetterDAO 实现
+type deadLetterMarker interface {
+	MarkReplayed(ctx context.Context, id, taskID string) (bool, error)
+	Unmark(ctx context.Context, id string) error
+}
+
+// taskInserter 写入持久化队列，由 mongodb.TaskQueueDAO 实现
+type taskInserter interface {
+	Insert(ctx context.Context, task *mongodb.QueuedTask) error
+}
+
+// ReplayDeadLetter 将死信作为新任务写入持久化队列，由运行中的实例接管执行，
+// 处理器未按名称注册的任务无法恢复，不能重新入队。重新入队的任务沿用死信记录的重试策略和去重时间
+func ReplayDeadLetter(ctx context.Context, dead *mongodb.DeadLetterDAO, queue *mongodb.TaskQueueDAO, letter *mongodb.DeadLetter) (string, error) {
+	return replayDeadLetter(ctx, dead, queue, letter)
+}
+
+// replayDeadLetter 先标记死信再写入队列，避免同一死信被重复入队；写入失败时恢复标记
+func replayDeadLetter(ctx context.Context, dead deadLetterMarker, queue taskInserter, letter *mongodb.DeadLetter) (string, error) {
+	if letter.Handler == "" {
+		return "", errors.New("任务未记录处理器名称，无法重新入队")
+	}
+	taskID := primitive.NewObjectID().Hex()
+	marked, err := dead.MarkReplayed(ctx, letter.ID, taskID)
+	if err != nil {
+		return "", err
+	}
+	if !marked {
+		return "", errors.New("死信不存在或已重新入队")
+	}
+
+	// 原采集批次已结束，重新入队的任务不再计入
+	meta := make(map[string]interface{}, len(letter.Meta))
+	for k, v := range letter.Meta {
+		if k != MetaRunID {
+			meta[k] = v
+		}
+	}
+	err = queue.Insert(ctx, &mongodb.QueuedTask{
+		ID:       taskID,
+		Handler:  letter.Handler,
+		Endpoint: letter.Endpoint,
+		URL:      letter.URL,
+		Method:   letter.Method,
+		Headers:  letter.Headers,
+		Body:     letter.Body,
+		Priority: letter.Priority,
+		Meta:     meta,
+		DedupTTL: letter.DedupTTL,
+		Retry:    letter.Retry,
+	})
+	if err != nil {
+		dead.Unmark(ctx, letter.ID)
+		return "", err
+	}
+	return taskID, nil
+}
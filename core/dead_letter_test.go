@@ -0,0 +1,115 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeDeadLetters 记录标记和恢复操作的死信存储
+type fakeDeadLetters struct {
+	replayed bool   // 死信是否已重新入队
+	taskID   string // 标记时记录的任务ID
+	calls    []string
+}
+
+func (f *fakeDeadLetters) MarkReplayed(ctx context.Context, id, taskID string) (bool, error) {
+	f.calls = append(f.calls, "mark")
+	if f.replayed {
+		return false, nil
+	}
+	f.replayed, f.taskID = true, taskID
+	return true, nil
+}
+
+func (f *fakeDeadLetters) Unmark(ctx context.Context, id string) error {
+	f.calls = append(f.calls, "unmark")
+	f.replayed, f.taskID = false, ""
+	return nil
+}
+
+// fakeQueue 记录写入的任务
+type fakeQueue struct {
+	err   error
+	tasks []*mongodb.QueuedTask
+}
+
+func (f *fakeQueue) Insert(ctx context.Context, task *mongodb.QueuedTask) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.tasks = append(f.tasks, task)
+	return nil
+}
+
+func TestReplayDeadLetter(t *testing.T) {
+	newLetter := func() *mongodb.DeadLetter {
+		return &mongodb.DeadLetter{
+			ID:       "letter",
+			Handler:  "author",
+			URL:      "https://example.com",
+			Meta:     map[string]interface{}{MetaRunID: "run", "page": int64(2)},
+			DedupTTL: time.Hour,
+			Retry:    &mongodb.QueuedRetry{MaxAttempts: ptr(5)},
+		}
+	}
+	tests := []struct {
+		name      string
+		letter    func() *mongodb.DeadLetter
+		replayed  bool
+		insertErr error
+		wantErr   bool
+		wantCalls []string
+		wantQueue int
+	}{
+		{name: "重新入队", letter: newLetter, wantCalls: []string{"mark"}, wantQueue: 1},
+		{
+			name: "未记录处理器",
+			letter: func() *mongodb.DeadLetter {
+				letter := newLetter()
+				letter.Handler = ""
+				return letter
+			},
+			wantErr: true,
+		},
+		{name: "已重新入队", letter: newLetter, replayed: true, wantErr: true, wantCalls: []string{"mark"}},
+		{name: "写入队列失败时恢复标记", letter: newLetter, insertErr: errors.New("写入失败"), wantErr: true, wantCalls: []string{"mark", "unmark"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dead := &fakeDeadLetters{replayed: tt.replayed}
+			queue := &fakeQueue{err: tt.insertErr}
+			taskID, err := replayDeadLetter(context.Background(), dead, queue, tt.letter())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("返回错误 %v，期望错误: %v", err, tt.wantErr)
+			}
+			if len(dead.calls) != len(tt.wantCalls) {
+				t.Fatalf("死信操作为 %v，期望 %v", dead.calls, tt.wantCalls)
+			}
+			for i := range dead.calls {
+				if dead.calls[i] != tt.wantCalls[i] {
+					t.Fatalf("死信操作为 %v，期望 %v", dead.calls, tt.wantCalls)
+				}
+			}
+			if len(queue.tasks) != tt.wantQueue {
+				t.Fatalf("写入队列 %d 个任务，期望 %d", len(queue.tasks), tt.wantQueue)
+			}
+			if tt.wantQueue == 0 {
+				return
+			}
+
+			task := queue.tasks[0]
+			if task.ID != taskID || dead.taskID != taskID {
+				t.Errorf("任务ID %s 与死信记录的 %s 应一致", task.ID, dead.taskID)
+			}
+			if _, ok := task.Meta[MetaRunID]; ok || task.Meta["page"] != int64(2) {
+				t.Errorf("重新入队的任务 meta 为 %v，应去掉批次ID并保留其他字段", task.Meta)
+			}
+			if task.DedupTTL != time.Hour || task.Retry == nil || *task.Retry.MaxAttempts != 5 {
+				t.Errorf("重新入队的任务应沿用去重时间和重试策略，实际为 %v、%+v", task.DedupTTL, task.Retry)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package core
+
+import (
+	"collyDemo/pkg/schedule"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 接口分组
+const (
+	GroupMain = "main" // 主要数据，列表分页并采集详情
+	GroupRank = "rank" // 排名数据
+)
+
+// 分页方式
+const (
+	PaginationPage = "page" // 按 {{page}}/{{limit}} 翻页，由处理器根据返回的总数创建下一页任务
+	PaginationNone = "none" // 只请求第一页
+)
+
+// Endpoint 接口配置，描述一个采集接口的请求方式、处理器和执行频率
+type Endpoint struct {
+	Name         string            `json:"name"`          // 接口名称，同时作为配置文件中的任务键
+	Title        string            `json:"title"`         // 定时任务名称
+	Description  string            `json:"description"`   // 定时任务描述
+	Group        string            `json:"group"`         // 分组: main 或 rank
+	URL          string            `json:"url"`           // URL模板，支持 {{page}}、{{limit}}，配置了 window 时还支持 {{date_min}}、{{date_max}}
+	Method       string            `json:"method"`        // 请求方法，默认 GET
+	Body         string            `json:"body"`          // 请求体模板，变量同 URL
+	Window       string            `json:"window"`        // 日期窗口表达式，如 last_7_days、yesterday、current_week，入队时按 Asia/Shanghai 时间解析
+	WindowFormat string            `json:"window_format"` // 日期格式，默认 20060102
+	Headers      map[string]string `json:"headers"`       // 附加请求头，覆盖目录中的默认请求头
+	Pagination   string            `json:"pagination"`    // 分页方式: page 或 none
+	PageSize     int64             `json:"page_size"`     // 每页数量
+	Handler      string            `json:"handler"`       // 处理器名称
+	Collection   string            `json:"collection"`    // 数据写入的集合
+	Detail       *DetailEndpoint   `json:"detail"`        // 详情接口，可为空
+	Schedule     string            `json:"schedule"`      // 默认执行频率，可被配置文件覆盖
+	Priority     string            `json:"priority"`      // 列表任务优先级，默认 rank 分组为 rank，main 分组为 list
+	DedupTTL     string            `json:"dedup_ttl"`     // 列表任务去重时间，如 30m，默认不去重
+	Retry        *EndpointRetry    `json:"retry"`         // 列表任务重试策略，未填写的字段使用 system 中的配置
+
+	dedupTTL time.Duration
+	retry    *RetryOverride
+}
+
+// DetailEndpoint 详情接口配置
+type DetailEndpoint struct {
+	URL        string            `json:"url"`        // URL模板，支持 {{id}}
+	Method     string            `json:"method"`     // 请求方法，默认 GET
+	Headers    map[string]string `json:"headers"`    // 附加请求头
+	Handler    string            `json:"handler"`    // 处理器名称
+	Collection string            `json:"collection"` // 数据写入的集合
+	Priority   string            `json:"priority"`   // 详情任务优先级，默认 detail
+	DedupTTL   string            `json:"dedup_ttl"`  // 详情任务去重时间，默认 1h，"0s" 表示不去重
+	Retry      *EndpointRetry    `json:"retry"`      // 详情任务重试策略，为空时使用列表接口的 retry
+
+	dedupTTL time.Duration
+	retry    *RetryOverride
+}
+
+// Catalog 接口目录
+type Catalog struct {
+	Headers   map[string]string `json:"headers"`   // 所有接口的默认请求头，authorization 在请求时替换为账号token
+	Endpoints []*Endpoint       `json:"endpoints"` // 接口列表，按定义顺序分发
+	Limits    []*EndpointLimit  `json:"limits"`    // 按请求路径限制并发数和请求频率，请求匹配第一条规则
+
+	byName map[string]*Endpoint
+}
+
+// LoadCatalog 加载并校验接口目录文件
+func LoadCatalog(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取接口目录失败: %w", err)
+	}
+
+	catalog := &Catalog{}
+	if err := json.Unmarshal(data, catalog); err != nil {
+		return nil, fmt.Errorf("解析接口目录失败 %s: %w", path, err)
+	}
+	if err := catalog.init(); err != nil {
+		return nil, fmt.Errorf("接口目录校验失败 %s: %w", path, err)
+	}
+	return catalog, nil
+}
+
+// init 填充默认值、建立名称索引并校验
+func (c *Catalog) init() error {
+	var errs []error
+	c.byName = make(map[string]*Endpoint, len(c.Endpoints))
+
+	for i, ep := range c.Endpoints {
+		if ep.Name == "" {
+			errs = append(errs, fmt.Errorf("endpoints[%d]: name 不能为空", i))
+			continue
+		}
+		if _, exists := c.byName[ep.Name]; exists {
+			errs = append(errs, fmt.Errorf("%s: 接口名称重复", ep.Name))
+			continue
+		}
+		c.byName[ep.Name] = ep
+
+		if ep.Method == "" {
+			ep.Method = "GET"
+		}
+		if ep.Pagination == "" {
+			ep.Pagination = PaginationNone
+		}
+		if ep.Title == "" {
+			ep.Title = ep.Name
+		}
+		if ep.Window != "" && ep.WindowFormat == "" {
+			ep.WindowFormat = DefaultWindowFormat
+		}
+		if ep.Priority == "" {
+			ep.Priority = PriorityList
+			if ep.Group == GroupRank {
+				ep.Priority = PriorityRank
+			}
+		}
+		if ep.Detail != nil {
+			if ep.Detail.Method == "" {
+				ep.Detail.Method = "GET"
+			}
+			if ep.Detail.Priority == "" {
+				ep.Detail.Priority = PriorityDetail
+			}
+		}
+		for _, err := range ep.validate() {
+			errs = append(errs, fmt.Errorf("%s: %w", ep.Name, err))
+		}
+	}
+	for i, limit := range c.Limits {
+		if err := limit.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("limits[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (ep *Endpoint) validate() []error {
+	var errs []error
+	switch ep.Group {
+	case GroupMain, GroupRank:
+	default:
+		errs = append(errs, fmt.Errorf("group 只支持 %s 或 %s，当前为 %q", GroupMain, GroupRank, ep.Group))
+	}
+	if ep.URL == "" {
+		errs = append(errs, errors.New("url 不能为空"))
+	}
+	if err := ValidatePriority(ep.Priority); err != nil {
+		errs = append(errs, err)
+	}
+	if ttl, err := parseDedupTTL(ep.DedupTTL, 0); err != nil {
+		errs = append(errs, err)
+	} else {
+		ep.dedupTTL = ttl
+	}
+	if retry, err := ep.Retry.parse(); err != nil {
+		errs = append(errs, err)
+	} else {
+		ep.retry = retry
+	}
+	if ep.Handler == "" {
+		errs = append(errs, errors.New("handler 不能为空"))
+	}
+	if ep.Schedule != ScheduleOff {
+		if _, err := schedule.Parse(ep.Schedule); err != nil {
+			errs = append(errs, fmt.Errorf("schedule: %w", err))
+		}
+	}
+	switch ep.Pagination {
+	case PaginationPage:
+		if ep.PageSize <= 0 {
+			errs = append(errs, errors.New("分页接口的 page_size 必须大于0"))
+		}
+	case PaginationNone:
+	default:
+		errs = append(errs, fmt.Errorf("pagination 只支持 %s 或 %s，当前为 %q", PaginationPage, PaginationNone, ep.Pagination))
+	}
+	vars := []string{"page", "limit"}
+	if ep.Window != "" {
+		if _, err := ResolveWindow(ep.Window, time.Now()); err != nil {
+			errs = append(errs, fmt.Errorf("window: %w", err))
+		}
+		vars = append(vars, "date_min", "date_max")
+	}
+	for _, tpl := range []string{ep.URL, ep.Body} {
+		if err := checkTemplate(tpl, vars...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if ep.Detail != nil {
+		if ep.Detail.URL == "" {
+			errs = append(errs, errors.New("detail.url 不能为空"))
+		} else if !strings.Contains(ep.Detail.URL, "{{id}}") {
+			errs = append(errs, errors.New("detail.url 必须包含 {{id}}"))
+		}
+		if err := checkTemplate(ep.Detail.URL, "id"); err != nil {
+			errs = append(errs, err)
+		}
+		if ep.Detail.Handler == "" {
+			errs = append(errs, errors.New("detail.handler 不能为空"))
+		}
+		if err := ValidatePriority(ep.Detail.Priority); err != nil {
+			errs = append(errs, fmt.Errorf("detail.%w", err))
+		}
+		if ttl, err := parseDedupTTL(ep.Detail.DedupTTL, DefaultDetailDedupTTL); err != nil {
+			errs = append(errs, fmt.Errorf("detail.%w", err))
+		} else {
+			ep.Detail.dedupTTL = ttl
+		}
+		if ep.Detail.Retry == nil {
+			ep.Detail.retry = ep.retry
+		} else if retry, err := ep.Detail.Retry.parse(); err != nil {
+			errs = append(errs, fmt.Errorf("detail.%w", err))
+		} else {
+			ep.Detail.retry = retry
+		}
+	}
+	return errs
+}
+
+// parseDedupTTL 解析去重时间，为空时使用默认值
+func parseDedupTTL(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("dedup_ttl 格式错误: %q", value)
+	}
+	return ttl, nil
+}
+
+// Endpoint 根据名称获取接口
+func (c *Catalog) Endpoint(name string) (*Endpoint, bool) {
+	ep, ok := c.byName[name]
+	return ep, ok
+}
+
+// HandlerNames 目录中引用的所有处理器名称
+func (c *Catalog) HandlerNames() []string {
+	var names []string
+	for _, ep := range c.Endpoints {
+		names = append(names, ep.Handler)
+		if ep.Detail != nil {
+			names = append(names, ep.Detail.Handler)
+		}
+	}
+	return names
+}
+
+// headers 合并默认请求头与附加请求头
+func (c *Catalog) headers(extra ...map[string]string) map[string]string {
+	headers := make(map[string]string, len(c.Headers))
+	for k, v := range c.Headers {
+		headers[k] = v
+	}
+	for _, m := range extra {
+		for k, v := range m {
+			headers[k] = v
+		}
+	}
+	return headers
+}
+
+var templateVar = regexp.MustCompile(`{{\s*(\w+)\s*}}`)
+
+// checkTemplate 检查模板只使用了允许的变量
+func checkTemplate(tpl string, allowed ...string) error {
+	for _, match := range templateVar.FindAllStringSubmatch(tpl, -1) {
+		known := false
+		for _, name := range allowed {
+			if match[1] == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("模板变量 {{%s}} 不支持，可用变量: %s", match[1], strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// renderTemplate 替换模板中的 {{变量}}
+func renderTemplate(tpl string, vars map[string]interface{}) string {
+	return templateVar.ReplaceAllStringFunc(tpl, func(match string) string {
+		name := templateVar.FindStringSubmatch(match)[1]
+		if value, ok := vars[name]; ok {
+			return fmt.Sprint(value)
+		}
+		return match
+	})
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointLimit 按请求路径限制同时执行的请求数和请求频率，与所用账号无关，
+// 请求按路径匹配接口目录中的第一条规则
+type EndpointLimit struct {
+	Path        string `json:"path"`        // 请求路径模式，* 匹配路径中的一段，如 /api/author/detail/*
+	Concurrency int    `json:"concurrency"` // 同时执行的请求数上限，0 表示不限制
+	PerMinute   int    `json:"per_minute"`  // 每分钟请求数上限，0 表示不限制
+	PerHour     int    `json:"per_hour"`    // 每小时请求数上限，0 表示不限制
+}
+
+func (l *EndpointLimit) validate() error {
+	var errs []error
+	if !strings.HasPrefix(l.Path, "/") {
+		errs = append(errs, fmt.Errorf("path 必须以 / 开头，当前为 %q", l.Path))
+	} else if _, err := path.Match(l.Path, ""); err != nil {
+		errs = append(errs, fmt.Errorf("path 格式错误: %q", l.Path))
+	}
+	if l.Concurrency < 0 || l.PerMinute < 0 || l.PerHour < 0 {
+		errs = append(errs, errors.New("concurrency、per_minute、per_hour 不能为负数"))
+	}
+	if l.Concurrency == 0 && l.PerMinute == 0 && l.PerHour == 0 {
+		errs = append(errs, errors.New("concurrency、per_minute、per_hour 至少配置一项"))
+	}
+	return errors.Join(errs...)
+}
+
+// slidingWindow 滑动窗口计数，记录最近 period 内的请求时间
+type slidingWindow struct {
+	limit  int
+	period time.Duration
+	times  []time.Time
+}
+
+// wait 距离可以发起下一次请求还需等待的时间
+func (w *slidingWindow) wait(now time.Time) time.Duration {
+	if w.limit <= 0 {
+		return 0
+	}
+	expired := 0
+	for expired < len(w.times) && now.Sub(w.times[expired]) >= w.period {
+		expired++
+	}
+	w.times = w.times[expired:]
+	if len(w.times) < w.limit {
+		return 0
+	}
+	return w.times[0].Add(w.period).Sub(now)
+}
+
+func (w *slidingWindow) add(now time.Time) {
+	if w.limit > 0 {
+		w.times = append(w.times, now)
+	}
+}
+
+// endpointLimiter 单条路径规则的限制状态
+type endpointLimiter struct {
+	limit    *EndpointLimit
+	active   int
+	minute   slidingWindow
+	hour     slidingWindow
+	released chan struct{} // 有请求结束时关闭并替换，唤醒等待并发名额的重试请求
+	notify   func()        // 有请求结束时唤醒等待取任务的工作协程
+	mu       sync.Mutex
+}
+
+// tryAcquire 占用并发名额并记录一次请求，达到并发上限时返回 false 和 0，达到频率上限时返回 false 和还需等待的时间
+func (l *endpointLimiter) tryAcquire(now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reserve(now)
+}
+
+// reserve 同 tryAcquire，调用方需持有 l.mu
+func (l *endpointLimiter) reserve(now time.Time) (bool, time.Duration) {
+	wait := l.minute.wait(now)
+	if w := l.hour.wait(now); w > wait {
+		wait = w
+	}
+	if wait > 0 || (l.limit.Concurrency > 0 && l.active >= l.limit.Concurrency) {
+		return false, wait
+	}
+	l.minute.add(now)
+	l.hour.add(now)
+	l.active++
+	return true, 0
+}
+
+// acquire 等待并发名额和频率限制，ctx 取消时返回 false，用于任务的重试请求
+func (l *endpointLimiter) acquire(ctx context.Context) bool {
+	logged := false
+	for {
+		l.mu.Lock()
+		ok, wait := l.reserve(time.Now())
+		released, active := l.released, l.active
+		l.mu.Unlock()
+		if ok {
+			return true
+		}
+
+		if !logged && wait > 0 {
+			log.Printf("接口请求频率达到上限，等待 %v: %s", wait.Round(time.Second), l.limit.Path)
+			logged = true
+		} else if !logged {
+			log.Printf("接口并发数达到上限 %d，等待: %s", active, l.limit.Path)
+			logged = true
+		}
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			expired = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-expired:
+		case <-released:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+	}
+}
+
+func (l *endpointLimiter) release() {
+	l.mu.Lock()
+	l.active--
+	close(l.released)
+	l.released = make(chan struct{})
+	l.mu.Unlock()
+	if l.notify != nil {
+		l.notify()
+	}
+}
+
+func (l *endpointLimiter) status() map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.minute.wait(now)
+	l.hour.wait(now)
+	return map[string]interface{}{
+		"path":        l.limit.Path,
+		"active":      l.active,
+		"concurrency": l.limit.Concurrency,
+		"last_minute": len(l.minute.times),
+		"per_minute":  l.limit.PerMinute,
+		"last_hour":   len(l.hour.times),
+		"per_hour":    l.limit.PerHour,
+	}
+}
+
+// endpointLimits 接口目录中的所有路径规则
+type endpointLimits []*endpointLimiter
+
+// newEndpointLimits 创建路径规则的限制状态，notify 在请求结束时调用，唤醒等待取任务的工作协程
+func newEndpointLimits(limits []*EndpointLimit, notify func()) endpointLimits {
+	limiters := make(endpointLimits, 0, len(limits))
+	for _, limit := range limits {
+		limiters = append(limiters, &endpointLimiter{
+			limit:    limit,
+			minute:   slidingWindow{limit: limit.PerMinute, period: time.Minute},
+			hour:     slidingWindow{limit: limit.PerHour, period: time.Hour},
+			released: make(chan struct{}),
+			notify:   notify,
+		})
+	}
+	return limiters
+}
+
+// match 获取请求地址匹配的第一条规则，没有匹配的规则时返回 nil
+func (ls endpointLimits) match(rawURL string) *endpointLimiter {
+	if len(ls) == 0 {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	for _, l := range ls {
+		if ok, _ := path.Match(l.limit.Path, u.Path); ok {
+			return l
+		}
+	}
+	return nil
+}
+
+func (ls endpointLimits) status() []map[string]interface{} {
+	status := make([]map[string]interface{}, 0, len(ls))
+	for _, l := range ls {
+		status = append(status, l.status())
+	}
+	return status
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// leaseStore 主节点租约的存储，由 mongodb.SchedulerLeaseDAO 实现
+type leaseStore interface {
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*mongodb.SchedulerLease, error)
+	Renew(ctx context.Context, name, holder string, token int64, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name, holder string, token int64) error
+}
+
+// LeaderElector 基于 MongoDB 租约的主节点选举，多实例部署时只有主节点触发定时任务。
+//
+// 主节点每隔 interval 续约一次，续约时发现租约已被其他实例接管则立即退出主节点身份；
+// 续约持续失败（如与数据库断开）时，在租约到期前一个续约间隔主动退出，避免与接管的实例同时执行。
+// 备用节点每隔 interval 尝试获取租约，主节点正常关闭时释放租约，备用节点在一个间隔内接管，
+// 主节点异常退出时在租约过期后接管。每次易主防护令牌加一，旧主节点携带旧令牌的状态写入会被拒绝。
+type LeaderElector struct {
+	leases   leaseStore
+	name     string
+	holder   string
+	ttl      time.Duration
+	interval time.Duration
+
+	leader   bool
+	token    int64
+	deadline time.Time // 本地判断的主节点身份有效期
+	mu       sync.Mutex
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewLeaderElector 创建主节点选举，name 为租约名称，holder 为当前实例标识，interval 需小于 ttl 的一半
+func NewLeaderElector(leases *mongodb.SchedulerLeaseDAO, name, holder string, ttl, interval time.Duration) *LeaderElector {
+	return &LeaderElector{
+		leases:   leases,
+		name:     name,
+		holder:   holder,
+		ttl:      ttl,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// DefaultInstanceID 默认实例标识，由主机名和进程号组成
+func DefaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Start 立即尝试获取一次租约，随后定期续约或尝试接管
+func (e *LeaderElector) Start() {
+	log.Printf("启动主节点选举: 租约=%s, 实例=%s, 有效期=%v, 续约间隔=%v", e.name, e.holder, e.ttl, e.interval)
+	e.tick()
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				e.tick()
+			case <-e.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止选举，当前为主节点时释放租约
+func (e *LeaderElector) Stop() {
+	close(e.stop)
+	e.wg.Wait()
+
+	e.mu.Lock()
+	leader, token := e.leader, e.token
+	e.leader = false
+	e.mu.Unlock()
+	if !leader {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+	defer cancel()
+	if err := e.leases.Release(ctx, e.name, e.holder, token); err != nil {
+		log.Printf("释放主节点租约失败: %v", err)
+		return
+	}
+	log.Printf("已释放主节点租约: %s", e.name)
+}
+
+// IsLeader 当前实例是否为主节点，续约失败超过有效期后即使尚未收到接管结果也返回 false
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader && time.Now().Before(e.deadline)
+}
+
+// Token 当前持有的防护令牌，未成为过主节点时为0
+func (e *LeaderElector) Token() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.token
+}
+
+// tick 主节点续约，备用节点尝试接管
+func (e *LeaderElector) tick() {
+	e.mu.Lock()
+	leader, token := e.leader, e.token
+	e.mu.Unlock()
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+	defer cancel()
+
+	if leader {
+		renewed, err := e.leases.Renew(ctx, e.name, e.holder, token, e.ttl)
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		switch {
+		case err != nil:
+			if !start.Before(e.deadline) {
+				e.leader = false
+				log.Printf("主节点续约失败且已超过有效期，退出主节点身份: %v", err)
+			} else {
+				log.Printf("主节点续约失败，将在 %s 前重试: %v", formatTime(e.deadline), err)
+			}
+		case !renewed:
+			e.leader = false
+			log.Printf("主节点租约已被其他实例接管，退出主节点身份: %s", e.name)
+		default:
+			e.deadline = e.validUntil(start)
+		}
+		return
+	}
+
+	lease, err := e.leases.Acquire(ctx, e.name, e.holder, e.ttl)
+	if err != nil || lease == nil {
+		return
+	}
+	e.mu.Lock()
+	e.leader = true
+	e.token = lease.Token
+	e.deadline = e.validUntil(start)
+	e.mu.Unlock()
+	log.Printf("成为主节点: 租约=%s, 实例=%s, 防护令牌=%d", e.name, e.holder, lease.Token)
+}
+
+// validUntil 在 start 发起的获取或续约成功后，本地判断的主节点身份有效期：
+// 比租约到期提前一个续约间隔，续约持续失败时在其他实例接管前退出
+func (e *LeaderElector) validUntil(start time.Time) time.Time {
+	return start.Add(e.ttl - e.interval)
+}
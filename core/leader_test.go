@@ -0,0 +1,123 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeLeases 记录调用并按设定返回结果的租约存储
+type fakeLeases struct {
+	lease    *mongodb.SchedulerLease
+	renewed  bool
+	err      error
+	released int64 // 释放租约时携带的防护令牌
+}
+
+func (f *fakeLeases) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*mongodb.SchedulerLease, error) {
+	return f.lease, f.err
+}
+
+func (f *fakeLeases) Renew(ctx context.Context, name, holder string, token int64, ttl time.Duration) (bool, error) {
+	return f.renewed, f.err
+}
+
+func (f *fakeLeases) Release(ctx context.Context, name, holder string, token int64) error {
+	f.released = token
+	return f.err
+}
+
+func newTestElector(leases *fakeLeases) *LeaderElector {
+	e := NewLeaderElector(nil, "scheduler", "host-1", time.Minute, 10*time.Second)
+	e.leases = leases
+	return e
+}
+
+func TestLeaderElectorAcquire(t *testing.T) {
+	leases := &fakeLeases{}
+	e := newTestElector(leases)
+	e.tick()
+	if e.IsLeader() {
+		t.Fatal("未获得租约时不应成为主节点")
+	}
+
+	leases.lease = &mongodb.SchedulerLease{Token: 3}
+	before := time.Now()
+	e.tick()
+	after := time.Now()
+	if !e.IsLeader() || e.Token() != 3 {
+		t.Fatalf("获得租约后 leader=%v token=%d，期望 true、3", e.IsLeader(), e.Token())
+	}
+	// 有效期比租约到期提前一个续约间隔
+	if e.deadline.Before(before.Add(50*time.Second)) || e.deadline.After(after.Add(50*time.Second)) {
+		t.Errorf("有效期为 %s，期望在获取后 50s", e.deadline)
+	}
+}
+
+func TestLeaderElectorRenew(t *testing.T) {
+	tests := []struct {
+		name       string
+		renewed    bool
+		err        error
+		expired    bool // 续约前本地有效期是否已过
+		wantLeader bool
+	}{
+		{name: "续约成功", renewed: true, wantLeader: true},
+		{name: "租约已被接管", renewed: false, wantLeader: false},
+		{name: "续约失败但仍在有效期内", err: errors.New("连接断开"), wantLeader: true},
+		{name: "续约失败且已超过有效期", err: errors.New("连接断开"), expired: true, wantLeader: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leases := &fakeLeases{lease: &mongodb.SchedulerLease{Token: 1}}
+			e := newTestElector(leases)
+			e.tick()
+			e.deadline = time.Now().Add(time.Second)
+			if tt.expired {
+				e.deadline = time.Now().Add(-time.Second)
+			}
+			deadline := e.deadline
+
+			leases.renewed, leases.err = tt.renewed, tt.err
+			e.tick()
+			e.mu.Lock()
+			leader := e.leader
+			e.mu.Unlock()
+			if leader != tt.wantLeader {
+				t.Fatalf("续约后 leader=%v，期望 %v", leader, tt.wantLeader)
+			}
+			if tt.err != nil && e.deadline != deadline {
+				t.Error("续约失败时不应延长有效期")
+			}
+			if tt.renewed && !e.deadline.After(deadline) {
+				t.Error("续约成功后应延长有效期")
+			}
+		})
+	}
+}
+
+func TestLeaderElectorIsLeaderExpires(t *testing.T) {
+	e := newTestElector(&fakeLeases{lease: &mongodb.SchedulerLease{Token: 1}})
+	e.tick()
+	e.mu.Lock()
+	e.deadline = time.Now().Add(-time.Second)
+	e.mu.Unlock()
+	if e.IsLeader() {
+		t.Error("超过本地有效期后即使尚未续约也不应视为主节点")
+	}
+}
+
+func TestLeaderElectorStopReleases(t *testing.T) {
+	leases := &fakeLeases{lease: &mongodb.SchedulerLease{Token: 7}}
+	e := newTestElector(leases)
+	e.tick()
+	e.Stop()
+	if leases.released != 7 {
+		t.Errorf("释放租约时携带的防护令牌为 %d，期望 7", leases.released)
+	}
+	if e.IsLeader() {
+		t.Error("停止后不应再是主节点")
+	}
+}
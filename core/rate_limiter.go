@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"log"
 	"sync"
 	"time"
@@ -30,7 +31,8 @@ func (rl *RateLimiter) Limit() int {
 	return rl.limit
 }
 
-func (rl *RateLimiter) Wait() {
+// Wait 获取一个令牌，令牌不足时等待补充，ctx 取消时返回错误且不消耗令牌
+func (rl *RateLimiter) Wait(ctx context.Context) error {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
@@ -51,11 +53,14 @@ func (rl *RateLimiter) Wait() {
 	if rl.tokens <= 0 {
 		waitTime := rl.refillRate
 		log.Printf("速率限制等待: %v", waitTime)
-		time.Sleep(waitTime)
+		if !sleepContext(ctx, waitTime) {
+			return ctx.Err()
+		}
 		rl.tokens = 1
 		rl.lastRefill = time.Now()
 	}
 
 	// 消耗一个令牌
 	rl.tokens--
+	return nil
 }
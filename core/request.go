@@ -2,12 +2,12 @@ package core
 
 import (
 	"bytes"
+	"collyDemo/pkg/utils"
 	"context"
 	"io"
 	"log"
 	"net/http"
 	"sync"
-	"time"
 
 	"github.com/gocolly/colly/v2"
 )
@@ -17,23 +17,36 @@ var (
 	dispatcherOnce sync.Once
 )
 
-func ExecuteRequest(task *Task, account *Account, dispatcher *TaskDispatcher) error {
+// ExecuteRequest 使用账号执行任务请求并调用处理器，ctx 取消或超过 TaskTimeout 时中止请求，
+// 处理器收到的 ctx 同样会被取消，用于中止数据库写入
+func ExecuteRequest(ctx context.Context, task *Task, account *Account, dispatcher *TaskDispatcher) error {
 	// 在执行请求前应用速率限制
 	if account.RateLimit != nil {
-		account.RateLimit.Wait()
+		if err := account.RateLimit.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	config := DefaultDispatcherConfig()
+	if dispatcher != nil {
+		config = dispatcher.config
 	}
 
+	taskCtx, cancel := context.WithTimeout(ctx, config.TaskTimeout)
+	defer cancel()
+
 	c := colly.NewCollector(
 		colly.Async(true),
+		colly.StdlibContext(taskCtx),
 	)
 
 	// 设置超时
-	c.SetRequestTimeout(30 * time.Second)
+	c.SetRequestTimeout(config.RequestTimeout)
 
 	// 设置代理
 	if account.Proxy != "" {
 		if err := c.SetProxy(account.Proxy); err != nil {
-			log.Printf("设置代理失败: %v", err)
+			log.Printf("设置代理失败: %s, 错误: %v", utils.RedactURL(account.Proxy), err)
 			return err
 		}
 	}
@@ -62,32 +75,42 @@ func ExecuteRequest(task *Task, account *Account, dispatcher *TaskDispatcher) er
 
 	// 注册响应处理
 	c.OnResponse(func(r *colly.Response) {
-		if err := task.Handler(r, account, dispatcher); err != nil {
+		if err := task.Handler(taskCtx, r, account, dispatcher); err != nil {
 			log.Printf("处理器错误: %v", err)
-			done <- err
+			done <- withResponse(err, r.StatusCode, r.Body)
 		} else {
 			done <- nil
 		}
 	})
 
-	// 处理请求错误
+	// 处理请求错误，按状态码分类，取消导致的失败不按网络错误重试
 	c.OnError(func(r *colly.Response, err error) {
-		log.Printf("请求失败: %s, 错误: %v, 账号: %s", task.URL, err, account.UserName)
-		done <- err
+		log.Printf("请求失败: %s, 状态码: %d, 错误: %v, 账号: %s", task.URL, r.StatusCode, err, account)
+		if r.StatusCode == 0 && ctx.Err() != nil {
+			done <- ctx.Err()
+			return
+		}
+		done <- withResponse(responseError(r.StatusCode, r.Headers, err), r.StatusCode, r.Body)
 	})
 
-	// 发送请求
-	err = c.Request(request.Method, request.URL.String(), request.Body, nil, request.Header)
+	// 发送请求，任务保存在请求上下文中供处理器读取
+	requestCtx := colly.NewContext()
+	requestCtx.Put(taskContextKey, task)
+	err = c.Request(request.Method, request.URL.String(), request.Body, requestCtx, request.Header)
 	if err != nil {
 		return err
 	}
 
-	// 等待响应处理完成，带超时机制
+	// 等待响应处理完成，超时或取消时返回
 	select {
 	case err := <-done:
 		return err
-	case <-time.After(60 * time.Second):
-		log.Printf("请求超时: %s", task.URL)
-		return context.DeadlineExceeded
+	case <-taskCtx.Done():
+		if ctx.Err() != nil {
+			log.Printf("请求已取消: %s", task.URL)
+		} else {
+			log.Printf("请求超时: %s", task.URL)
+		}
+		return taskCtx.Err()
 	}
 }
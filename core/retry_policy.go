@@ -0,0 +1,165 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy 任务重试策略，第n次重试前等待 InitialDelay*2^(n-1)，不超过 MaxDelay，
+// 并随机减少最多 Jitter 比例的等待时间，避免大量任务同时重试
+type RetryPolicy struct {
+	MaxAttempts  int           // 最多尝试次数，包括第一次
+	InitialDelay time.Duration // 第一次重试前的等待时间
+	MaxDelay     time.Duration // 单次等待时间上限
+	Jitter       float64       // 随机减少的比例，0 到 1
+	MaxElapsed   time.Duration // 从第一次尝试开始的最长时间，超过后不再重试，0 表示不限制
+}
+
+// RetryOverride 接口的重试策略，只覆盖默认策略中已填写的字段，为 nil 的字段表示未填写；
+// 填写为 0 的字段同样覆盖默认值，如 MaxElapsed 为 0 表示不限制重试总时间
+type RetryOverride struct {
+	MaxAttempts  *int
+	InitialDelay *time.Duration
+	MaxDelay     *time.Duration
+	Jitter       *float64
+	MaxElapsed   *time.Duration
+}
+
+// EndpointRetry 接口目录中的重试策略，未填写的字段使用 system 中的默认策略
+type EndpointRetry struct {
+	MaxAttempts  int      `json:"max_attempts"`
+	InitialDelay string   `json:"initial_delay"`
+	MaxDelay     string   `json:"max_delay"`
+	Jitter       *float64 `json:"jitter"`
+	MaxElapsed   string   `json:"max_elapsed"`
+}
+
+// Validate 校验重试策略
+func (p RetryPolicy) Validate() error {
+	var errs []error
+	if p.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("最多尝试次数必须大于等于1，当前为 %d", p.MaxAttempts))
+	}
+	if p.InitialDelay < 0 || p.MaxDelay < 0 || p.MaxElapsed < 0 {
+		errs = append(errs, errors.New("重试等待时间不能为负数"))
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		errs = append(errs, fmt.Errorf("jitter 必须在0到1之间，当前为 %v", p.Jitter))
+	}
+	return errors.Join(errs...)
+}
+
+// merge 用接口的重试策略覆盖默认策略中已填写的字段
+func (p RetryPolicy) merge(override *RetryOverride) RetryPolicy {
+	if override == nil {
+		return p
+	}
+	if override.MaxAttempts != nil {
+		p.MaxAttempts = *override.MaxAttempts
+	}
+	if override.InitialDelay != nil {
+		p.InitialDelay = *override.InitialDelay
+	}
+	if override.MaxDelay != nil {
+		p.MaxDelay = *override.MaxDelay
+	}
+	if override.MaxElapsed != nil {
+		p.MaxElapsed = *override.MaxElapsed
+	}
+	if override.Jitter != nil {
+		p.Jitter = *override.Jitter
+	}
+	return p
+}
+
+// backoff 第 attempt 次尝试失败后的等待时间，服务端返回 Retry-After 时至少等待该时间
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	delay := p.InitialDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 && delay > 0 {
+		delay -= time.Duration(rand.Int63n(int64(float64(delay)*p.Jitter) + 1))
+	}
+	if after := retryAfter(err); after > delay {
+		delay = after
+	}
+	return delay
+}
+
+// record 转换为持久化任务中保存的重试策略
+func (o *RetryOverride) record() *mongodb.QueuedRetry {
+	if o == nil {
+		return nil
+	}
+	return &mongodb.QueuedRetry{
+		MaxAttempts:  o.MaxAttempts,
+		InitialDelay: o.InitialDelay,
+		MaxDelay:     o.MaxDelay,
+		Jitter:       o.Jitter,
+		MaxElapsed:   o.MaxElapsed,
+	}
+}
+
+// retryFromRecord 还原持久化任务的重试策略
+func retryFromRecord(r *mongodb.QueuedRetry) *RetryOverride {
+	if r == nil {
+		return nil
+	}
+	return &RetryOverride{
+		MaxAttempts:  r.MaxAttempts,
+		InitialDelay: r.InitialDelay,
+		MaxDelay:     r.MaxDelay,
+		Jitter:       r.Jitter,
+		MaxElapsed:   r.MaxElapsed,
+	}
+}
+
+// parse 解析接口目录中的重试策略，空字符串和为 0 的 max_attempts 表示未填写，"0s" 和 0 的 jitter 覆盖默认值
+func (r *EndpointRetry) parse() (*RetryOverride, error) {
+	if r == nil {
+		return nil, nil
+	}
+	override := &RetryOverride{}
+	var errs []error
+	for _, field := range []struct {
+		name   string
+		value  string
+		target **time.Duration
+	}{
+		{"initial_delay", r.InitialDelay, &override.InitialDelay},
+		{"max_delay", r.MaxDelay, &override.MaxDelay},
+		{"max_elapsed", r.MaxElapsed, &override.MaxElapsed},
+	} {
+		if field.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(field.value)
+		if err != nil || d < 0 {
+			errs = append(errs, fmt.Errorf("retry.%s 格式错误: %q", field.name, field.value))
+			continue
+		}
+		*field.target = &d
+	}
+	switch {
+	case r.MaxAttempts < 0:
+		errs = append(errs, fmt.Errorf("retry.max_attempts 不能为负数，当前为 %d", r.MaxAttempts))
+	case r.MaxAttempts > 0:
+		attempts := r.MaxAttempts
+		override.MaxAttempts = &attempts
+	}
+	if r.Jitter != nil {
+		if *r.Jitter < 0 || *r.Jitter > 1 {
+			errs = append(errs, fmt.Errorf("retry.jitter 必须在0到1之间，当前为 %v", *r.Jitter))
+		}
+		jitter := *r.Jitter
+		override.Jitter = &jitter
+	}
+	return override, errors.Join(errs...)
+}
@@ -0,0 +1,238 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	rateLimited := &TaskError{Class: ErrorRateLimited, StatusCode: http.StatusTooManyRequests, RetryAfter: 90 * time.Second, Err: errors.New("too many requests")}
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		err     error
+		want    time.Duration
+	}{
+		{"第一次重试", RetryPolicy{InitialDelay: time.Second, MaxDelay: time.Minute}, 1, nil, time.Second},
+		{"第二次重试翻倍", RetryPolicy{InitialDelay: time.Second, MaxDelay: time.Minute}, 2, nil, 2 * time.Second},
+		{"第五次重试", RetryPolicy{InitialDelay: time.Second, MaxDelay: time.Minute}, 5, nil, 16 * time.Second},
+		{"不超过上限", RetryPolicy{InitialDelay: time.Second, MaxDelay: time.Minute}, 7, nil, time.Minute},
+		{"重试次数很大时不溢出", RetryPolicy{InitialDelay: time.Second, MaxDelay: time.Minute}, 200, nil, time.Minute},
+		{"上限为0不限制", RetryPolicy{InitialDelay: time.Second}, 8, nil, 128 * time.Second},
+		{"初始等待为0", RetryPolicy{MaxDelay: time.Minute}, 3, nil, 0},
+		{"Retry-After 大于退避时间", RetryPolicy{InitialDelay: time.Second, MaxDelay: time.Minute}, 1, rateLimited, 90 * time.Second},
+		{"Retry-After 可以超过上限", RetryPolicy{InitialDelay: time.Second, MaxDelay: time.Minute}, 10, rateLimited, 90 * time.Second},
+		{"Retry-After 小于退避时间", RetryPolicy{InitialDelay: 2 * time.Minute, MaxDelay: 5 * time.Minute}, 1, rateLimited, 2 * time.Minute},
+		{"包装后的 Retry-After", RetryPolicy{InitialDelay: time.Second}, 1, fmt.Errorf("请求失败: %w", rateLimited), 90 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.backoff(tt.attempt, tt.err); got != tt.want {
+				t.Errorf("backoff(%d) = %v，期望 %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoffJitter(t *testing.T) {
+	policy := RetryPolicy{InitialDelay: time.Second, MaxDelay: 10 * time.Second, Jitter: 0.5}
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 500 * time.Millisecond, time.Second},
+		{3, 2 * time.Second, 4 * time.Second},
+		{10, 5 * time.Second, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempt), func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				if got := policy.backoff(tt.attempt, nil); got < tt.min || got > tt.max {
+					t.Fatalf("backoff(%d) = %v，应在 %v 到 %v 之间", tt.attempt, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+
+	// 随机减少后仍不少于 Retry-After
+	err := &TaskError{Class: ErrorRateLimited, RetryAfter: 10 * time.Second, Err: errors.New("429")}
+	for i := 0; i < 1000; i++ {
+		if got := policy.backoff(10, err); got < 10*time.Second {
+			t.Fatalf("backoff = %v，不应少于 Retry-After", got)
+		}
+	}
+}
+
+// ptr 返回指向 v 的指针，用于填写重试策略中的可选字段
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestRetryPolicyMerge(t *testing.T) {
+	base := RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.2, MaxElapsed: 10 * time.Minute}
+	tests := []struct {
+		name     string
+		override *RetryOverride
+		want     RetryPolicy
+	}{
+		{"未配置", nil, base},
+		{"未填写的字段使用默认值", &RetryOverride{MaxAttempts: ptr(5)}, RetryPolicy{MaxAttempts: 5, InitialDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.2, MaxElapsed: 10 * time.Minute}},
+		{"jitter 可以设为0", &RetryOverride{Jitter: ptr(0.0)}, RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second, MaxDelay: time.Minute, Jitter: 0, MaxElapsed: 10 * time.Minute}},
+		{"max_elapsed 可以设为0表示不限制", &RetryOverride{MaxElapsed: ptr(time.Duration(0))}, RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.2}},
+		{"等待时间可以设为0", &RetryOverride{InitialDelay: ptr(time.Duration(0)), MaxDelay: ptr(time.Duration(0))}, RetryPolicy{MaxAttempts: 3, Jitter: 0.2, MaxElapsed: 10 * time.Minute}},
+		{"覆盖所有字段", &RetryOverride{MaxAttempts: ptr(1), InitialDelay: ptr(2 * time.Second), MaxDelay: ptr(5 * time.Minute), Jitter: ptr(0.5), MaxElapsed: ptr(time.Hour)}, RetryPolicy{MaxAttempts: 1, InitialDelay: 2 * time.Second, MaxDelay: 5 * time.Minute, Jitter: 0.5, MaxElapsed: time.Hour}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base.merge(tt.override); got != tt.want {
+				t.Errorf("merge = %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndpointRetryParse(t *testing.T) {
+	jitter := 0.5
+	badJitter := 1.5
+	tests := []struct {
+		name    string
+		retry   *EndpointRetry
+		want    *RetryOverride
+		wantErr bool
+	}{
+		{name: "未配置", retry: nil, want: nil},
+		{name: "只填写部分字段", retry: &EndpointRetry{MaxAttempts: 5, MaxDelay: "5m"}, want: &RetryOverride{MaxAttempts: ptr(5), MaxDelay: ptr(5 * time.Minute)}},
+		{name: "所有字段", retry: &EndpointRetry{MaxAttempts: 2, InitialDelay: "10s", MaxDelay: "1m", Jitter: &jitter, MaxElapsed: "30m"},
+			want: &RetryOverride{MaxAttempts: ptr(2), InitialDelay: ptr(10 * time.Second), MaxDelay: ptr(time.Minute), Jitter: ptr(0.5), MaxElapsed: ptr(30 * time.Minute)}},
+		{name: "填写为0的字段", retry: &EndpointRetry{MaxElapsed: "0s", Jitter: ptr(0.0)},
+			want: &RetryOverride{MaxElapsed: ptr(time.Duration(0)), Jitter: ptr(0.0)}},
+		{name: "时间格式错误", retry: &EndpointRetry{InitialDelay: "10"}, wantErr: true},
+		{name: "时间为负数", retry: &EndpointRetry{MaxDelay: "-1s"}, wantErr: true},
+		{name: "次数为负数", retry: &EndpointRetry{MaxAttempts: -1}, wantErr: true},
+		{name: "jitter 超出范围", retry: &EndpointRetry{Jitter: &badJitter}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.retry.parse()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("应返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("返回错误: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parse = %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyRecord(t *testing.T) {
+	if (*RetryOverride)(nil).record() != nil || retryFromRecord(nil) != nil {
+		t.Error("未配置重试策略时应保存为空")
+	}
+	policy := &RetryOverride{MaxAttempts: ptr(5), InitialDelay: ptr(10 * time.Second), MaxElapsed: ptr(time.Duration(0))}
+	if got := retryFromRecord(policy.record()); !reflect.DeepEqual(got, policy) {
+		t.Errorf("还原的重试策略为 %+v，期望 %+v", got, policy)
+	}
+}
+
+func TestRetryPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		wantErr bool
+	}{
+		{"有效", RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.2}, false},
+		{"尝试次数为0", RetryPolicy{MaxAttempts: 0}, true},
+		{"等待时间为负数", RetryPolicy{MaxAttempts: 1, InitialDelay: -time.Second}, true},
+		{"jitter 超出范围", RetryPolicy{MaxAttempts: 1, Jitter: 1.1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v，期望返回错误: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	var syntaxErr *json.SyntaxError
+	jsonErr := json.Unmarshal([]byte("{"), &struct{}{})
+	if !errors.As(jsonErr, &syntaxErr) {
+		t.Fatalf("构造 JSON 解析错误失败: %v", jsonErr)
+	}
+	duplicate := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "duplicate key"}}}
+	tests := []struct {
+		name            string
+		err             error
+		class           string
+		retryable       bool
+		accountSpecific bool
+	}{
+		{"5xx", responseError(http.StatusBadGateway, nil, errors.New("bad gateway")), ErrorTransport, true, false},
+		{"未收到响应", responseError(0, nil, errors.New("connection reset")), ErrorTransport, true, false},
+		{"429", responseError(http.StatusTooManyRequests, nil, errors.New("too many requests")), ErrorRateLimited, true, true},
+		{"401", responseError(http.StatusUnauthorized, nil, errors.New("unauthorized")), ErrorAuth, true, true},
+		{"403", responseError(http.StatusForbidden, nil, errors.New("forbidden")), ErrorAuth, true, true},
+		{"404", responseError(http.StatusNotFound, nil, errors.New("not found")), ErrorPayload, false, false},
+		{"JSON 解析错误", jsonErr, ErrorPayload, false, false},
+		{"程序关闭", context.Canceled, ErrorCancelled, false, false},
+		{"超时", context.DeadlineExceeded, ErrorTransport, true, false},
+		{"重复键", duplicate, ErrorStorage, false, false},
+		{"其他数据库错误", mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 2, Message: "bad value"}}}, ErrorStorage, true, false},
+		{"处理器指定分类", fmt.Errorf("处理失败: %w", NewTaskError(ErrorPayload, errors.New("bad data"))), ErrorPayload, false, false},
+		{"未分类", errors.New("unexpected"), ErrorUnknown, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorClass(tt.err); got != tt.class {
+				t.Errorf("ErrorClass = %s，期望 %s", got, tt.class)
+			}
+			if got := Retryable(tt.err); got != tt.retryable {
+				t.Errorf("Retryable = %v，期望 %v", got, tt.retryable)
+			}
+			if got := AccountSpecific(tt.err); got != tt.accountSpecific {
+				t.Errorf("AccountSpecific = %v，期望 %v", got, tt.accountSpecific)
+			}
+		})
+	}
+}
+
+func TestResponseErrorRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "30")
+	if got := retryAfter(responseError(http.StatusTooManyRequests, &header, errors.New("429"))); got != 30*time.Second {
+		t.Errorf("Retry-After = %v，期望 30s", got)
+	}
+	header.Set("Retry-After", "Wed, 21 Oct 2026 07:28:00 GMT")
+	if got := retryAfter(responseError(http.StatusTooManyRequests, &header, errors.New("429"))); got != 0 {
+		t.Errorf("无法解析的 Retry-After 应忽略，当前为 %v", got)
+	}
+}
+
+func TestRestoreTaskRetry(t *testing.T) {
+	d := &TaskDispatcher{handlers: func(string) (func(context.Context, *colly.Response, *Account, *TaskDispatcher) error, error) {
+		return func(context.Context, *colly.Response, *Account, *TaskDispatcher) error { return nil }, nil
+	}}
+	retry := &RetryOverride{MaxAttempts: ptr(5), Jitter: ptr(0.0)}
+	task := d.restoreTask(&mongodb.QueuedTask{ID: "1", Handler: "author", URL: "https://example.com", Retry: retry.record()})
+	if task == nil || !reflect.DeepEqual(task.Retry, retry) {
+		t.Errorf("恢复的任务重试策略为 %+v，期望 %+v", task.Retry, retry)
+	}
+}
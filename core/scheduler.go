@@ -1,21 +1,43 @@
 package core
 
 import (
+	"collyDemo/mongodb"
+	"collyDemo/pkg/schedule"
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"math/rand"
+	"slices"
 	"sync"
 	"time"
 )
 
+// checkInterval 检查到期任务的间隔，cron 表达式最小精度为秒
+const checkInterval = time.Second
+
 // ScheduledTask 定时任务结构
 type ScheduledTask struct {
 	ID          string
 	Name        string
 	Description string
 	Schedule    string // cron表达式或时间间隔
-	Handler     func() error
+	Handler     func(context.Context) error
+	Policy      TaskPolicy
+	plan        schedule.Schedule
+	constraints *schedule.Constraints // 调度策略中的允许执行时间段和停止日期，为 nil 表示不限制
 	LastRun     time.Time
 	NextRun     time.Time
 	Enabled     bool
+	Paused      bool               // 是否手动暂停
+	pending     int                // run_all 策略下待补跑的次数
+	running     bool               // 是否正在执行
+	queued      bool               // queue 策略下是否有排队等待的执行
+	runGen      int                // 执行序号，用于识别被 replace 取消的旧执行
+	cancel      context.CancelFunc // 取消当前执行
+	triggered   bool               // 上游任务均已完成，等待执行
+	manual      bool               // 手动触发，等待执行
+	deps        dependencies       // 依赖任务本轮的等待状态
 	mu          sync.Mutex
 }
 
@@ -24,42 +46,56 @@ type Scheduler struct {
 	tasks         map[string]*ScheduledTask
 	dispatcher    *TaskDispatcher
 	taskScheduler *TaskScheduler
+	states        *mongodb.SchedulerStateDAO
+	leader        *LeaderElector // 多实例部署时的主节点选举，为 nil 表示单实例运行
+	leading       bool           // 是否以主节点身份调度，只在主循环中读写
 	stop          chan struct{}
 	wg            sync.WaitGroup
 	mu            sync.RWMutex
 }
 
-// NewScheduler 创建定时任务调度器
-func NewScheduler(dispatcher *TaskDispatcher, taskScheduler *TaskScheduler) *Scheduler {
-	return &Scheduler{
+// NewScheduler 创建定时任务调度器，states 为空时不持久化任务状态，leader 为空时不参与主节点选举
+func NewScheduler(dispatcher *TaskDispatcher, taskScheduler *TaskScheduler, states *mongodb.SchedulerStateDAO, leader *LeaderElector) *Scheduler {
+	s := &Scheduler{
 		tasks:         make(map[string]*ScheduledTask),
 		dispatcher:    dispatcher,
 		taskScheduler: taskScheduler,
+		states:        states,
+		leader:        leader,
 		stop:          make(chan struct{}),
 	}
+	dispatcher.Runs().OnFinish(s.onRunFinished)
+	return s
 }
 
-// AddTask 添加定时任务
-func (s *Scheduler) AddTask(id, name, description, schedule string, handler func() error) {
+// AddTask 添加定时任务，执行频率无法解析时返回错误
+func (s *Scheduler) AddTask(id, name, description, spec string, handler func(context.Context) error) error {
+	plan, err := schedule.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("定时任务 %s: %w", id, err)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	now := time.Now()
-	nextRun := s.calculateNextRun(schedule, now)
+	nextRun := plan.Next(now)
 
 	task := &ScheduledTask{
 		ID:          id,
 		Name:        name,
 		Description: description,
-		Schedule:    schedule,
+		Schedule:    spec,
 		Handler:     handler,
+		plan:        plan,
 		LastRun:     time.Time{},
 		NextRun:     nextRun,
 		Enabled:     true,
 	}
 
 	s.tasks[id] = task
-	log.Printf("添加定时任务: %s (%s), 下次执行时间: %s", name, schedule, nextRun.Format("2006-01-02 15:04:05"))
+	log.Printf("添加定时任务: %s (%s), 下次执行时间: %s", name, spec, nextRun.Format("2006-01-02 15:04:05"))
+	return nil
 }
 
 // RemoveTask 移除定时任务
@@ -68,15 +104,18 @@ func (s *Scheduler) RemoveTask(id string) {
 	defer s.mu.Unlock()
 
 	if task, exists := s.tasks[id]; exists {
+		task.mu.Lock()
 		task.Enabled = false
+		task.mu.Unlock()
 		delete(s.tasks, id)
 		log.Printf("移除定时任务: %s", task.Name)
 	}
 }
 
-// Start 启动调度器
+// Start 恢复持久化的任务状态并启动调度器，参与主节点选举时在成为主节点后才恢复状态并开始调度
 func (s *Scheduler) Start() {
 	log.Println("启动定时任务调度器")
+	s.updateLeadership()
 
 	s.wg.Add(1)
 	go s.run()
@@ -93,7 +132,7 @@ func (s *Scheduler) Stop() {
 func (s *Scheduler) run() {
 	defer s.wg.Done()
 
-	ticker := time.NewTicker(1 * time.Minute) // 每分钟检查一次
+	ticker := time.NewTicker(checkInterval)
 	defer ticker.Stop()
 
 	for {
@@ -101,8 +140,51 @@ func (s *Scheduler) run() {
 		case <-s.stop:
 			return
 		case <-ticker.C:
-			s.checkAndExecuteTasks()
+			if s.updateLeadership() {
+				s.checkAndExecuteTasks()
+			}
+		}
+	}
+}
+
+// IsLeader 当前实例是否负责触发定时任务，单实例运行时始终为 true
+func (s *Scheduler) IsLeader() bool {
+	return s.leader == nil || s.leader.IsLeader()
+}
+
+// updateLeadership 处理主节点身份变化并返回是否以主节点身份调度：
+// 成为主节点时从数据库恢复上一任主节点保存的任务状态，失去主节点身份时取消执行中的任务并清除排队和补跑
+func (s *Scheduler) updateLeadership() bool {
+	leader := s.IsLeader()
+	switch {
+	case leader && !s.leading:
+		if s.leader != nil {
+			log.Println("成为主节点，恢复定时任务状态并开始调度")
+		}
+		s.leading = true
+		s.restoreStates()
+	case !leader && s.leading:
+		log.Println("失去主节点身份，停止调度定时任务")
+		s.leading = false
+		s.revokeTasks()
+	}
+	return s.leading
+}
+
+// revokeTasks 取消执行中的任务，清除排队和待补跑的执行
+func (s *Scheduler) revokeTasks() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, task := range s.tasks {
+		task.mu.Lock()
+		if task.running {
+			task.cancel()
 		}
+		task.queued = false
+		task.pending = 0
+		task.triggered = false
+		task.manual = false
+		task.mu.Unlock()
 	}
 }
 
@@ -120,59 +202,129 @@ func (s *Scheduler) checkAndExecuteTasks() {
 	now := time.Now()
 	for _, task := range tasks {
 		task.mu.Lock()
-		if task.Enabled && now.After(task.NextRun) {
-			// 执行任务
-			go s.executeTask(task)
+		s.checkDependencyTimeout(task, now)
+		due := !task.NextRun.IsZero() && !now.Before(task.NextRun)
+		// 待补跑和依赖触发的执行同样只在允许执行的时间段内开始
+		allowed := task.constraints.Allowed(now)
+		catchUp := task.pending > 0 && allowed
+		triggered := task.triggered && allowed
+		if !task.Enabled || task.Paused || !(due || catchUp || triggered || task.manual) {
+			task.mu.Unlock()
+			continue
+		}
+
+		// 先推进下次执行时间并清除触发标记，避免下一轮检查重复触发；
+		// 手动、到期和依赖触发同时发生时合并为一次执行，各自的状态分别更新
+		if !(due || triggered || task.manual) {
+			// 补跑在实际开始或排队时才减少待补跑次数，上一次执行未结束时等待，不受 forbid 和 replace 影响
+			s.catchUp(task, now)
+			task.mu.Unlock()
+			continue
+		}
+		task.manual = false
+		if due {
+			task.NextRun = s.calculateNextRun(task, now)
+		}
+		if triggered {
+			task.triggered = false
+		}
+
+		if !task.running {
+			s.startTask(task, now)
+			task.mu.Unlock()
+			continue
+		}
+
+		switch task.Policy.overlap() {
+		case schedule.OverlapQueue:
+			if !task.queued {
+				task.queued = true
+				log.Printf("定时任务仍在执行，排队等待: %s", task.Name)
+			} else {
+				log.Printf("定时任务已有排队的执行，跳过本次: %s", task.Name)
+			}
+		case schedule.OverlapReplace:
+			log.Printf("定时任务仍在执行，取消上一次执行: %s", task.Name)
+			task.cancel()
+			s.startTask(task, now)
+		default:
+			log.Printf("定时任务仍在执行，跳过本次: %s", task.Name)
 		}
 		task.mu.Unlock()
 	}
 }
 
-// executeTask 执行单个任务
-func (s *Scheduler) executeTask(task *ScheduledTask) {
-	task.mu.Lock()
-	task.LastRun = time.Now()
-	task.mu.Unlock()
+// catchUp 开始一次待补跑的执行：上一次执行已结束时立即开始，queue 策略下排队，否则等待上一次执行结束。调用方需持有 task.mu
+func (s *Scheduler) catchUp(task *ScheduledTask, now time.Time) {
+	switch {
+	case !task.running:
+		task.pending--
+		s.startTask(task, now)
+	case task.Policy.overlap() == schedule.OverlapQueue && !task.queued:
+		task.pending--
+		task.queued = true
+		log.Printf("定时任务仍在执行，补跑排队等待: %s, 剩余补跑: %d", task.Name, task.pending)
+	}
+}
+
+// constrainedPlan 返回跳过不允许执行时间的执行计划，调用方需持有 task.mu
+func (t *ScheduledTask) constrainedPlan() schedule.Schedule {
+	return schedule.Constrain(t.plan, t.constraints)
+}
 
+// calculateNextRun 计算 from 之后的下次执行时间：跳过允许时间段之外和停止日期内的触发时间，
+// 再随机推迟 [0, Jitter)，推迟后超出允许时间段时不推迟。依赖任务返回零值。调用方需持有 task.mu
+func (s *Scheduler) calculateNextRun(task *ScheduledTask, from time.Time) time.Time {
+	// 依赖任务由上游任务触发，不按执行频率执行
+	if len(task.Policy.DependsOn) > 0 {
+		return time.Time{}
+	}
+	next := task.constrainedPlan().Next(from)
+	if next.IsZero() || task.Policy.Jitter <= 0 {
+		return next
+	}
+	if delayed := next.Add(time.Duration(rand.Int63n(int64(task.Policy.Jitter)))); task.constraints.Allowed(delayed) {
+		return delayed
+	}
+	return next
+}
+
+// startTask 开始一次执行，调用方需持有 task.mu
+func (s *Scheduler) startTask(task *ScheduledTask, now time.Time) {
+	ctx, cancel := context.WithCancel(context.Background())
+	task.running = true
+	task.runGen++
+	task.cancel = cancel
+	task.LastRun = now
+	go s.executeTask(ctx, task, task.runGen)
+}
+
+// executeTask 执行单个任务，结束后若有排队的执行则立即开始
+func (s *Scheduler) executeTask(ctx context.Context, task *ScheduledTask, gen int) {
 	log.Printf("执行定时任务: %s", task.Name)
+	s.saveState(task)
 
-	if err := task.Handler(); err != nil {
+	if err := task.Handler(ctx); err != nil {
 		log.Printf("定时任务执行失败: %s, 错误: %v", task.Name, err)
 	} else {
 		log.Printf("定时任务执行成功: %s", task.Name)
 	}
 
-	// 计算下次执行时间
 	task.mu.Lock()
-	task.NextRun = s.calculateNextRun(task.Schedule, time.Now())
-	task.mu.Unlock()
-
-	log.Printf("定时任务下次执行时间: %s, %s", task.Name, task.NextRun.Format("2006-01-02 15:04:05"))
-}
-
-// calculateNextRun 计算下次执行时间
-func (s *Scheduler) calculateNextRun(schedule string, from time.Time) time.Time {
-	switch schedule {
-	case "hourly":
-		// 每小时执行一次
-		return from.Add(1 * time.Hour)
-	case "daily":
-		// 每天执行一次
-		return from.Add(24 * time.Hour)
-	case "weekly":
-		// 每周执行一次
-		return from.Add(7 * 24 * time.Hour)
-	case "monthly":
-		// 每月执行一次
-		return from.AddDate(0, 1, 0)
-	default:
-		// 尝试解析时间间隔 (如 "2h", "30m", "1d")
-		if duration, err := time.ParseDuration(schedule); err == nil {
-			return from.Add(duration)
+	// 被 replace 取消的旧执行不修改状态
+	if task.runGen == gen {
+		task.running = false
+		task.cancel()
+		if task.queued && task.Enabled {
+			task.queued = false
+			log.Printf("开始排队的定时任务: %s", task.Name)
+			s.startTask(task, time.Now())
 		}
-		// 默认每小时执行一次
-		return from.Add(1 * time.Hour)
 	}
+	nextRun := task.NextRun
+	task.mu.Unlock()
+
+	log.Printf("定时任务下次执行时间: %s, %s", task.Name, nextRun.Format("2006-01-02 15:04:05"))
 }
 
 // GetTaskStatus 获取任务状态
@@ -183,224 +335,264 @@ func (s *Scheduler) GetTaskStatus() map[string]interface{} {
 	status := make(map[string]interface{})
 	for id, task := range s.tasks {
 		task.mu.Lock()
-		status[id] = map[string]interface{}{
+		taskStatus := map[string]interface{}{
 			"name":        task.Name,
 			"description": task.Description,
 			"schedule":    task.Schedule,
 			"enabled":     task.Enabled,
+			"paused":      task.Paused,
 			"last_run":    task.LastRun,
 			"next_run":    task.NextRun,
+			"misfire":     task.Policy.misfire(),
+			"pending":     task.pending,
+			"overlap":     task.Policy.overlap(),
+			"running":     task.running,
+			"queued":      task.queued,
+			"windows":     task.Policy.Windows,
+			"blackouts":   task.Policy.Blackouts,
+			"jitter":      task.Policy.Jitter,
+			"depends_on":  task.Policy.DependsOn,
+			"triggered":   task.triggered,
+		}
+		if len(task.Policy.DependsOn) > 0 {
+			taskStatus["dependencies"] = task.dependencyStatus()
 		}
+		status[id] = taskStatus
 		task.mu.Unlock()
 	}
 
 	return status
 }
 
-// 预定义的定时任务处理器
-func (s *Scheduler) createMainTasksHandler() func() error {
-	return func() error {
-		log.Println("执行主要数据采集任务")
-		s.taskScheduler.AddMainTasks()
+// createEndpointTasksHandler 将单个接口加入任务队列，排名接口同样只加入自身，按各自的执行频率采集。
+// 每次触发开始一个新的采集批次，批次ID随 meta 传递给派生的分页和详情任务，批次结束后处理器才返回。
+func (s *Scheduler) createEndpointTasksHandler(id string, ep *Endpoint) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		log.Printf("执行%s任务", ep.Title)
+		runs := s.dispatcher.Runs()
+		runID := runs.Start(id, ep.Name)
+		run := runs.watch(runID)
+		if err := s.taskScheduler.AddEndpointTask(ep.Name, map[string]interface{}{MetaRunID: runID}); err != nil {
+			// 相同的任务仍在去重时间内，本次执行视为成功，依赖任务照常触发
+			if errors.Is(err, ErrTaskDuplicate) {
+				runs.Skip(runID, err)
+				return nil
+			}
+			runs.Abort(runID, err)
+			return err
+		}
+
+		// 等待采集批次结束，派生的分页和详情任务完成前视为仍在执行，重叠执行策略据此生效
+		record, err := run.wait(ctx)
+		if err != nil {
+			return err
+		}
+		if record.Status != mongodb.CrawlRunSucceeded {
+			return fmt.Errorf("采集批次 %s 未成功结束，状态: %s", runID, record.Status)
+		}
 		return nil
 	}
 }
 
-func (s *Scheduler) createRankTasksHandler() func() error {
-	return func() error {
-		log.Println("执行排名数据采集任务")
-		s.taskScheduler.AddRankTasks()
-		return nil
-	}
+// ScheduleOff 表示停用任务的执行频率
+const ScheduleOff = schedule.Off
+
+// configTask 可由配置文件控制执行频率的定时任务
+type configTask struct {
+	id          string
+	name        string
+	description string
+	schedule    string // 接口目录中的默认执行频率
+	handler     func(context.Context) error
 }
 
-func (s *Scheduler) createAuthorTasksHandler() func() error {
-	return func() error {
-		log.Println("执行达人数据采集任务")
-		// 只添加达人相关任务
-		tasks := GetMainTasks()
-		if len(tasks) > 0 {
-			task := &Task{
-				URL:     tasks[0].URL,
-				Method:  tasks[0].Method,
-				Headers: s.taskScheduler.headers,
-				Body:    tasks[0].Body,
-				Handler: s.taskScheduler.handlers["author"],
-				Meta:    tasks[0].Meta,
-			}
-			s.dispatcher.AddTask(task)
+// configTasks 根据接口目录生成定时任务，键为接口名称，与配置文件 main_tasks、rank_tasks 中的键一致
+func (s *Scheduler) configTasks() map[string]configTask {
+	tasks := make(map[string]configTask)
+	for _, ep := range s.taskScheduler.Catalog().Endpoints {
+		id := ep.Name + "_tasks"
+		tasks[ep.Name] = configTask{
+			id:          id,
+			name:        ep.Title,
+			description: ep.Description,
+			schedule:    ep.Schedule,
+			handler:     s.createEndpointTasksHandler(id, ep),
 		}
-		return nil
 	}
+	return tasks
 }
 
-func (s *Scheduler) createBrandTasksHandler() func() error {
-	return func() error {
-		log.Println("执行品牌数据采集任务")
-		tasks := GetMainTasks()
-		if len(tasks) > 1 {
-			task := &Task{
-				URL:     tasks[1].URL,
-				Method:  tasks[1].Method,
-				Headers: s.taskScheduler.headers,
-				Body:    tasks[1].Body,
-				Handler: s.taskScheduler.handlers["brand"],
-				Meta:    tasks[1].Meta,
-			}
-			s.dispatcher.AddTask(task)
-		}
-		return nil
-	}
+// InitTasksWithConfig 根据接口目录初始化定时任务，specs 中的执行频率和调度策略覆盖目录中的默认值
+func (s *Scheduler) InitTasksWithConfig(specs map[string]TaskSpec) error {
+	return s.applyConfigTasks(specs, false)
+}
+
+// ApplySchedules 将配置中的执行频率同步到已注册的定时任务：
+// 新增配置中出现的任务，移除被设为 off 的任务，频率变化的任务原地调整并重新计算下次执行时间，
+// 从配置中删除的任务恢复为接口目录中的默认频率。
+// 已分发到任务队列或正在执行的请求不受影响。
+func (s *Scheduler) ApplySchedules(specs map[string]TaskSpec) error {
+	return s.applyConfigTasks(specs, true)
 }
 
-func (s *Scheduler) createLiveTasksHandler() func() error {
-	return func() error {
-		log.Println("执行直播数据采集任务")
-		tasks := GetMainTasks()
-		if len(tasks) > 2 {
-			task := &Task{
-				URL:     tasks[2].URL,
-				Method:  tasks[2].Method,
-				Headers: s.taskScheduler.headers,
-				Body:    tasks[2].Body,
-				Handler: s.taskScheduler.handlers["live"],
-				Meta:    tasks[2].Meta,
+// applyConfigTasks reload 为 true 时为每项变更输出审计日志；
+// 配置了目录中不存在的任务或频率无法解析时返回错误，不做任何修改
+func (s *Scheduler) applyConfigTasks(specs map[string]TaskSpec, reload bool) error {
+	defs := s.configTasks()
+	resolved := make(map[string]TaskSpec, len(defs))
+	var errs []error
+	for key := range specs {
+		if _, ok := defs[key]; !ok {
+			errs = append(errs, fmt.Errorf("接口目录中不存在任务: %s", key))
+		}
+	}
+	for key, def := range defs {
+		spec := specs[key]
+		if spec.Schedule == "" {
+			spec.Schedule = def.schedule
+		}
+		if err := spec.Policy.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		} else if spec.Schedule != ScheduleOff {
+			plan, err := schedule.Parse(spec.Schedule)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			} else if constraints, _ := spec.Policy.constraints(); schedule.Constrain(plan, constraints).Next(time.Now()).IsZero() {
+				errs = append(errs, fmt.Errorf("%s: 执行频率 %q 在允许执行的时间段内永远不会触发", key, spec.Schedule))
 			}
-			s.dispatcher.AddTask(task)
 		}
-		return nil
+		resolved[key] = spec
+	}
+	graph := make(map[string][]string)
+	for key, spec := range resolved {
+		if len(spec.Policy.DependsOn) > 0 {
+			graph[key] = spec.Policy.DependsOn
+		}
+	}
+	errs = append(errs, checkDependencyGraph(graph, defs)...)
+	if err := errors.Join(errs...); err != nil {
+		return err
 	}
-}
 
-func (s *Scheduler) createProductTasksHandler() func() error {
-	return func() error {
-		log.Println("执行商品数据采集任务")
-		tasks := GetMainTasks()
-		if len(tasks) > 3 {
-			task := &Task{
-				URL:     tasks[3].URL,
-				Method:  tasks[3].Method,
-				Headers: s.taskScheduler.headers,
-				Body:    tasks[3].Body,
-				Handler: s.taskScheduler.handlers["product"],
-				Meta:    tasks[3].Meta,
+	// 配置中的依赖为任务名称，转换为定时任务ID
+	for key, spec := range resolved {
+		if len(spec.Policy.DependsOn) == 0 {
+			continue
+		}
+		ids := make([]string, len(spec.Policy.DependsOn))
+		for i, name := range spec.Policy.DependsOn {
+			ids[i] = defs[name].id
+			if resolved[name].Schedule == ScheduleOff {
+				log.Printf("警告: %s 依赖的任务 %s 已停用，不会被触发", key, name)
 			}
-			s.dispatcher.AddTask(task)
 		}
-		return nil
+		spec.Policy.DependsOn = ids
+		resolved[key] = spec
 	}
-}
 
-func (s *Scheduler) createStoreTasksHandler() func() error {
-	return func() error {
-		log.Println("执行店铺数据采集任务")
-		tasks := GetMainTasks()
-		if len(tasks) > 4 {
-			task := &Task{
-				URL:     tasks[4].URL,
-				Method:  tasks[4].Method,
-				Headers: s.taskScheduler.headers,
-				Body:    tasks[4].Body,
-				Handler: s.taskScheduler.handlers["store"],
-				Meta:    tasks[4].Meta,
+	for key, def := range defs {
+		spec := resolved[key]
+
+		s.mu.RLock()
+		task, exists := s.tasks[def.id]
+		s.mu.RUnlock()
+
+		switch {
+		case spec.Schedule == ScheduleOff:
+			if exists {
+				s.RemoveTask(def.id)
+				log.Printf("[配置变更] 停用定时任务: %s", def.id)
+			}
+		case !exists:
+			if err := s.AddTask(def.id, def.name, def.description, spec.Schedule, def.handler); err != nil {
+				return err
 			}
-			s.dispatcher.AddTask(task)
+			s.setPolicy(def.id, spec.Policy, false)
+			if reload {
+				log.Printf("[配置变更] 新增定时任务: %s (%s)", def.id, spec.Schedule)
+			}
+		default:
+			s.reschedule(task, spec.Schedule, "[配置变更]")
+			s.setPolicy(def.id, spec.Policy, reload)
 		}
-		return nil
 	}
+	return nil
 }
 
-func (s *Scheduler) createVideoTasksHandler() func() error {
-	return func() error {
-		log.Println("执行视频数据采集任务")
-		tasks := GetMainTasks()
-		if len(tasks) > 5 {
-			task := &Task{
-				URL:     tasks[5].URL,
-				Method:  tasks[5].Method,
-				Headers: s.taskScheduler.headers,
-				Body:    tasks[5].Body,
-				Handler: s.taskScheduler.handlers["video"],
-				Meta:    tasks[5].Meta,
-			}
-			s.dispatcher.AddTask(task)
+// setPolicy 更新任务的调度策略，reload 为 true 时输出审计日志
+func (s *Scheduler) setPolicy(id string, policy TaskPolicy, reload bool) {
+	s.mu.RLock()
+	task, exists := s.tasks[id]
+	s.mu.RUnlock()
+	if !exists {
+		return
+	}
+
+	task.mu.Lock()
+	defer task.mu.Unlock()
+	if task.Policy.Equal(policy) {
+		return
+	}
+	constraints, err := policy.constraints()
+	if err != nil {
+		log.Printf("[配置变更] 调度策略无效，保持不变: %s, 错误: %v", id, err)
+		return
+	}
+	old := task.Policy
+	task.Policy = policy
+	task.constraints = constraints
+	if reload {
+		log.Printf("[配置变更] 调整定时任务策略: %s, %+v -> %+v", id, old, policy)
+	}
+
+	// 依赖变化时重新开始等待上游任务
+	if !slices.Equal(old.DependsOn, policy.DependsOn) {
+		task.deps.reset(DependencyIdle, "")
+		task.triggered = false
+	}
+
+	// 允许执行时间、随机推迟或依赖变化时重新计算下次执行时间
+	if !slices.Equal(old.Windows, policy.Windows) || !slices.Equal(old.Blackouts, policy.Blackouts) || old.Jitter != policy.Jitter ||
+		!slices.Equal(old.DependsOn, policy.DependsOn) {
+		from := task.LastRun
+		if from.IsZero() {
+			from = time.Now()
+		}
+		task.NextRun = s.calculateNextRun(task, from)
+		if reload {
+			log.Printf("[配置变更] 重新计算下次执行时间: %s, %s", id, formatTime(task.NextRun))
+			go s.saveState(task)
 		}
-		return nil
 	}
 }
 
-// InitDefaultTasks 初始化默认定时任务
-func (s *Scheduler) InitDefaultTasks() {
-	// 主要数据采集任务 - 每天执行一次
-	s.AddTask("main_tasks", "主要数据采集", "采集达人、品牌、直播、商品、店铺、视频数据", "daily", s.createMainTasksHandler())
-
-	// 排名数据采集任务 - 每小时执行一次
-	s.AddTask("rank_tasks", "排名数据采集", "采集各种排名数据", "hourly", s.createRankTasksHandler())
-
-	// 单独的数据采集任务 - 可以设置不同的执行频率
-	s.AddTask("author_tasks", "达人数据采集", "采集达人数据", "6h", s.createAuthorTasksHandler())
-	s.AddTask("brand_tasks", "品牌数据采集", "采集品牌数据", "12h", s.createBrandTasksHandler())
-	s.AddTask("live_tasks", "直播数据采集", "采集直播数据", "2h", s.createLiveTasksHandler())
-	s.AddTask("product_tasks", "商品数据采集", "采集商品数据", "4h", s.createProductTasksHandler())
-	s.AddTask("store_tasks", "店铺数据采集", "采集店铺数据", "8h", s.createStoreTasksHandler())
-	s.AddTask("video_tasks", "视频数据采集", "采集视频数据", "3h", s.createVideoTasksHandler())
-}
+// reschedule 调整已有任务的执行频率，下次执行时间以上次执行时间为基准重新计算，source 为日志中的变更来源
+func (s *Scheduler) reschedule(task *ScheduledTask, spec, source string) {
+	task.mu.Lock()
+	defer task.mu.Unlock()
 
-// InitTasksWithConfig 使用配置文件初始化定时任务
-func (s *Scheduler) InitTasksWithConfig(mainSchedules, rankSchedules map[string]string) {
-	// 主要数据采集任务
-	for taskType, schedule := range mainSchedules {
-		switch taskType {
-		case "author":
-			s.AddTask("author_tasks", "达人数据采集", "采集达人数据", schedule, s.createAuthorTasksHandler())
-		case "brand":
-			s.AddTask("brand_tasks", "品牌数据采集", "采集品牌数据", schedule, s.createBrandTasksHandler())
-		case "live":
-			s.AddTask("live_tasks", "直播数据采集", "采集直播数据", schedule, s.createLiveTasksHandler())
-		case "product":
-			s.AddTask("product_tasks", "商品数据采集", "采集商品数据", schedule, s.createProductTasksHandler())
-		case "store":
-			s.AddTask("store_tasks", "店铺数据采集", "采集店铺数据", schedule, s.createStoreTasksHandler())
-		case "video":
-			s.AddTask("video_tasks", "视频数据采集", "采集视频数据", schedule, s.createVideoTasksHandler())
-		}
+	if task.Schedule == spec {
+		return
+	}
+	plan, err := schedule.Parse(spec)
+	if err != nil {
+		log.Printf("%s 执行频率无效，保持不变: %s, 错误: %v", source, task.ID, err)
+		return
 	}
 
-	// 排名数据采集任务
-	for taskType, schedule := range rankSchedules {
-		switch taskType {
-		case "author_fans_increase_rank":
-			s.AddTask("author_fans_increase_rank_tasks", "达人涨粉榜采集", "采集达人涨粉榜数据", schedule, s.createRankTasksHandler())
-		case "author_fans_decrease_rank":
-			s.AddTask("author_fans_decrease_rank_tasks", "达人掉粉榜采集", "采集达人掉粉榜数据", schedule, s.createRankTasksHandler())
-		case "author_potential_rank":
-			s.AddTask("author_potential_rank_tasks", "达人带货潜力榜采集", "采集达人带货潜力榜数据", schedule, s.createRankTasksHandler())
-		case "product_hot_sale_rank":
-			s.AddTask("product_hot_sale_rank_tasks", "商品热销榜采集", "采集商品热销榜数据", schedule, s.createRankTasksHandler())
-		case "product_real_time_sales_rank":
-			s.AddTask("product_real_time_sales_rank_tasks", "商品实时销量榜采集", "采集商品实时销量榜数据", schedule, s.createRankTasksHandler())
-		case "live_author_sales_rank":
-			s.AddTask("live_author_sales_rank_tasks", "直播达人带货榜采集", "采集直播达人带货榜数据", schedule, s.createRankTasksHandler())
-		case "live_hot_push_rank":
-			s.AddTask("live_hot_push_rank_tasks", "直播热推榜采集", "采集直播热推榜数据", schedule, s.createRankTasksHandler())
-		case "hot_video_rank":
-			s.AddTask("hot_video_rank_tasks", "热门视频榜采集", "采集热门视频榜数据", schedule, s.createRankTasksHandler())
-		case "ecommerce_video_rank":
-			s.AddTask("ecommerce_video_rank_tasks", "电商视频榜采集", "采集电商视频榜数据", schedule, s.createRankTasksHandler())
-		case "video_hot_push":
-			s.AddTask("video_hot_push_tasks", "视频热推采集", "采集视频热推数据", schedule, s.createRankTasksHandler())
-		case "hot_sale_shop":
-			s.AddTask("hot_sale_shop_tasks", "热销小店采集", "采集热销小店数据", schedule, s.createRankTasksHandler())
-		case "site_hourly_rank":
-			s.AddTask("site_hourly_rank_tasks", "全站小时榜采集", "采集全站小时榜数据", schedule, s.createRankTasksHandler())
-		case "sales_hourly_rank":
-			s.AddTask("sales_hourly_rank_tasks", "带货小时榜采集", "采集带货小时榜数据", schedule, s.createRankTasksHandler())
-		case "real_time_hot_spot":
-			s.AddTask("real_time_hot_spot_tasks", "实时热点采集", "采集实时热点数据", schedule, s.createRankTasksHandler())
-		case "soaring_hot_spot":
-			s.AddTask("soaring_hot_spot_tasks", "飙升热点采集", "采集飙升热点数据", schedule, s.createRankTasksHandler())
-		case "explore_hot_burst":
-			s.AddTask("explore_hot_burst_tasks", "探测爆款采集", "采集探测爆款数据", schedule, s.createRankTasksHandler())
-		}
+	from := task.LastRun
+	if from.IsZero() {
+		from = time.Now()
 	}
+	oldSchedule := task.Schedule
+	task.Schedule = spec
+	task.plan = plan
+	task.NextRun = s.calculateNextRun(task, from)
+
+	log.Printf("%s 调整定时任务: %s, 执行频率 %s -> %s, 下次执行时间: %s",
+		source, task.ID, oldSchedule, spec, task.NextRun.Format("2006-01-02 15:04:05"))
+	go s.saveState(task)
 }
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"collyDemo/pkg/schedule"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// 手动控制定时任务的错误
+var (
+	ErrTaskNotFound = errors.New("定时任务不存在")
+	ErrTaskPaused   = errors.New("定时任务已暂停")
+	ErrNotLeader    = errors.New("当前实例不是主节点")
+)
+
+// task 获取定时任务
+func (s *Scheduler) task(id string) (*ScheduledTask, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	task, ok := s.tasks[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	return task, nil
+}
+
+// TriggerNow 立即执行一次任务，不受允许执行时间段和依赖限制，仍遵守重叠执行策略，不影响下次执行时间
+func (s *Scheduler) TriggerNow(id string) error {
+	if !s.IsLeader() {
+		return ErrNotLeader
+	}
+	task, err := s.task(id)
+	if err != nil {
+		return err
+	}
+
+	task.mu.Lock()
+	defer task.mu.Unlock()
+	if task.Paused {
+		return fmt.Errorf("%w: %s", ErrTaskPaused, id)
+	}
+	task.manual = true
+	log.Printf("[手动操作] 立即执行定时任务: %s", id)
+	return nil
+}
+
+// Pause 暂停任务，取消执行中的任务并清除排队、补跑和依赖触发的执行，暂停期间到期的执行不补跑。
+// 已分发到任务队列的请求不受影响。
+func (s *Scheduler) Pause(id string) error {
+	if !s.IsLeader() {
+		return ErrNotLeader
+	}
+	task, err := s.task(id)
+	if err != nil {
+		return err
+	}
+
+	task.mu.Lock()
+	if task.Paused {
+		task.mu.Unlock()
+		return nil
+	}
+	task.Paused = true
+	if task.running {
+		task.cancel()
+	}
+	task.queued = false
+	task.pending = 0
+	task.triggered = false
+	task.manual = false
+	task.mu.Unlock()
+
+	log.Printf("[手动操作] 暂停定时任务: %s", id)
+	go s.saveState(task)
+	return nil
+}
+
+// Resume 恢复暂停的任务，从当前时间起重新计算下次执行时间
+func (s *Scheduler) Resume(id string) error {
+	if !s.IsLeader() {
+		return ErrNotLeader
+	}
+	task, err := s.task(id)
+	if err != nil {
+		return err
+	}
+
+	task.mu.Lock()
+	if !task.Paused {
+		task.mu.Unlock()
+		return nil
+	}
+	task.Paused = false
+	task.NextRun = s.calculateNextRun(task, time.Now())
+	nextRun := task.NextRun
+	task.mu.Unlock()
+
+	log.Printf("[手动操作] 恢复定时任务: %s, 下次执行时间: %s", id, formatTime(nextRun))
+	go s.saveState(task)
+	return nil
+}
+
+// UpdateSchedule 修改任务的执行频率，以上次执行时间为基准重新计算下次执行时间。
+// 修改只在内存中生效，配置文件变化触发热加载时以配置文件为准。
+func (s *Scheduler) UpdateSchedule(id, spec string) error {
+	if !s.IsLeader() {
+		return ErrNotLeader
+	}
+	task, err := s.task(id)
+	if err != nil {
+		return err
+	}
+	plan, err := schedule.Parse(spec)
+	if err != nil {
+		return err
+	}
+
+	task.mu.Lock()
+	if schedule.Constrain(plan, task.constraints).Next(time.Now()).IsZero() {
+		task.mu.Unlock()
+		return fmt.Errorf("执行频率 %q 在允许执行的时间段内永远不会触发", spec)
+	}
+	task.mu.Unlock()
+
+	s.reschedule(task, spec, "[手动操作]")
+	return nil
+}
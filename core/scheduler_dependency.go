@@ -0,0 +1,161 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+// 依赖任务的状态
+const (
+	DependencyIdle           = "idle"            // 等待上游任务开始新一轮
+	DependencyWaiting        = "waiting"         // 部分上游任务已成功完成
+	DependencyReady          = "ready"           // 上游任务均已成功完成，等待执行
+	DependencyTimeout        = "timeout"         // 等待其余上游任务超时，本轮放弃
+	DependencyUpstreamFailed = "upstream_failed" // 上游任务失败，本轮放弃
+)
+
+// dependencies 依赖任务本轮的等待状态
+type dependencies struct {
+	state     string          // 依赖状态
+	succeeded map[string]bool // 本轮已成功完成的上游任务
+	since     time.Time       // 本轮第一个上游任务完成的时间
+	reason    string          // 放弃本轮的原因
+}
+
+// reset 开始新一轮等待
+func (d *dependencies) reset(state, reason string) {
+	d.state = state
+	d.succeeded = make(map[string]bool)
+	d.since = time.Time{}
+	d.reason = reason
+}
+
+// onRunFinished 上游任务的采集批次结束后更新依赖它的任务，上游任务全部成功时触发下游任务。
+// 上游任务在同一轮内重复成功只计一次；任一上游任务失败则放弃本轮，等待上游任务重新完成。
+func (s *Scheduler) onRunFinished(run mongodb.CrawlRun) {
+	s.mu.RLock()
+	downstream := make([]*ScheduledTask, 0)
+	for _, task := range s.tasks {
+		task.mu.Lock()
+		if slices.Contains(task.Policy.DependsOn, run.Job) {
+			downstream = append(downstream, task)
+		}
+		task.mu.Unlock()
+	}
+	s.mu.RUnlock()
+
+	for _, task := range downstream {
+		task.mu.Lock()
+		deps := &task.deps
+		if run.Status != mongodb.CrawlRunSucceeded {
+			deps.reset(DependencyUpstreamFailed, fmt.Sprintf("上游任务 %s 批次 %s 状态为 %s", run.Job, run.ID, run.Status))
+			log.Printf("上游任务未成功完成，%s 放弃本轮: %s", task.ID, deps.reason)
+			task.mu.Unlock()
+			continue
+		}
+
+		if deps.state != DependencyWaiting {
+			deps.reset(DependencyWaiting, "")
+			deps.since = time.Now()
+		}
+		deps.succeeded[run.Job] = true
+		if len(deps.succeeded) < len(task.Policy.DependsOn) {
+			log.Printf("依赖任务 %s 等待上游任务: 已完成 %d/%d", task.ID, len(deps.succeeded), len(task.Policy.DependsOn))
+			task.mu.Unlock()
+			continue
+		}
+
+		deps.state = DependencyReady
+		task.triggered = true
+		log.Printf("上游任务均已成功完成，触发依赖任务: %s", task.ID)
+		task.mu.Unlock()
+	}
+}
+
+// checkDependencyTimeout 等待其余上游任务超过超时时间时放弃本轮，调用方需持有 task.mu
+func (s *Scheduler) checkDependencyTimeout(task *ScheduledTask, now time.Time) {
+	timeout := task.Policy.DependencyTimeout
+	deps := &task.deps
+	if timeout <= 0 || deps.state != DependencyWaiting || now.Sub(deps.since) < timeout {
+		return
+	}
+
+	missing := make([]string, 0, len(task.Policy.DependsOn))
+	for _, id := range task.Policy.DependsOn {
+		if !deps.succeeded[id] {
+			missing = append(missing, id)
+		}
+	}
+	deps.reset(DependencyTimeout, fmt.Sprintf("等待上游任务 %v 超过 %v", missing, timeout))
+	log.Printf("依赖任务 %s 放弃本轮: %s", task.ID, deps.reason)
+}
+
+// dependencyStatus 依赖任务的状态，调用方需持有 task.mu
+func (t *ScheduledTask) dependencyStatus() map[string]interface{} {
+	upstream := make(map[string]bool, len(t.Policy.DependsOn))
+	for _, id := range t.Policy.DependsOn {
+		upstream[id] = t.deps.succeeded[id]
+	}
+	return map[string]interface{}{
+		"state":    t.deps.state,
+		"upstream": upstream,
+		"since":    t.deps.since,
+		"reason":   t.deps.reason,
+	}
+}
+
+// checkDependencyGraph 校验任务依赖：上游任务必须存在，不能依赖自身，不能形成环。
+// deps 的键和值均为配置文件中的任务名称
+func checkDependencyGraph(deps map[string][]string, known map[string]configTask) []error {
+	var errs []error
+	for key, upstream := range deps {
+		for _, name := range upstream {
+			if name == key {
+				errs = append(errs, fmt.Errorf("%s: 不能依赖自身", key))
+			} else if _, ok := known[name]; !ok {
+				errs = append(errs, fmt.Errorf("%s: 依赖的任务不存在: %s", key, name))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// 深度优先搜索检测环
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := make(map[string]int, len(deps))
+	var visit func(key string, path []string) error
+	visit = func(key string, path []string) error {
+		switch marks[key] {
+		case visiting:
+			return fmt.Errorf("任务依赖形成环: %v", append(path, key))
+		case visited:
+			return nil
+		}
+		marks[key] = visiting
+		for _, name := range deps[key] {
+			if err := visit(name, append(path, key)); err != nil {
+				return err
+			}
+		}
+		marks[key] = visited
+		return nil
+	}
+	keys := make([]string, 0, len(deps))
+	for key := range deps {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if err := visit(key, nil); err != nil {
+			return []error{err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckDependencyGraph(t *testing.T) {
+	known := map[string]configTask{"a": {}, "b": {}, "c": {}}
+	tests := []struct {
+		name    string
+		deps    map[string][]string
+		wantErr string // 错误信息中应包含的内容，为空表示没有错误
+	}{
+		{name: "链式依赖", deps: map[string][]string{"b": {"a"}, "c": {"a", "b"}}},
+		{name: "依赖自身", deps: map[string][]string{"a": {"a"}}, wantErr: "不能依赖自身"},
+		{name: "依赖不存在的任务", deps: map[string][]string{"a": {"x"}}, wantErr: "依赖的任务不存在: x"},
+		{name: "两个任务互相依赖", deps: map[string][]string{"a": {"b"}, "b": {"a"}}, wantErr: "形成环"},
+		{name: "三个任务形成环", deps: map[string][]string{"a": {"c"}, "b": {"a"}, "c": {"b"}}, wantErr: "形成环"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := checkDependencyGraph(tt.deps, known)
+			if tt.wantErr == "" {
+				if len(errs) > 0 {
+					t.Errorf("不应返回错误: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Errorf("返回 %v，期望包含 %q 的错误", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+// dependentTask 创建依赖 upstream 的任务及调度器
+func dependentTask(timeout time.Duration, upstream ...string) (*Scheduler, *ScheduledTask) {
+	task := &ScheduledTask{
+		ID:      "downstream",
+		Enabled: true,
+		Policy:  TaskPolicy{DependsOn: upstream, DependencyTimeout: timeout},
+	}
+	return &Scheduler{tasks: map[string]*ScheduledTask{task.ID: task}}, task
+}
+
+func finishedRun(job, status string) mongodb.CrawlRun {
+	return mongodb.CrawlRun{ID: job + "-run", Job: job, Status: status}
+}
+
+func TestOnRunFinished(t *testing.T) {
+	tests := []struct {
+		name          string
+		runs          []mongodb.CrawlRun
+		wantState     string
+		wantTriggered bool
+	}{
+		{
+			name:      "部分上游任务完成",
+			runs:      []mongodb.CrawlRun{finishedRun("a", mongodb.CrawlRunSucceeded)},
+			wantState: DependencyWaiting,
+		},
+		{
+			name: "同一上游任务重复完成只计一次",
+			runs: []mongodb.CrawlRun{
+				finishedRun("a", mongodb.CrawlRunSucceeded),
+				finishedRun("a", mongodb.CrawlRunSucceeded),
+			},
+			wantState: DependencyWaiting,
+		},
+		{
+			name: "上游任务全部完成",
+			runs: []mongodb.CrawlRun{
+				finishedRun("a", mongodb.CrawlRunSucceeded),
+				finishedRun("b", mongodb.CrawlRunSucceeded),
+			},
+			wantState:     DependencyReady,
+			wantTriggered: true,
+		},
+		{
+			name: "上游任务失败时放弃本轮",
+			runs: []mongodb.CrawlRun{
+				finishedRun("a", mongodb.CrawlRunSucceeded),
+				finishedRun("b", mongodb.CrawlRunFailed),
+			},
+			wantState: DependencyUpstreamFailed,
+		},
+		{
+			name: "放弃后重新开始一轮",
+			runs: []mongodb.CrawlRun{
+				finishedRun("a", mongodb.CrawlRunSucceeded),
+				finishedRun("b", mongodb.CrawlRunStalled),
+				finishedRun("b", mongodb.CrawlRunSucceeded),
+			},
+			wantState: DependencyWaiting,
+		},
+		{
+			name:      "不相关的任务",
+			runs:      []mongodb.CrawlRun{finishedRun("c", mongodb.CrawlRunFailed)},
+			wantState: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, task := dependentTask(0, "a", "b")
+			for _, run := range tt.runs {
+				s.onRunFinished(run)
+			}
+			if task.deps.state != tt.wantState || task.triggered != tt.wantTriggered {
+				t.Errorf("依赖状态为 %q，triggered=%v，期望 %q，triggered=%v",
+					task.deps.state, task.triggered, tt.wantState, tt.wantTriggered)
+			}
+		})
+	}
+}
+
+func TestCheckDependencyTimeout(t *testing.T) {
+	s, task := dependentTask(time.Hour, "a", "b")
+	s.onRunFinished(finishedRun("a", mongodb.CrawlRunSucceeded))
+	since := task.deps.since
+
+	s.checkDependencyTimeout(task, since.Add(30*time.Minute))
+	if task.deps.state != DependencyWaiting {
+		t.Fatalf("未超时时依赖状态为 %s，期望 waiting", task.deps.state)
+	}
+	s.checkDependencyTimeout(task, since.Add(time.Hour))
+	if task.deps.state != DependencyTimeout || len(task.deps.succeeded) != 0 {
+		t.Fatalf("超时后依赖状态为 %s，已完成 %v，期望 timeout 且清空", task.deps.state, task.deps.succeeded)
+	}
+	if !strings.Contains(task.deps.reason, "[b]") {
+		t.Errorf("超时原因应列出未完成的上游任务: %s", task.deps.reason)
+	}
+
+	// 超时后上游任务再次完成时重新开始一轮，此前完成的上游任务不再计入
+	s.onRunFinished(finishedRun("b", mongodb.CrawlRunSucceeded))
+	if task.deps.state != DependencyWaiting || task.triggered {
+		t.Errorf("新一轮依赖状态为 %s，triggered=%v，期望 waiting 且未触发", task.deps.state, task.triggered)
+	}
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"collyDemo/pkg/schedule"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// stateTimeout 读写任务状态的超时时间
+const stateTimeout = 5 * time.Second
+
+// restoreStates 从数据库恢复任务的上次和下次执行时间，并按策略处理停机期间错过的执行，
+// 恢复后立即保存所有任务状态，保证频繁重启不会推迟下次执行时间
+func (s *Scheduler) restoreStates() {
+	if s.states == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stateTimeout)
+	states, err := s.states.List(ctx)
+	cancel()
+	if err != nil {
+		log.Printf("读取定时任务状态失败，按当前时间重新计算: %v", err)
+		return
+	}
+
+	byID := make(map[string]*mongodb.SchedulerState, len(states))
+	for _, state := range states {
+		byID[state.ID] = state
+	}
+
+	s.mu.RLock()
+	tasks := make([]*ScheduledTask, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		tasks = append(tasks, task)
+	}
+	s.mu.RUnlock()
+
+	now := time.Now()
+	for _, task := range tasks {
+		if state, ok := byID[task.ID]; ok {
+			s.restoreTask(task, state, now)
+		}
+		s.saveState(task)
+	}
+}
+
+// restoreTask 恢复单个任务，执行频率变化时以上次执行时间为基准重新计算下次执行时间
+func (s *Scheduler) restoreTask(task *ScheduledTask, state *mongodb.SchedulerState, now time.Time) {
+	task.mu.Lock()
+	defer task.mu.Unlock()
+
+	task.LastRun = state.LastRun
+	task.Paused = state.Paused
+	if task.Paused {
+		log.Printf("恢复定时任务: %s, 已暂停", task.ID)
+	}
+	if len(task.Policy.DependsOn) > 0 {
+		// 依赖任务由上游任务触发，不补跑
+		task.NextRun = time.Time{}
+		return
+	}
+	nextRun := state.NextRun
+	if state.Schedule != task.Schedule || nextRun.IsZero() || !task.constraints.Allowed(nextRun) {
+		from := state.LastRun
+		if from.IsZero() {
+			from = now
+		}
+		nextRun = s.calculateNextRun(task, from)
+	}
+
+	if !nextRun.Before(now) {
+		task.NextRun = nextRun
+		log.Printf("恢复定时任务: %s, 上次执行: %s, 下次执行: %s",
+			task.ID, formatTime(task.LastRun), formatTime(task.NextRun))
+		return
+	}
+
+	switch task.Policy.misfire() {
+	case schedule.MisfireSkip:
+		task.NextRun = s.calculateNextRun(task, now)
+		log.Printf("定时任务错过执行时间 %s，按 skip 策略跳过: %s, 下次执行: %s",
+			formatTime(nextRun), task.ID, formatTime(task.NextRun))
+	case schedule.MisfireRunAll:
+		missed := 0
+		plan := task.constrainedPlan()
+		for t := nextRun; !t.IsZero() && !t.After(now) && missed < maxMisfireRuns; t = plan.Next(t) {
+			missed++
+		}
+		task.pending = missed
+		task.NextRun = s.calculateNextRun(task, now)
+		log.Printf("定时任务错过执行时间 %s，按 run_all 策略补跑 %d 次: %s", formatTime(nextRun), missed, task.ID)
+	default:
+		// 当前不在允许执行的时间段内时推迟到下一个允许执行的时间
+		task.NextRun = task.constraints.NextAllowed(now)
+		log.Printf("定时任务错过执行时间 %s，按 run_once 策略补跑: %s, 补跑时间: %s", formatTime(nextRun), task.ID, formatTime(task.NextRun))
+	}
+}
+
+// saveState 保存任务状态，失败只记录日志，备用节点不保存
+func (s *Scheduler) saveState(task *ScheduledTask) {
+	if s.states == nil || !s.IsLeader() {
+		return
+	}
+	var token int64
+	if s.leader != nil {
+		token = s.leader.Token()
+	}
+
+	task.mu.Lock()
+	state := &mongodb.SchedulerState{
+		ID:       task.ID,
+		Schedule: task.Schedule,
+		LastRun:  task.LastRun,
+		NextRun:  task.NextRun,
+		Paused:   task.Paused,
+		Token:    token,
+	}
+	task.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), stateTimeout)
+	defer cancel()
+	if err := s.states.Save(ctx, state); errors.Is(err, mongodb.ErrStaleToken) {
+		log.Printf("定时任务状态已由新的主节点保存，忽略本次保存: %s", task.ID)
+	} else if err != nil {
+		log.Printf("保存定时任务状态失败: %s, 错误: %v", task.ID, err)
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"collyDemo/pkg/schedule"
+	"context"
+	"testing"
+	"time"
+)
+
+// TestSchedulerCatchUp run_all 的补跑在上一次执行结束后才开始，开始或排队时才减少待补跑次数
+func TestSchedulerCatchUp(t *testing.T) {
+	tests := []struct {
+		overlap     string
+		wantPending int  // 上一次执行未结束时检查后的待补跑次数
+		wantQueued  bool // 上一次执行未结束时是否排队
+	}{
+		{schedule.OverlapForbid, 2, false},
+		{schedule.OverlapReplace, 2, false},
+		{schedule.OverlapQueue, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.overlap, func(t *testing.T) {
+			release := make(chan struct{})
+			defer close(release)
+			task := &ScheduledTask{
+				ID:      "job",
+				Name:    "job",
+				Enabled: true,
+				Policy:  TaskPolicy{Overlap: tt.overlap},
+				Handler: func(ctx context.Context) error {
+					<-release
+					return nil
+				},
+				pending: 2,
+				running: true,
+				cancel:  func() {},
+			}
+			s := &Scheduler{tasks: map[string]*ScheduledTask{task.ID: task}}
+
+			s.checkAndExecuteTasks()
+			task.mu.Lock()
+			if task.pending != tt.wantPending || task.queued != tt.wantQueued || task.runGen != 0 {
+				t.Errorf("执行中检查后 pending=%d queued=%v runGen=%d，期望 pending=%d queued=%v runGen=0",
+					task.pending, task.queued, task.runGen, tt.wantPending, tt.wantQueued)
+			}
+			task.running = false
+			task.queued = false
+			task.mu.Unlock()
+
+			s.checkAndExecuteTasks()
+			task.mu.Lock()
+			defer task.mu.Unlock()
+			if !task.running || task.pending != tt.wantPending-1 {
+				t.Errorf("上一次执行结束后应开始补跑，running=%v pending=%d", task.running, task.pending)
+			}
+		})
+	}
+}
+
+// TestSchedulerManualTrigger 手动触发与到期或依赖触发同时发生时只执行一次，并推进下次执行时间、清除依赖触发
+func TestSchedulerManualTrigger(t *testing.T) {
+	plan, err := schedule.Parse("1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		due       bool
+		triggered bool
+	}{
+		{name: "手动触发", due: false},
+		{name: "手动触发且到期", due: true},
+		{name: "手动触发且依赖触发", triggered: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			defer close(release)
+			now := time.Now()
+			task := &ScheduledTask{
+				ID:      "job",
+				Name:    "job",
+				Enabled: true,
+				Policy:  TaskPolicy{Overlap: schedule.OverlapQueue},
+				Handler: func(ctx context.Context) error {
+					<-release
+					return nil
+				},
+				plan:      plan,
+				NextRun:   now.Add(time.Hour),
+				manual:    true,
+				triggered: tt.triggered,
+			}
+			if tt.due {
+				task.NextRun = now.Add(-time.Second)
+			}
+			s := &Scheduler{tasks: map[string]*ScheduledTask{task.ID: task}}
+
+			s.checkAndExecuteTasks()
+			s.checkAndExecuteTasks()
+			task.mu.Lock()
+			defer task.mu.Unlock()
+			if task.runGen != 1 || task.queued {
+				t.Errorf("应只执行一次，runGen=%d queued=%v", task.runGen, task.queued)
+			}
+			if task.manual || task.triggered {
+				t.Errorf("执行后应清除触发标记，manual=%v triggered=%v", task.manual, task.triggered)
+			}
+			if !task.NextRun.After(now) {
+				t.Errorf("下次执行时间应在当前时间之后: %s", task.NextRun)
+			}
+		})
+	}
+}
+
+func TestRunTrackerWait(t *testing.T) {
+	tracker, tasks, _ := trackRun(t, 1)
+	run := tracker.watch(tasks[0].Meta[MetaRunID].(string))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := run.wait(ctx); err == nil {
+		t.Fatal("批次未结束时 wait 应等待到 ctx 取消")
+	}
+
+	tracker.done(tasks[0], nil, nil)
+	record, err := run.wait(context.Background())
+	if err != nil || record.Status != mongodb.CrawlRunSucceeded {
+		t.Errorf("批次结束后 wait 返回 %s, %v，期望 succeeded", record.Status, err)
+	}
+}
@@ -1,387 +1,214 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/gocolly/colly/v2"
 )
 
-// TaskConfig 任务配置结构
-type TaskConfig struct {
-	URL     string
-	Method  string
-	Body    []byte
-	Handler func(*colly.Response, *Account, *TaskDispatcher) error
-	Meta    map[string]interface{}
+// TaskScheduler 任务调度器，根据接口目录创建请求任务
+type TaskScheduler struct {
+	dispatcher *TaskDispatcher
+	catalog    *Catalog
+	handlers   map[string]func(context.Context, *colly.Response, *Account, *TaskDispatcher) error
 }
 
-// GetDefaultHeaders 获取默认请求头
-func GetDefaultHeaders(token string) map[string]string {
-	return map[string]string{
-		"accept":             "*/*",
-		"accept-language":    "zh-HK,zh-CN;q=0.9,zh;q=0.8,zh-TW;q=0.7",
-		"authorization":      token,
-		"origin":             "https://www.kaogujia.com",
-		"priority":           "u=1, i",
-		"referer":            "https://www.kaogujia.com/",
-		"sec-ch-ua":          `"Google Chrome";v="137", "Chromium";v="137", "Not/A)Brand";v="24"`,
-		"sec-ch-ua-mobile":   "?0",
-		"sec-ch-ua-platform": `"Windows"`,
-		"sec-fetch-dest":     "empty",
-		"sec-fetch-mode":     "cors",
-		"sec-fetch-site":     "same-site",
-		"user-agent":         "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/137.0.0.0 Safari/537.36",
-		"version_code":       "3.1",
-		"content-type":       "application/json",
+// NewTaskScheduler 创建任务调度器，任务分发器通过它按名称查找处理器恢复持久化的任务，
+// 并使用接口目录中的路径规则限制请求，需在 dispatcher.Run 之前调用
+func NewTaskScheduler(dispatcher *TaskDispatcher, catalog *Catalog) *TaskScheduler {
+	s := &TaskScheduler{
+		dispatcher: dispatcher,
+		catalog:    catalog,
+		handlers:   make(map[string]func(context.Context, *colly.Response, *Account, *TaskDispatcher) error),
 	}
+	dispatcher.handlers = s.handler
+	dispatcher.limits = newEndpointLimits(catalog.Limits, dispatcher.queue.notify)
+	return s
 }
 
-// GetMainTasks 获取主要任务配置
-func GetMainTasks() []TaskConfig {
-	return []TaskConfig{
-		// 达人任务
-		{
-			URL:     "https://service.kaogujia.com/api/author/search?limit=50&page=1&sort_field=gmv&sort=0",
-			Method:  "POST",
-			Body:    []byte(`{"sort_field":"gmv","sort":0,"limit":50,"page":1}`),
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 品牌任务
-		{
-			URL:     "https://service.kaogujia.com/api/brand/search?limit=50&page=1&sort_field=gmv&sort=0",
-			Method:  "POST",
-			Body:    []byte(`{"period":1,"keyword":""}`),
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 直播任务
-		{
-			URL:     "https://service.kaogujia.com/api/live/search?limit=50&page=1&sort_field=gmv&sort=0",
-			Method:  "POST",
-			Body:    []byte(`{"pub_time":{"min":"20250629","max":"20250705"},"keyword":"","keyword_type":1}`),
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 商品任务
-		{
-			URL:     "https://service.kaogujia.com/api/sku/search?limit=50&page=1&sort_field=sales&sort=0",
-			Method:  "POST",
-			Body:    []byte(`{"period":1,"keyword":""}`),
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 店铺任务
-		{
-			URL:     "https://service.kaogujia.com/api/shop/search?limit=50&page=1&sort_field=gmv&sort=0",
-			Method:  "POST",
-			Body:    []byte(`{"period":1,"keyword":""}`),
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 视频任务
-		{
-			URL:     "https://service.kaogujia.com/api/video/search?limit=50&page=1&sort_field=like_count&sort=0",
-			Method:  "POST",
-			Body:    []byte(`{"date_code":{"min":"20250629","max":"20250705"},"keyword":"","video_type":1}`),
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-	}
+// Catalog 获取接口目录
+func (s *TaskScheduler) Catalog() *Catalog {
+	return s.catalog
 }
 
-// GetRankTasks 获取排名任务配置
-func GetRankTasks() []TaskConfig {
-	return []TaskConfig{
-		// 达人涨粉榜
-		{
-			URL:     "https://service.kaogujia.com/api/rank/author/fans/increase?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 达人掉粉榜
-		{
-			URL:     "https://service.kaogujia.com/api/rank/author/fans/decrease?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 达人带货潜力榜
-		{
-			URL:     "https://service.kaogujia.com/api/rank/author/potential?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 商品热销榜
-		{
-			URL:     "https://service.kaogujia.com/api/rank/product/hot/sale?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 商品实时销量榜
-		{
-			URL:     "https://service.kaogujia.com/api/rank/product/real/time/sales?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 直播达人带货榜
-		{
-			URL:     "https://service.kaogujia.com/api/rank/live/author/sales?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 直播热推榜
-		{
-			URL:     "https://service.kaogujia.com/api/rank/live/hot/push?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 热门视频榜
-		{
-			URL:     "https://service.kaogujia.com/api/rank/video/hot?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 电商视频榜
-		{
-			URL:     "https://service.kaogujia.com/api/rank/video/ecommerce?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 视频热推
-		{
-			URL:     "https://service.kaogujia.com/api/rank/video/hot/push?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 热销小店
-		{
-			URL:     "https://service.kaogujia.com/api/rank/shop/hot/sale?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 全站小时榜
-		{
-			URL:     "https://service.kaogujia.com/api/rank/site/hourly?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 带货小时榜
-		{
-			URL:     "https://service.kaogujia.com/api/rank/sales/hourly?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 实时热点
-		{
-			URL:     "https://service.kaogujia.com/api/hot/spot/real/time?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 飙升热点
-		{
-			URL:     "https://service.kaogujia.com/api/hot/spot/soaring?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-		// 探测爆款
-		{
-			URL:     "https://service.kaogujia.com/api/explore/hot/burst?limit=50&page=1",
-			Method:  "GET",
-			Handler: nil, // 将在运行时设置
-			Meta: map[string]interface{}{
-				"page":     1,
-				"pageSize": 50,
-			},
-		},
-	}
+// RegisterHandler 注册处理器
+func (s *TaskScheduler) RegisterHandler(name string, handler func(context.Context, *colly.Response, *Account, *TaskDispatcher) error) {
+	s.handlers[name] = handler
 }
 
-// CreatePaginationTask 创建分页任务
-func CreatePaginationTask(baseURL string, method string, body []byte, handler func(*colly.Response, *Account, *TaskDispatcher) error, page, limit int64, headers map[string]string) *Task {
-	url := fmt.Sprintf("%s&page=%d&limit=%d", baseURL, page, limit)
-	return &Task{
-		URL:     url,
-		Method:  method,
-		Headers: headers,
-		Body:    body,
-		Handler: handler,
-		Meta: map[string]interface{}{
-			"page":  page,
-			"limit": limit,
-		},
+// CheckHandlers 检查接口目录引用的处理器是否都已注册
+func (s *TaskScheduler) CheckHandlers() error {
+	var errs []error
+	for _, name := range s.catalog.HandlerNames() {
+		if s.handlers[name] == nil {
+			errs = append(errs, fmt.Errorf("处理器未注册: %s", name))
+		}
 	}
+	return errors.Join(errs...)
 }
 
-// CreateDetailTask 创建详情任务
-func CreateDetailTask(baseURL, id string, handler func(*colly.Response, *Account, *TaskDispatcher) error, headers map[string]string, meta map[string]interface{}) *Task {
-	url := fmt.Sprintf("%s/%s", baseURL, id)
-	return &Task{
-		URL:     url,
-		Method:  "GET",
-		Headers: headers,
-		Handler: handler,
-		Meta:    meta,
+func (s *TaskScheduler) handler(name string) (func(context.Context, *colly.Response, *Account, *TaskDispatcher) error, error) {
+	handler := s.handlers[name]
+	if handler == nil {
+		return nil, fmt.Errorf("处理器未注册: %s", name)
 	}
+	return handler, nil
 }
 
-// TaskScheduler 任务调度器
-type TaskScheduler struct {
-	dispatcher *TaskDispatcher
-	headers    map[string]string
-	handlers   map[string]func(*colly.Response, *Account, *TaskDispatcher) error
+func (s *TaskScheduler) endpoint(name string) (*Endpoint, error) {
+	ep, ok := s.catalog.Endpoint(name)
+	if !ok {
+		return nil, fmt.Errorf("接口不存在: %s", name)
+	}
+	return ep, nil
 }
 
-// NewTaskScheduler 创建任务调度器
-func NewTaskScheduler(dispatcher *TaskDispatcher, token string) *TaskScheduler {
-	return &TaskScheduler{
-		dispatcher: dispatcher,
-		headers:    GetDefaultHeaders(token),
-		handlers:   make(map[string]func(*colly.Response, *Account, *TaskDispatcher) error),
+// NewListTask 创建接口的列表任务，meta 中的内容会传递给后续分页和详情任务；
+// 接口配置了日期窗口且 meta 中没有已解析的窗口时，按当前时间解析
+func (s *TaskScheduler) NewListTask(name string, page, limit int64, meta map[string]interface{}) (*Task, error) {
+	ep, err := s.endpoint(name)
+	if err != nil {
+		return nil, err
+	}
+	handler, err := s.handler(ep.Handler)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = ep.PageSize
+	}
+
+	taskMeta := make(map[string]interface{}, len(meta)+2)
+	for k, v := range meta {
+		taskMeta[k] = v
+	}
+	taskMeta["page"] = page
+	taskMeta["limit"] = limit
+
+	vars := map[string]interface{}{"page": page, "limit": limit}
+	if ep.Window != "" {
+		// 分页任务从 meta 继承已解析的窗口，保证同一轮采集使用相同的日期范围
+		if _, resolved := taskMeta[MetaWindowMin]; !resolved {
+			window, err := ResolveWindow(ep.Window, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			taskMeta[MetaWindow] = ep.Window
+			taskMeta[MetaWindowMin] = window.Start.Format(ep.WindowFormat)
+			taskMeta[MetaWindowMax] = window.End.Format(ep.WindowFormat)
+		}
+		vars["date_min"] = taskMeta[MetaWindowMin]
+		vars["date_max"] = taskMeta[MetaWindowMax]
+	}
+	var body []byte
+	if ep.Body != "" {
+		body = []byte(renderTemplate(ep.Body, vars))
 	}
+
+	return &Task{
+		URL:         renderTemplate(ep.URL, vars),
+		Method:      ep.Method,
+		Headers:     s.catalog.headers(ep.Headers),
+		Body:        body,
+		Handler:     handler,
+		HandlerName: ep.Handler,
+		Endpoint:    ep.Name,
+		Priority:    metaPriority(taskMeta, ep.Priority),
+		DedupTTL:    ep.dedupTTL,
+		Retry:       ep.retry,
+		Meta:        taskMeta,
+	}, nil
 }
 
-// RegisterHandler 注册处理器
-func (s *TaskScheduler) RegisterHandler(name string, handler func(*colly.Response, *Account, *TaskDispatcher) error) {
-	s.handlers[name] = handler
+// NextPageTask 根据当前列表任务创建指定页的任务
+func (s *TaskScheduler) NextPageTask(parent *Task, page, limit int64) (*Task, error) {
+	if parent == nil {
+		return nil, errors.New("缺少当前任务，无法创建分页任务")
+	}
+	ep, err := s.endpoint(parent.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if ep.Pagination != PaginationPage {
+		return nil, fmt.Errorf("接口 %s 不支持分页", ep.Name)
+	}
+	return s.NewListTask(ep.Name, page, limit, inheritMeta(parent))
 }
 
-// AddMainTasks 添加主要任务
-func (s *TaskScheduler) AddMainTasks() {
-	tasks := GetMainTasks()
-	handlerNames := []string{"author", "brand", "live", "product", "store", "video"}
+// NewDetailTask 根据当前列表任务创建详情任务
+func (s *TaskScheduler) NewDetailTask(parent *Task, id string, meta map[string]interface{}) (*Task, error) {
+	if parent == nil {
+		return nil, errors.New("缺少当前任务，无法创建详情任务")
+	}
+	ep, err := s.endpoint(parent.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if ep.Detail == nil {
+		return nil, fmt.Errorf("接口 %s 未配置详情接口", ep.Name)
+	}
+	handler, err := s.handler(ep.Detail.Handler)
+	if err != nil {
+		return nil, err
+	}
 
-	for i, config := range tasks {
-		if i < len(handlerNames) && s.handlers[handlerNames[i]] != nil {
-			task := &Task{
-				URL:     config.URL,
-				Method:  config.Method,
-				Headers: s.headers,
-				Body:    config.Body,
-				Handler: s.handlers[handlerNames[i]],
-				Meta:    config.Meta,
-			}
-			s.dispatcher.AddTask(task)
-		}
+	taskMeta := inheritMeta(parent)
+	for k, v := range meta {
+		taskMeta[k] = v
 	}
+
+	return &Task{
+		URL:         renderTemplate(ep.Detail.URL, map[string]interface{}{"id": id}),
+		Method:      ep.Detail.Method,
+		Headers:     s.catalog.headers(ep.Headers, ep.Detail.Headers),
+		Handler:     handler,
+		HandlerName: ep.Detail.Handler,
+		Endpoint:    ep.Name,
+		Priority:    metaPriority(taskMeta, ep.Detail.Priority),
+		DedupTTL:    ep.Detail.dedupTTL,
+		Retry:       ep.Detail.retry,
+		Meta:        taskMeta,
+	}, nil
 }
 
-// AddRankTasks 添加排名任务
-func (s *TaskScheduler) AddRankTasks() {
-	tasks := GetRankTasks()
-	handlerNames := []string{
-		"author_fans_increase_rank", "author_fans_decrease_rank", "author_potential_rank",
-		"product_hot_sale_rank", "product_real_time_sales_rank", "live_author_sales_rank",
-		"live_hot_push_rank", "hot_video_rank", "ecommerce_video_rank", "video_hot_push",
-		"hot_sale_shop", "site_hourly_rank", "sales_hourly_rank", "real_time_hot_spot",
-		"soaring_hot_spot", "explore_hot_burst",
+// metaPriority meta 中设置了优先级时使用 meta 中的值，否则使用接口目录中的配置
+func metaPriority(meta map[string]interface{}, priority string) string {
+	if p, ok := meta[MetaPriority].(string); ok && p != "" {
+		return p
 	}
+	return priority
+}
 
-	for i, config := range tasks {
-		if i < len(handlerNames) && s.handlers[handlerNames[i]] != nil {
-			task := &Task{
-				URL:     config.URL,
-				Method:  config.Method,
-				Headers: s.headers,
-				Body:    config.Body,
-				Handler: s.handlers[handlerNames[i]],
-				Meta:    config.Meta,
-			}
-			s.dispatcher.AddTask(task)
+// inheritMeta 复制父任务的 meta，去掉分页信息
+func inheritMeta(parent *Task) map[string]interface{} {
+	meta := make(map[string]interface{}, len(parent.Meta))
+	for k, v := range parent.Meta {
+		if k == "page" || k == "limit" {
+			continue
 		}
+		meta[k] = v
 	}
+	return meta
 }
 
-// SchedulePeriodicTasks 调度周期性任务
-func (s *TaskScheduler) SchedulePeriodicTasks() {
-	// 每小时执行一次排名任务
-	go func() {
-		ticker := time.NewTicker(1 * time.Hour)
-		defer ticker.Stop()
+// 任务未能加入任务队列的原因
+var (
+	ErrTaskNotAdded  = errors.New("任务未加入任务队列，调度器已停止")
+	ErrTaskDuplicate = errors.New("任务未加入任务队列，去重时间内已有相同的任务")
+)
 
-		for {
-			select {
-			case <-ticker.C:
-				s.AddRankTasks()
-			}
-		}
-	}()
+// AddEndpointTask 将接口第一页加入任务队列，meta 中的 run_id 等字段会传递给后续的分页和详情任务；
+// 任务未入队时返回 ErrTaskDuplicate 或 ErrTaskNotAdded，调用方需结束对应的采集批次
+func (s *TaskScheduler) AddEndpointTask(name string, meta map[string]interface{}) error {
+	task, err := s.NewListTask(name, 1, 0, meta)
+	if err != nil {
+		return err
+	}
+	if err := s.dispatcher.add(task); err != nil {
+		return fmt.Errorf("%w: %s", err, task.URL)
+	}
+	return nil
 }
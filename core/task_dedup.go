@@ -0,0 +1,92 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// DefaultDetailDedupTTL 详情任务默认去重时间，同一详情在该时间内只采集一次
+const DefaultDetailDedupTTL = time.Hour
+
+// Fingerprint 任务指纹，由请求方法、URL和请求体计算，相同指纹的任务请求完全相同
+func Fingerprint(task *Task) string {
+	h := sha256.New()
+	h.Write([]byte(task.Method))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(task.URL))
+	h.Write([]byte{'\n'})
+	h.Write(task.Body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// taskDedup 按任务指纹去重，记录每个指纹的过期时间和各接口被丢弃的重复任务数
+type taskDedup struct {
+	seen    map[string]time.Time // 指纹 -> 过期时间
+	dropped map[string]int64     // 接口 -> 丢弃的重复任务数
+	total   int64
+	mu      sync.Mutex
+}
+
+func newTaskDedup() *taskDedup {
+	return &taskDedup{
+		seen:    make(map[string]time.Time),
+		dropped: make(map[string]int64),
+	}
+}
+
+// check 任务在去重时间内已入队过时返回 true 并计入统计，否则记录指纹
+func (d *taskDedup) check(task *Task, now time.Time) bool {
+	if task.DedupTTL <= 0 {
+		return false
+	}
+	fp := Fingerprint(task)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if expires, ok := d.seen[fp]; ok && now.Before(expires) {
+		d.dropped[task.Endpoint]++
+		d.total++
+		return true
+	}
+	d.seen[fp] = now.Add(task.DedupTTL)
+	return false
+}
+
+// forget 删除任务指纹，最终失败的任务可以在去重时间内重新入队
+func (d *taskDedup) forget(task *Task) {
+	if task.DedupTTL <= 0 {
+		return
+	}
+	fp := Fingerprint(task)
+	d.mu.Lock()
+	delete(d.seen, fp)
+	d.mu.Unlock()
+}
+
+// purge 清理过期的指纹
+func (d *taskDedup) purge(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for fp, expires := range d.seen {
+		if !now.Before(expires) {
+			delete(d.seen, fp)
+		}
+	}
+}
+
+// stats 去重统计
+func (d *taskDedup) stats() map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	endpoints := make(map[string]int64, len(d.dropped))
+	for name, n := range d.dropped {
+		endpoints[name] = n
+	}
+	return map[string]interface{}{
+		"fingerprints": len(d.seen),
+		"dropped":      d.total,
+		"endpoints":    endpoints,
+	}
+}
@@ -1,84 +1,289 @@
 package core
 
 import (
+	"collyDemo/mongodb"
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
+
+	"github.com/gocolly/colly/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// DispatcherConfig 任务分发器配置
+type DispatcherConfig struct {
+	MaxRetries      int           // 单个任务最大尝试次数，可被接口目录中的 retry 覆盖
+	RetryDelay      time.Duration // 第一次重试前的等待时间，之后每次翻倍
+	RetryMaxDelay   time.Duration // 单次重试等待时间上限
+	RetryJitter     float64       // 重试等待时间随机减少的比例
+	RetryMaxElapsed time.Duration // 任务从第一次尝试开始超过该时间后不再重试，0 表示不限制
+	RequestTimeout  time.Duration // 单次HTTP请求超时时间
+	TaskTimeout     time.Duration // 等待响应及处理器完成的超时时间
+
+	PriorityWeights map[string]int // 各优先级权重，未配置的使用 DefaultPriorityWeights
+	MaxQueueWait    time.Duration  // 任务排队超过该时间时不论优先级立即执行，0 表示不限制
+
+	InstanceID        string        // 实例标识，持久化队列中标记任务由哪个实例持有
+	VisibilityTimeout time.Duration // 持久化任务的租约有效期，实例停止续约超过该时间后任务由其他实例接管
+
+	MaxConcurrency int                 // 运行时调整并发数的上限
+	Adaptive       AdaptiveConcurrency // 自适应并发配置
+
+	RunStallTimeout time.Duration // 采集批次超过该时间没有进度时不再等待剩余任务，0 表示不限制
+}
+
+// DefaultDispatcherConfig 获取默认分发器配置
+func DefaultDispatcherConfig() DispatcherConfig {
+	return DispatcherConfig{
+		MaxRetries:      3,
+		RetryDelay:      time.Second,
+		RetryMaxDelay:   time.Minute,
+		RetryJitter:     0.2,
+		RetryMaxElapsed: 10 * time.Minute,
+		RequestTimeout:  30 * time.Second,
+		TaskTimeout:     60 * time.Second,
+
+		PriorityWeights: DefaultPriorityWeights(),
+		MaxQueueWait:    10 * time.Minute,
+
+		VisibilityTimeout: 2 * time.Minute,
+
+		MaxConcurrency: 10,
+		Adaptive: AdaptiveConcurrency{
+			MinConcurrency: 1,
+			Interval:       30 * time.Second,
+			TargetLatency:  20 * time.Second,
+			MaxFailureRate: 0.2,
+			DecreaseFactor: 0.5,
+		},
+
+		RunStallTimeout: 30 * time.Minute,
+	}
+}
+
+// RetryPolicy 默认重试策略
+func (c DispatcherConfig) RetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:  c.MaxRetries,
+		InitialDelay: c.RetryDelay,
+		MaxDelay:     c.RetryMaxDelay,
+		Jitter:       c.RetryJitter,
+		MaxElapsed:   c.RetryMaxElapsed,
+	}
+}
+
+// Validate 校验重试策略、优先级权重和排队时间
+func (c DispatcherConfig) Validate() error {
+	var errs []error
+	if err := c.RetryPolicy().Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	for name, weight := range c.PriorityWeights {
+		if err := ValidatePriority(name); err != nil || name == "" {
+			errs = append(errs, fmt.Errorf("未知的优先级: %q", name))
+		} else if weight <= 0 {
+			errs = append(errs, fmt.Errorf("优先级 %s 的权重必须大于0，当前为 %d", name, weight))
+		}
+	}
+	if c.MaxQueueWait < 0 {
+		errs = append(errs, fmt.Errorf("最长排队时间不能为负数，当前为 %s", c.MaxQueueWait))
+	}
+	if c.VisibilityTimeout < 3*time.Second {
+		errs = append(errs, fmt.Errorf("任务租约有效期不能小于3秒，当前为 %s", c.VisibilityTimeout))
+	}
+	if c.MaxConcurrency < 1 {
+		errs = append(errs, fmt.Errorf("最大并发数必须大于等于1，当前为 %d", c.MaxConcurrency))
+	}
+	if c.Adaptive.MinConcurrency < 1 || c.Adaptive.MinConcurrency > c.MaxConcurrency {
+		errs = append(errs, fmt.Errorf("自适应并发下限必须在1到%d之间，当前为 %d", c.MaxConcurrency, c.Adaptive.MinConcurrency))
+	}
+	if c.Adaptive.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("自适应并发调整周期必须大于0，当前为 %s", c.Adaptive.Interval))
+	}
+	if c.Adaptive.MaxFailureRate < 0 || c.Adaptive.MaxFailureRate > 1 {
+		errs = append(errs, fmt.Errorf("自适应并发失败率阈值必须在0到1之间，当前为 %v", c.Adaptive.MaxFailureRate))
+	}
+	if c.Adaptive.DecreaseFactor <= 0 || c.Adaptive.DecreaseFactor >= 1 {
+		errs = append(errs, fmt.Errorf("自适应并发减少乘数必须大于0且小于1，当前为 %v", c.Adaptive.DecreaseFactor))
+	}
+	if c.RunStallTimeout < 0 {
+		errs = append(errs, fmt.Errorf("采集批次无进度超时时间不能为负数，当前为 %s", c.RunStallTimeout))
+	}
+	return errors.Join(errs...)
+}
+
+// runningTask 执行中的任务
+type runningTask struct {
+	URL   string
+	Start time.Time
+}
+
+// stopGracePeriod 关闭超时取消执行中的任务后，等待工作协程退出的时间
+const stopGracePeriod = 5 * time.Second
+
+// taskQueueCapacity 任务队列容量
+const taskQueueCapacity = 1000000
+
 type TaskDispatcher struct {
 	accountPool *AccountPool
-	taskChan    chan *Task
+	config      DispatcherConfig
+	queue       *taskQueue
 	wg          sync.WaitGroup
-	mu          sync.Mutex
-	stop        chan struct{}
+	stop        chan struct{} // 关闭后不再接收新任务
+	stopOnce    sync.Once
+
+	ctx    context.Context // 执行中的请求和处理器的上下文，关闭超时后取消
+	cancel context.CancelFunc
 
 	// 新增字段
 	activeTasks  int
 	activeMu     sync.Mutex
-	currentTasks sync.Map
+	currentTasks sync.Map // 任务ID -> *runningTask
+	held         sync.Map // 内存队列中及执行中的任务ID，接管持久化队列中的任务时跳过
+
+	runs  *RunTracker            // 采集批次统计
+	store *taskStore             // 持久化队列，为 nil 时任务只保存在内存中
+	dedup *taskDedup             // 按请求指纹去重
+	dead  *mongodb.DeadLetterDAO // 最终失败的任务，为 nil 时只记录日志
+
+	limiter       *concurrencyLimiter // 并发上限，工作协程执行任务前获取名额
+	concurrencyMu sync.Mutex
+	workers       int               // 已启动的工作协程数，只增不减，多出的协程等待名额
+	running       bool              // Run 已调用，调整并发数时可以启动新的工作协程
+	adaptive      bool              // 是否开启自适应并发
+	window        concurrencyWindow // 当前调整周期内的请求统计
+
+	handlers func(name string) (func(context.Context, *colly.Response, *Account, *TaskDispatcher) error, error)
+	limits   endpointLimits // 按请求路径的并发和频率限制，由 NewTaskScheduler 根据接口目录设置
 }
 
-func NewTaskDispatcher(pool *AccountPool) *TaskDispatcher {
-	return &TaskDispatcher{
+// NewTaskDispatcher 创建任务分发器，runs 用于保存采集批次记录，为 nil 时不保存；
+// queue 用于持久化任务队列，为 nil 时任务只保存在内存中，停止或崩溃后丢失；
+// dead 用于保存最终失败的任务，为 nil 时只记录日志
+func NewTaskDispatcher(pool *AccountPool, config DispatcherConfig, runs *mongodb.CrawlRunDAO, queue *mongodb.TaskQueueDAO, dead *mongodb.DeadLetterDAO) *TaskDispatcher {
+	d := &TaskDispatcher{
 		accountPool: pool,
-		taskChan:    make(chan *Task, 1000000),
+		config:      config,
+		queue:       newTaskQueue(taskQueueCapacity, config.PriorityWeights, config.MaxQueueWait),
 		stop:        make(chan struct{}),
+		runs:        NewRunTracker(runs),
+		dedup:       newTaskDedup(),
+		dead:        dead,
+		limiter:     newConcurrencyLimiter(1),
+		adaptive:    config.Adaptive.Enabled,
 	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	d.limiter.notify = d.queue.notify
+	if queue != nil {
+		d.store = newTaskStore(queue, config.InstanceID, config.VisibilityTimeout)
+	}
+	return d
+}
+
+// Runs 获取采集批次统计
+func (d *TaskDispatcher) Runs() *RunTracker {
+	return d.runs
+}
+
+// RecordStored 记录响应对应的任务入库的数据条数，计入所属采集批次
+func (d *TaskDispatcher) RecordStored(r *colly.Response, n int) {
+	d.runs.stored(TaskFromResponse(r), n)
 }
 
 var addTaskLimiter = time.NewTicker(10 * time.Millisecond)
 
-func (d *TaskDispatcher) AddTask(task *Task) {
+// AddTask 将任务加入任务队列，返回任务是否已入队并计入所属采集批次；
+// 重复任务和调度器停止后的任务返回 false，停止期间保存到持久化队列的任务同样返回 false，下次启动时执行
+func (d *TaskDispatcher) AddTask(task *Task) bool {
+	return d.add(task) == nil
+}
+
+// add 将任务加入任务队列，重复任务返回 ErrTaskDuplicate，调度器停止后的任务返回 ErrTaskNotAdded
+func (d *TaskDispatcher) add(task *Task) error {
+	if task.ID == "" {
+		task.ID = primitive.NewObjectID().Hex()
+	}
+
+	// 停止期间同样去重，避免重复任务保存到持久化队列
+	if d.dedup.check(task, time.Now()) {
+		log.Printf("丢弃重复任务: %s", task.URL)
+		return ErrTaskDuplicate
+	}
+
 	select {
 	case <-d.stop:
+		if d.store != nil && task.HandlerName != "" {
+			// 停止期间处理器产生的任务保存到持久化队列，下次启动时恢复
+			d.store.save(task)
+			log.Printf("调度器已停止，任务保存到持久化队列: %s", task.URL)
+			return ErrTaskNotAdded
+		}
 		log.Printf("调度器已停止，拒绝新任务: %s", task.URL)
-		return
+		return ErrTaskNotAdded
 	default:
 	}
 
+	d.store.save(task)
+	d.runs.added(task)
 	<-addTaskLimiter.C
 
-	// 非阻塞式添加任务，避免死锁
-	select {
-	case d.taskChan <- task:
-		// 成功添加任务
-	default:
-		// 队列满时的处理
-		log.Printf("任务队列已满，等待空间: %s", task.URL)
-		for len(d.taskChan) > 900 { // 留一些缓冲
-			time.Sleep(100 * time.Millisecond)
-		}
-		d.taskChan <- task
+	if !d.enqueue(task) {
+		// 已计入批次的任务在停止时随批次标记为中断
+		log.Printf("调度器已停止，丢弃任务: %s", task.URL)
+		return ErrTaskNotAdded
 	}
+	return nil
 }
 
-func (d *TaskDispatcher) TaskStatus() (queueLen, active int) {
-	d.mu.Lock()
-	queueLen = len(d.taskChan)
-	d.mu.Unlock()
+// enqueue 将任务加入内存队列并记录任务ID，执行结束后删除
+func (d *TaskDispatcher) enqueue(task *Task) bool {
+	d.held.Store(task.ID, struct{}{})
+	if !d.queue.push(task) {
+		d.held.Delete(task.ID)
+		return false
+	}
+	return true
+}
+
+// TaskStatus 获取排队任务总数、执行中任务数及各优先级排队的任务数
+func (d *TaskDispatcher) TaskStatus() (queueLen, active int, queues map[string]int) {
+	queues, queueLen = d.queue.lengths()
 
 	d.activeMu.Lock()
 	active = d.activeTasks
 	d.activeMu.Unlock()
 
-	return queueLen, active
+	return queueLen, active, queues
+}
+
+// DedupStats 获取去重统计: 记录中的指纹数、丢弃的重复任务总数及各接口丢弃数
+func (d *TaskDispatcher) DedupStats() map[string]interface{} {
+	return d.dedup.stats()
 }
 
+// Run 按初始并发数启动工作池，运行中可通过 SetConcurrency 或自适应并发调整
 func (d *TaskDispatcher) Run(concurrency int) {
-	log.Printf("启动任务调度器，并发数: %d", concurrency)
+	log.Printf("启动任务调度器，并发数: %d, 上限: %d, 自适应: %v", concurrency, d.config.MaxConcurrency, d.adaptive)
 
 	// 启动监控协程
 	go d.monitorTaskQueue()
 
+	// 续约并恢复持久化队列中的任务
+	go d.runStore()
+
+	// 自适应调整并发数
+	go d.runAdaptive()
+
 	// 启动工作池
-	for i := 0; i < concurrency; i++ {
-		d.wg.Add(1)
-		go d.worker(i)
-	}
+	d.concurrencyMu.Lock()
+	d.running = true
+	d.concurrencyMu.Unlock()
+	d.applyConcurrency(concurrency, "启动")
 
 	d.wg.Wait()
-	close(d.stop)
 }
 
 func (d *TaskDispatcher) worker(id int) {
@@ -91,62 +296,184 @@ func (d *TaskDispatcher) worker(id int) {
 		log.Printf("Worker %d 退出", id)
 	}()
 
+	gate := workerGate{limiter: d.limiter, limits: d.limits}
 	for {
-		select {
-		case <-d.stop:
+		// 只取出并发名额和接口限制都允许立即执行的任务，并发数减少时多出的工作协程在这里等待，
+		// 达到接口限制的任务留在队列中，不占用并发名额
+		task, ok := d.queue.pop(gate)
+		if !ok {
 			return
-		case task, ok := <-d.taskChan:
-			if !ok {
-				return
-			}
+		}
+		func() {
+			defer d.limiter.release()
+			d.handle(id, task)
+		}()
+	}
+}
 
-			if task == nil {
-				continue
-			}
+// workerGate 工作协程取任务时依次占用并发名额和任务第一次请求的接口名额
+type workerGate struct {
+	limiter *concurrencyLimiter
+	limits  endpointLimits
+}
 
-			log.Printf("Worker %d 接收到任务: %s", id, task.URL)
+func (g workerGate) enter() bool {
+	return g.limiter.tryAcquire()
+}
 
-			// 增加活跃任务计数
-			d.activeMu.Lock()
-			d.currentTasks.Store(task.URL, time.Now())
-			d.activeTasks++
-			d.activeMu.Unlock()
+func (g workerGate) leave() {
+	g.limiter.cancel()
+}
 
-			// 获取账号
-			acc := d.accountPool.GetAccount()
-			log.Printf("Worker %d 获取账号: %s, 执行任务: %s", id, acc.UserName, task.URL)
-
-			// 带重试的执行
-			retry := 0
-			maxRetries := 3
-			var lastErr error
-
-			for retry < maxRetries {
-				if err := ExecuteRequest(task, acc, d); err != nil {
-					lastErr = err
-					log.Printf("Worker %d 请求失败 (尝试 %d/%d): %v", id, retry+1, maxRetries, err)
-					retry++
-					if retry < maxRetries {
-						time.Sleep(time.Duration(retry) * time.Second)
-					}
-				} else {
-					lastErr = nil
-					break
-				}
-			}
+func (g workerGate) admit(task *Task) (bool, time.Duration) {
+	if limiter := g.limits.match(task.URL); limiter != nil {
+		return limiter.tryAcquire(time.Now())
+	}
+	return true, 0
+}
 
-			if lastErr != nil {
-				log.Printf("Worker %d 任务最终失败: %s, 错误: %v", id, task.URL, lastErr)
+// handle 执行一个任务并记录结果
+func (d *TaskDispatcher) handle(id int, task *Task) {
+	defer d.held.Delete(task.ID)
+	// 取出任务时已占用第一次请求的接口名额
+	limiter := d.limits.match(task.URL)
+	if !d.store.start(task) {
+		if limiter != nil {
+			limiter.release()
+		}
+		// 任务已完成或已被其他实例接管，本实例的批次统计不再等待该任务
+		log.Printf("Worker %d 任务已由其他实例接管或已完成，跳过: %s", id, task.URL)
+		d.runs.handedOff(task)
+		return
+	}
+	log.Printf("Worker %d 接收到任务: %s, 优先级: %s", id, task.URL, task.Priority)
+
+	// 增加活跃任务计数
+	d.activeMu.Lock()
+	d.currentTasks.Store(task.ID, &runningTask{URL: task.URL, Start: time.Now()})
+	d.activeTasks++
+	d.activeMu.Unlock()
+
+	// 按重试策略执行，每次尝试重新获取账号
+	accounts, attempts, lastErr := d.execute(id, task, limiter)
+
+	d.runs.done(task, accounts, lastErr)
+	switch {
+	case lastErr != nil && d.ctx.Err() != nil:
+		// 关闭超时被取消的任务保留在持久化队列中，下次启动时重新执行
+		log.Printf("Worker %d 任务被取消: %s", id, task.URL)
+	case lastErr != nil:
+		log.Printf("Worker %d 任务最终失败: %s, 错误: %v", id, task.URL, lastErr)
+		d.dedup.forget(task)
+		d.deadLetter(task, accounts, attempts, lastErr)
+		d.store.complete(task)
+	default:
+		d.store.complete(task)
+	}
+
+	// 减少活跃任务计数
+	d.activeMu.Lock()
+	d.currentTasks.Delete(task.ID)
+	d.activeTasks--
+	d.activeMu.Unlock()
+
+	log.Printf("Worker %d 完成任务: %s", id, task.URL)
+}
+
+// execute 按任务的重试策略执行任务，每次尝试重新获取账号，账号相关的错误换用未尝试过的账号；
+// 不可重试的错误立即返回，可重试的错误按退避时间等待后重试。limiter 为任务匹配的接口限制，第一次请求的名额已在取出任务时占用。
+// 返回尝试过的账号、每次失败尝试的记录及最后一次错误
+func (d *TaskDispatcher) execute(id int, task *Task, limiter *endpointLimiter) ([]*Account, []mongodb.DeadLetterAttempt, error) {
+	policy := d.config.RetryPolicy().merge(task.Retry)
+	start := time.Now()
+	var accounts []*Account
+	var attempts []mongodb.DeadLetterAttempt
+	failed := make(map[string]bool) // 因账号相关错误失败的账号
+
+	for attempt := 1; ; attempt++ {
+		// 重试前先等待接口的并发和频率限制，再获取账号，等待期间不占用账号
+		if attempt > 1 && limiter != nil && !limiter.acquire(d.ctx) {
+			return accounts, attempts, d.ctx.Err()
+		}
+		acc, err := d.accountPool.GetAccountExcluding(d.ctx, failed)
+		if err != nil {
+			if limiter != nil {
+				limiter.release()
 			}
+			return accounts, attempts, err
+		}
+		accounts = appendAccount(accounts, acc)
+		log.Printf("Worker %d 获取账号: %s, 执行任务: %s", id, acc, task.URL)
 
-			// 减少活跃任务计数
-			d.activeMu.Lock()
-			d.currentTasks.Delete(task.URL)
-			d.activeTasks--
-			d.activeMu.Unlock()
+		attemptStart := time.Now()
+		err = ExecuteRequest(d.ctx, task, acc, d)
+		if limiter != nil {
+			limiter.release()
+		}
+		d.observe(time.Since(attemptStart), err)
+		if err == nil {
+			return accounts, attempts, nil
+		}
+		class := ErrorClass(err)
+		statusCode, _ := errorResponse(err)
+		attempts = append(attempts, mongodb.DeadLetterAttempt{
+			Account:    acc.String(),
+			ErrorClass: class,
+			Error:      err.Error(),
+			StatusCode: statusCode,
+			Time:       attemptStart,
+			Duration:   time.Since(attemptStart),
+		})
+		log.Printf("Worker %d 请求失败 (尝试 %d/%d, 类型 %s, 账号 %s): %v", id, attempt, policy.MaxAttempts, class, acc, err)
+
+		if AccountSpecific(err) {
+			failed[acc.ID] = true
+		}
+		switch {
+		case !Retryable(err):
+			log.Printf("Worker %d 错误不可重试: %s", id, task.URL)
+			return accounts, attempts, err
+		case attempt >= policy.MaxAttempts:
+			return accounts, attempts, err
+		case class == ErrorAuth && !d.accountPool.HasAccountOutside(failed):
+			log.Printf("Worker %d 所有账号都无权限，不再重试: %s", id, task.URL)
+			return accounts, attempts, err
+		}
 
-			log.Printf("Worker %d 完成任务: %s", id, task.URL)
+		// 认证失败换账号后立即重试，其他错误退避后重试
+		var delay time.Duration
+		if class != ErrorAuth {
+			delay = policy.backoff(attempt, err)
 		}
+		if policy.MaxElapsed > 0 && time.Since(start)+delay > policy.MaxElapsed {
+			log.Printf("Worker %d 重试总时间将超过 %v，不再重试: %s", id, policy.MaxElapsed, task.URL)
+			return accounts, attempts, err
+		}
+		if !sleepContext(d.ctx, delay) {
+			return accounts, attempts, err
+		}
+	}
+}
+
+// appendAccount 记录尝试过的账号，同一账号只记录一次
+func appendAccount(accounts []*Account, acc *Account) []*Account {
+	for _, a := range accounts {
+		if a == acc {
+			return accounts
+		}
+	}
+	return append(accounts, acc)
+}
+
+// sleepContext 等待 delay，ctx 取消时提前返回 false
+func sleepContext(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
 	}
 }
 
@@ -161,15 +488,19 @@ func (d *TaskDispatcher) monitorTaskQueue() {
 		case <-d.stop:
 			return
 		case <-ticker.C:
-			queueLen, active := d.TaskStatus()
-			log.Printf("任务监控: 队列=%d, 执行中=%d, 连续空闲=%ds", queueLen, active, zeroCount)
+			queueLen, active, queues := d.TaskStatus()
+			log.Printf("任务监控: 队列=%d %v, 执行中=%d, 并发=%d, 连续空闲=%ds", queueLen, queues, active, d.limiter.get(), zeroCount)
+			d.dedup.purge(time.Now())
+			if d.config.RunStallTimeout > 0 {
+				d.runs.expire(d.config.RunStallTimeout)
+			}
 
 			// 检查长时间执行的任务
 			d.activeMu.Lock()
 			d.currentTasks.Range(func(key, value interface{}) bool {
-				startTime := value.(time.Time)
-				if time.Since(startTime) > 2*time.Minute {
-					log.Printf("警告: 任务执行时间过长: %s, 已执行: %v", key, time.Since(startTime))
+				running := value.(*runningTask)
+				if time.Since(running.Start) > 2*time.Minute {
+					log.Printf("警告: 任务执行时间过长: %s, 已执行: %v", running.URL, time.Since(running.Start))
 				}
 				return true
 			})
@@ -189,7 +520,41 @@ func (d *TaskDispatcher) monitorTaskQueue() {
 	}
 }
 
-func (d *TaskDispatcher) Stop() {
-	close(d.stop)
-	close(d.taskChan)
+// Stop 停止接收新任务并等待执行中的任务结束：
+// 开启持久化队列时排队中的任务保留在 task_queue 中由下次启动恢复，否则继续执行完排队中的任务；
+// ctx 到期后取消执行中的请求和处理器，不再执行剩余任务。未完成的采集批次标记为中断
+func (d *TaskDispatcher) Stop(ctx context.Context) {
+	d.stopOnce.Do(func() { close(d.stop) })
+	if d.store != nil {
+		d.queue.close()
+	} else {
+		d.queue.drain()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("执行中的任务已全部结束")
+	case <-ctx.Done():
+		queueLen, active, _ := d.TaskStatus()
+		log.Printf("等待任务结束超时，取消执行中的任务: 执行中=%d, 丢弃排队中的任务=%d", active, queueLen)
+		d.queue.close()
+		d.cancel()
+		select {
+		case <-done:
+		case <-time.After(stopGracePeriod):
+			log.Printf("仍有任务在 %v 内未响应取消，不再等待", stopGracePeriod)
+		}
+	}
+	d.cancel()
+
+	d.runs.Interrupt()
+	if d.store != nil {
+		d.store.release()
+	}
 }
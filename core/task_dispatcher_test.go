@@ -0,0 +1,83 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+func TestAddTaskAccepted(t *testing.T) {
+	d := NewTaskDispatcher(nil, DefaultDispatcherConfig(), nil, nil, nil)
+	runID := d.runs.Start("job", "endpoint")
+	newTask := func() *Task {
+		return &Task{URL: "https://example.com/list", DedupTTL: time.Hour, Meta: map[string]interface{}{MetaRunID: runID}}
+	}
+
+	if !d.AddTask(newTask()) {
+		t.Fatal("新任务应加入任务队列")
+	}
+	if d.AddTask(newTask()) {
+		t.Error("去重时间内的重复任务不应加入任务队列")
+	}
+	if status := d.runs.Status(); len(status) != 1 || status[0]["pending"] != 1 {
+		t.Errorf("批次中应只有 1 个未完成的任务，当前为 %v", status)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	d.Stop(ctx)
+	if err := d.add(newTask()); !errors.Is(err, ErrTaskDuplicate) {
+		t.Errorf("停止后的重复任务返回 %v，期望 %v", err, ErrTaskDuplicate)
+	}
+	if err := d.add(&Task{URL: "https://example.com/other"}); !errors.Is(err, ErrTaskNotAdded) {
+		t.Errorf("调度器停止后加入任务返回 %v，期望 %v", err, ErrTaskNotAdded)
+	}
+}
+
+func TestRestoreTaskDedupTTL(t *testing.T) {
+	d := &TaskDispatcher{handlers: func(string) (func(context.Context, *colly.Response, *Account, *TaskDispatcher) error, error) {
+		return func(context.Context, *colly.Response, *Account, *TaskDispatcher) error { return nil }, nil
+	}}
+	task := d.restoreTask(&mongodb.QueuedTask{ID: "1", Handler: "author", URL: "https://example.com", DedupTTL: 30 * time.Minute})
+	if task == nil {
+		t.Fatal("任务应恢复")
+	}
+	if task.DedupTTL != 30*time.Minute {
+		t.Errorf("恢复的任务去重时间为 %v，期望 30m", task.DedupTTL)
+	}
+}
+
+func TestTaskStoreOwnerPerProcess(t *testing.T) {
+	a := newTaskStore(nil, "host-1", time.Minute)
+	b := newTaskStore(nil, "host-1", time.Minute)
+	if a.owner == b.owner {
+		t.Error("每次启动的租约持有者应不同")
+	}
+	if !strings.HasPrefix(a.owner, "host-1/") || a.instance != "host-1" {
+		t.Errorf("租约持有者 %s 应以实例标识开头", a.owner)
+	}
+}
+
+func TestHeldTasks(t *testing.T) {
+	d := NewTaskDispatcher(nil, DefaultDispatcherConfig(), nil, nil, nil)
+	task := &Task{ID: "1", URL: "https://example.com"}
+	if !d.AddTask(task) {
+		t.Fatal("新任务应加入任务队列")
+	}
+	if _, held := d.held.Load(task.ID); !held {
+		t.Error("排队中的任务应记录在内存中，接管时跳过")
+	}
+
+	d.queue.close()
+	if d.enqueue(&Task{ID: "2"}) {
+		t.Fatal("队列关闭后不应加入任务")
+	}
+	if _, held := d.held.Load("2"); held {
+		t.Error("未入队的任务不应记录")
+	}
+}
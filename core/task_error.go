@@ -0,0 +1,170 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// 任务错误分类
+const (
+	ErrorTransport   = "transport"    // 网络错误、请求超时、5xx，退避后重试
+	ErrorRateLimited = "rate_limited" // 429 等频率限制，换账号退避后重试
+	ErrorAuth        = "auth"         // 401/403、is_authority=false 等账号无权限，换账号重试，所有账号都已尝试时不再重试
+	ErrorPayload     = "payload"      // 其他 4xx、响应格式错误、解密或解析失败，不重试
+	ErrorStorage     = "storage"      // 数据库错误，重复键不重试，其他退避后重试
+	ErrorCancelled   = "cancelled"    // 程序关闭取消，不重试
+	ErrorUnknown     = "unknown"      // 未分类的错误，退避后重试
+)
+
+// TaskError 带分类的任务错误，处理器可用 NewTaskError 包装错误指定分类
+type TaskError struct {
+	Class      string        // 错误分类
+	StatusCode int           // HTTP 状态码，非 HTTP 错误为 0
+	RetryAfter time.Duration // 服务端要求的重试等待时间
+	Response   []byte        // 响应内容的开头部分，保存到死信中
+	Err        error
+}
+
+func (e *TaskError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s (HTTP %d): %v", e.Class, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Class, e.Err)
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// NewTaskError 将错误包装为指定分类
+func NewTaskError(class string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TaskError{Class: class, Err: err}
+}
+
+// responseError 根据 HTTP 响应状态码分类请求错误
+func responseError(statusCode int, header *http.Header, err error) *TaskError {
+	taskErr := &TaskError{StatusCode: statusCode, Err: err}
+	switch {
+	case statusCode == 0 || statusCode >= 500:
+		taskErr.Class = ErrorTransport
+	case statusCode == http.StatusTooManyRequests:
+		taskErr.Class = ErrorRateLimited
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		taskErr.Class = ErrorAuth
+	default:
+		taskErr.Class = ErrorPayload
+	}
+	if header != nil {
+		if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds > 0 {
+			taskErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+	return taskErr
+}
+
+// responseSnippetSize 错误中保存的响应内容长度
+const responseSnippetSize = 1024
+
+// withResponse 为错误附加响应状态码和响应内容的开头部分，未包装的错误按类型推断分类
+func withResponse(err error, statusCode int, body []byte) error {
+	if err == nil {
+		return nil
+	}
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) {
+		taskErr = &TaskError{Class: ErrorClass(err), Err: err}
+		err = taskErr
+	}
+	if taskErr.StatusCode == 0 {
+		taskErr.StatusCode = statusCode
+	}
+	if taskErr.Response == nil && len(body) > 0 {
+		if len(body) > responseSnippetSize {
+			body = body[:responseSnippetSize]
+		}
+		taskErr.Response = append([]byte(nil), body...)
+	}
+	return err
+}
+
+// ErrorClass 获取错误分类，未包装的错误按类型推断
+func ErrorClass(err error) string {
+	var taskErr *TaskError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var serverErr mongo.ServerError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &taskErr):
+		return taskErr.Class
+	case errors.Is(err, context.Canceled):
+		return ErrorCancelled
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return ErrorPayload
+	case errors.As(err, &serverErr), mongo.IsNetworkError(err), errors.Is(err, mongo.ErrClientDisconnected):
+		return ErrorStorage
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return ErrorTransport
+	default:
+		return ErrorUnknown
+	}
+}
+
+// Retryable 错误是否可以重试
+func Retryable(err error) bool {
+	switch ErrorClass(err) {
+	case ErrorPayload, ErrorCancelled:
+		return false
+	case ErrorStorage:
+		return !mongo.IsDuplicateKeyError(err)
+	default:
+		return err != nil
+	}
+}
+
+// errorResponse 错误对应的响应状态码和响应内容的开头部分
+func errorResponse(err error) (int, []byte) {
+	var taskErr *TaskError
+	if errors.As(err, &taskErr) {
+		return taskErr.StatusCode, taskErr.Response
+	}
+	return 0, nil
+}
+
+// AccountSpecific 错误是否与所用账号有关，重试时换用其他账号
+func AccountSpecific(err error) bool {
+	switch ErrorClass(err) {
+	case ErrorAuth, ErrorRateLimited:
+		return true
+	default:
+		return false
+	}
+}
+
+// isTimeout 错误是否为请求或任务超时
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// retryAfter 服务端要求的重试等待时间
+func retryAfter(err error) time.Duration {
+	var taskErr *TaskError
+	if errors.As(err, &taskErr) {
+		return taskErr.RetryAfter
+	}
+	return 0
+}
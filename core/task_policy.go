@@ -0,0 +1,73 @@
+package core
+
+import (
+	"collyDemo/pkg/schedule"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// maxMisfireRuns run_all 策略最多补跑的次数，避免长时间停机后一次性涌入大量任务
+const maxMisfireRuns = 50
+
+// TaskPolicy 定时任务的调度策略
+type TaskPolicy struct {
+	Misfire   string        // 错过执行时间的处理策略: run_once（默认）、run_all、skip
+	Overlap   string        // 上一次执行未结束时再次到期的处理策略: forbid（默认）、queue、replace
+	Windows   []string      // 每天允许执行的时间段，如 "08:00-24:00"，为空表示全天
+	Blackouts []string      // 不执行的日期，如 "2026-02-17" 或 "2026-02-15~2026-02-21"
+	Jitter    time.Duration // 每次执行随机推迟 [0, Jitter) 的时间，避免大量任务同时开始
+
+	DependsOn         []string      // 上游任务ID，设置后不再按执行频率触发，上游任务本轮均成功完成后触发
+	DependencyTimeout time.Duration // 第一个上游任务完成后等待其余上游任务的最长时间，0 表示不限制
+}
+
+// Validate 校验调度策略
+func (p TaskPolicy) Validate() error {
+	errs := []error{schedule.ValidateMisfire(p.Misfire), schedule.ValidateOverlap(p.Overlap)}
+	if _, err := p.constraints(); err != nil {
+		errs = append(errs, err)
+	}
+	if p.Jitter < 0 {
+		errs = append(errs, fmt.Errorf("jitter 不能为负数，当前为 %s", p.Jitter))
+	}
+	if p.DependencyTimeout < 0 {
+		errs = append(errs, fmt.Errorf("dependency_timeout 不能为负数，当前为 %s", p.DependencyTimeout))
+	}
+	return errors.Join(errs...)
+}
+
+// Equal 判断两个调度策略是否相同
+func (p TaskPolicy) Equal(other TaskPolicy) bool {
+	return p.Misfire == other.Misfire && p.Overlap == other.Overlap && p.Jitter == other.Jitter &&
+		slices.Equal(p.Windows, other.Windows) && slices.Equal(p.Blackouts, other.Blackouts) &&
+		slices.Equal(p.DependsOn, other.DependsOn) && p.DependencyTimeout == other.DependencyTimeout
+}
+
+// constraints 解析允许执行的时间段和停止日期，均未配置时返回 nil
+func (p TaskPolicy) constraints() (*schedule.Constraints, error) {
+	return schedule.ParseConstraints(p.Windows, p.Blackouts)
+}
+
+// misfire 返回错过执行时间的处理策略，未配置时为 run_once
+func (p TaskPolicy) misfire() string {
+	if p.Misfire == "" {
+		return schedule.MisfireRunOnce
+	}
+	return p.Misfire
+}
+
+// overlap 返回重叠执行的处理策略，未配置时为 forbid
+func (p TaskPolicy) overlap() string {
+	if p.Overlap == "" {
+		return schedule.OverlapForbid
+	}
+	return p.Overlap
+}
+
+// TaskSpec 配置文件中单个任务的配置，键为接口名称
+type TaskSpec struct {
+	Schedule string // 执行频率，为空时使用接口目录中的默认值
+	Policy   TaskPolicy
+}
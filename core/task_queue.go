@@ -0,0 +1,313 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 任务优先级，同一优先级内先进先出
+const (
+	PriorityRank     = "rank"     // 排名接口
+	PriorityList     = "list"     // 列表分页
+	PriorityDetail   = "detail"   // 详情
+	PriorityBackfill = "backfill" // 历史数据回填
+)
+
+// MetaPriority 任务 meta 中的优先级，覆盖接口目录中的配置，并传递给后续分页和详情任务
+const MetaPriority = "priority"
+
+// priorities 所有优先级，从高到低排列
+var priorities = []string{PriorityRank, PriorityList, PriorityDetail, PriorityBackfill}
+
+// DefaultPriorityWeights 各优先级默认权重，按权重比例轮流取出任务
+func DefaultPriorityWeights() map[string]int {
+	return map[string]int{
+		PriorityRank:     8,
+		PriorityList:     4,
+		PriorityDetail:   2,
+		PriorityBackfill: 1,
+	}
+}
+
+// ValidatePriority 校验优先级名称，空字符串表示使用默认值
+func ValidatePriority(priority string) error {
+	if priority == "" {
+		return nil
+	}
+	for _, p := range priorities {
+		if p == priority {
+			return nil
+		}
+	}
+	return fmt.Errorf("priority 只支持 %s、%s、%s 或 %s，当前为 %q",
+		PriorityRank, PriorityList, PriorityDetail, PriorityBackfill, priority)
+}
+
+// queuedTask 队列中的任务及入队时间
+type queuedTask struct {
+	task     *Task
+	enqueued time.Time
+}
+
+// priorityClass 单个优先级的任务队列
+type priorityClass struct {
+	name    string
+	weight  int
+	current int // 平滑加权轮询的当前值
+	tasks   []queuedTask
+	head    int
+}
+
+func (c *priorityClass) len() int {
+	return len(c.tasks) - c.head
+}
+
+// remove 取出第 i 个排队的任务，前面的任务保持原有顺序
+func (c *priorityClass) remove(i int) *Task {
+	if i > 0 {
+		idx := c.head + i
+		target := c.tasks[idx]
+		copy(c.tasks[c.head+1:idx+1], c.tasks[c.head:idx])
+		c.tasks[c.head] = target
+	}
+	return c.pop()
+}
+
+func (c *priorityClass) pop() *Task {
+	task := c.tasks[c.head].task
+	c.tasks[c.head] = queuedTask{}
+	c.head++
+	// 已取出的部分超过一半时整理底层数组，避免持续增长
+	if c.head >= 64 && c.head*2 >= len(c.tasks) {
+		c.tasks = append(c.tasks[:0], c.tasks[c.head:]...)
+		c.head = 0
+	}
+	return task
+}
+
+// taskGate 工作协程从任务队列取任务时的准入控制，方法在队列的锁内调用
+type taskGate interface {
+	enter() bool                            // 获取并发名额，没有空闲名额时返回 false
+	leave()                                 // 没有可以立即执行的任务时归还并发名额
+	admit(task *Task) (bool, time.Duration) // 占用任务所需的接口名额，不能执行时返回还需等待的时间，0 表示等待其他请求结束
+}
+
+// maxAdmitScan 每个优先级最多检查的排队任务数，前面的任务都达到接口限制时其后的任务继续排队
+const maxAdmitScan = 100
+
+// taskQueue 按优先级加权轮询的任务队列：
+// 各优先级按权重比例取出任务，高优先级任务不会被大量低优先级任务阻塞；
+// 某个优先级最早的任务等待超过 maxWait 时优先取出，低优先级任务不会一直得不到执行；
+// 达到接口并发或频率限制的任务留在队列中，跳过它们取出后面可以执行的任务
+type taskQueue struct {
+	classes  []*priorityClass
+	byName   map[string]*priorityClass
+	size     int
+	capacity int
+	maxWait  time.Duration
+	closed   bool // 关闭后不再加入和取出任务
+	draining bool // 不再加入任务，取完剩余任务后关闭
+	wake     *time.Timer
+	wakeAt   time.Time // 频率限制到期后唤醒工作协程的时间
+	mu       sync.Mutex
+	cond     *sync.Cond
+}
+
+// newTaskQueue 创建任务队列，weights 中未配置的优先级使用默认权重，maxWait 为 0 表示不做饥饿保护
+func newTaskQueue(capacity int, weights map[string]int, maxWait time.Duration) *taskQueue {
+	q := &taskQueue{
+		byName:   make(map[string]*priorityClass, len(priorities)),
+		capacity: capacity,
+		maxWait:  maxWait,
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	defaults := DefaultPriorityWeights()
+	for _, name := range priorities {
+		weight, ok := weights[name]
+		if !ok || weight <= 0 {
+			weight = defaults[name]
+		}
+		class := &priorityClass{name: name, weight: weight}
+		q.classes = append(q.classes, class)
+		q.byName[name] = class
+	}
+	return q
+}
+
+// push 加入任务，队列已满时等待空间，队列关闭或排空中返回 false
+func (q *taskQueue) push(task *Task) bool {
+	class, ok := q.byName[task.Priority]
+	if !ok {
+		if task.Priority != "" {
+			log.Printf("未知的任务优先级 %q，按 %s 处理: %s", task.Priority, PriorityList, task.URL)
+		}
+		class = q.byName[PriorityList]
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.size >= q.capacity && !q.closed && !q.draining {
+		log.Printf("任务队列已满，等待空间: %s", task.URL)
+		for q.size >= q.capacity && !q.closed && !q.draining {
+			q.cond.Wait()
+		}
+	}
+	if q.closed || q.draining {
+		return false
+	}
+
+	class.tasks = append(class.tasks, queuedTask{task: task, enqueued: time.Now()})
+	q.size++
+	q.cond.Broadcast()
+	return true
+}
+
+// pop 取出下一个可以执行的任务，队列为空或 gate 不允许执行任何排队的任务时等待，队列关闭或排空后返回 false。
+// gate 为 nil 时不做准入控制
+func (q *taskQueue) pop(gate taskGate) (*Task, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		if q.closed || (q.size == 0 && q.draining) {
+			return nil, false
+		}
+		if q.size > 0 {
+			task, wait := q.take(time.Now(), gate)
+			if task != nil {
+				q.size--
+				q.cond.Broadcast()
+				return task, true
+			}
+			if wait > 0 {
+				q.wakeAfter(wait)
+			}
+		}
+		q.cond.Wait()
+	}
+}
+
+// take 按本次取任务的优先级顺序取出第一个 gate 允许执行的任务，都不能执行时返回 nil 及最短的等待时间。
+// 调用方需持有锁且队列不为空
+func (q *taskQueue) take(now time.Time, gate taskGate) (*Task, time.Duration) {
+	if gate == nil {
+		return q.next(now).pop(), 0
+	}
+	if !gate.enter() {
+		return nil, 0
+	}
+	classes, starved := q.candidates(now)
+	var wait time.Duration
+	for n, class := range classes {
+		for i := 0; i < class.len() && i < maxAdmitScan; i++ {
+			ok, w := gate.admit(class.tasks[class.head+i].task)
+			if ok {
+				q.picked(class, n < starved)
+				return class.remove(i), 0
+			}
+			if w > 0 && (wait == 0 || w < wait) {
+				wait = w
+			}
+		}
+	}
+	gate.leave()
+	return nil, wait
+}
+
+// next 选择本次取出任务的优先级，调用方需持有锁且队列不为空
+func (q *taskQueue) next(now time.Time) *priorityClass {
+	classes, starved := q.candidates(now)
+	q.picked(classes[0], starved > 0)
+	return classes[0]
+}
+
+// candidates 按本次取任务的先后顺序排列有任务的优先级，返回列表及排在前面的饥饿优先级数量：
+// 最早的任务等待超过 maxWait 的优先级按等待时间排在前面，其余按平滑加权轮询的顺序。调用方需持有锁
+func (q *taskQueue) candidates(now time.Time) ([]*priorityClass, int) {
+	var starved, weighted []*priorityClass
+	for _, class := range q.classes {
+		if class.len() == 0 {
+			continue
+		}
+		if q.maxWait > 0 && now.Sub(class.tasks[class.head].enqueued) >= q.maxWait {
+			starved = append(starved, class)
+		} else {
+			weighted = append(weighted, class)
+		}
+	}
+	sort.SliceStable(starved, func(i, j int) bool {
+		return starved[i].tasks[starved[i].head].enqueued.Before(starved[j].tasks[starved[j].head].enqueued)
+	})
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return weighted[i].current+weighted[i].weight > weighted[j].current+weighted[j].weight
+	})
+	return append(starved, weighted...), len(starved)
+}
+
+// picked 从 class 取出任务后更新平滑加权轮询的当前值，只在有任务的优先级之间分配；
+// 因饥饿保护取出时不更新。调用方需持有锁
+func (q *taskQueue) picked(class *priorityClass, starved bool) {
+	if starved {
+		return
+	}
+	total := 0
+	for _, c := range q.classes {
+		if c.len() == 0 {
+			continue
+		}
+		c.current += c.weight
+		total += c.weight
+	}
+	class.current -= total
+}
+
+// wakeAfter 在 wait 后唤醒等待的工作协程，已有更早的唤醒时不重复设置。调用方需持有锁
+func (q *taskQueue) wakeAfter(wait time.Duration) {
+	now := time.Now()
+	at := now.Add(wait)
+	if q.wake != nil && q.wakeAt.After(now) && !q.wakeAt.After(at) {
+		return
+	}
+	if q.wake != nil {
+		q.wake.Stop()
+	}
+	q.wakeAt = at
+	q.wake = time.AfterFunc(wait, q.notify)
+}
+
+// notify 唤醒等待的工作协程重新检查能否取出任务，在并发名额或接口名额归还时调用，不能在持有锁时调用
+func (q *taskQueue) notify() {
+	q.mu.Lock()
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// lengths 各优先级排队的任务数及总数
+func (q *taskQueue) lengths() (map[string]int, int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	lengths := make(map[string]int, len(q.classes))
+	for _, class := range q.classes {
+		lengths[class.name] = class.len()
+	}
+	return lengths, q.size
+}
+
+// drain 不再加入任务，工作协程取完剩余任务后退出
+func (q *taskQueue) drain() {
+	q.mu.Lock()
+	q.draining = true
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// close 关闭队列，唤醒所有等待的工作协程，未取出的任务被丢弃
+func (q *taskQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
@@ -0,0 +1,393 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func queueTask(priority string, n int) *Task {
+	return &Task{ID: fmt.Sprintf("%s-%d", priority, n), URL: fmt.Sprintf("https://example.com/%s/%d", priority, n), Priority: priority}
+}
+
+func TestTaskQueueWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights map[string]int
+		counts  map[string]int // 每个优先级入队的任务数
+		pops    int
+		want    map[string]int // 取出 pops 个任务后各优先级取出的数量
+	}{
+		{
+			name:    "默认权重",
+			weights: nil,
+			counts:  map[string]int{PriorityRank: 100, PriorityList: 100, PriorityDetail: 100, PriorityBackfill: 100},
+			pops:    150,
+			want:    map[string]int{PriorityRank: 80, PriorityList: 40, PriorityDetail: 20, PriorityBackfill: 10},
+		},
+		{
+			name:    "只在有任务的优先级之间分配",
+			weights: nil,
+			counts:  map[string]int{PriorityList: 100, PriorityDetail: 100},
+			pops:    60,
+			want:    map[string]int{PriorityList: 40, PriorityDetail: 20},
+		},
+		{
+			name:    "自定义权重",
+			weights: map[string]int{PriorityRank: 1, PriorityList: 1, PriorityDetail: 1, PriorityBackfill: 1},
+			counts:  map[string]int{PriorityRank: 100, PriorityBackfill: 100},
+			pops:    40,
+			want:    map[string]int{PriorityRank: 20, PriorityBackfill: 20},
+		},
+		{
+			name:    "高优先级取完后取低优先级",
+			weights: nil,
+			counts:  map[string]int{PriorityRank: 3, PriorityBackfill: 10},
+			pops:    13,
+			want:    map[string]int{PriorityRank: 3, PriorityBackfill: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTaskQueue(1000, tt.weights, 0)
+			for _, priority := range priorities {
+				for i := 0; i < tt.counts[priority]; i++ {
+					q.push(queueTask(priority, i))
+				}
+			}
+			got := make(map[string]int)
+			for i := 0; i < tt.pops; i++ {
+				task, ok := q.pop(nil)
+				if !ok {
+					t.Fatalf("第 %d 次取出失败", i+1)
+				}
+				got[task.Priority]++
+			}
+			for _, priority := range priorities {
+				if got[priority] != tt.want[priority] {
+					t.Errorf("%s 取出 %d 个，期望 %d 个", priority, got[priority], tt.want[priority])
+				}
+			}
+		})
+	}
+}
+
+func TestTaskQueueFIFOWithinPriority(t *testing.T) {
+	q := newTaskQueue(1000, nil, 0)
+	for i := 0; i < 200; i++ {
+		q.push(queueTask(PriorityDetail, i))
+	}
+	for i := 0; i < 200; i++ {
+		task, _ := q.pop(nil)
+		if want := fmt.Sprintf("%s-%d", PriorityDetail, i); task.ID != want {
+			t.Fatalf("第 %d 个任务为 %s，期望 %s", i+1, task.ID, want)
+		}
+	}
+}
+
+func TestTaskQueueUnknownPriority(t *testing.T) {
+	q := newTaskQueue(10, nil, 0)
+	q.push(queueTask("", 0))
+	q.push(queueTask("urgent", 1))
+	lengths, size := q.lengths()
+	if size != 2 || lengths[PriorityList] != 2 {
+		t.Errorf("未设置或未知优先级的任务应按 list 排队，当前为 %v", lengths)
+	}
+}
+
+// TestTaskQueueStarvation 等待超过 maxWait 的任务不论权重优先取出，多个优先级都超时时取等待最久的
+func TestTaskQueueStarvation(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		maxWait  time.Duration
+		enqueued map[string]time.Duration // 各优先级最早任务已等待的时间
+		want     string
+	}{
+		{
+			name:     "未超时按权重",
+			maxWait:  10 * time.Minute,
+			enqueued: map[string]time.Duration{PriorityRank: time.Minute, PriorityBackfill: 9 * time.Minute},
+			want:     PriorityRank,
+		},
+		{
+			name:     "低优先级超时后优先取出",
+			maxWait:  10 * time.Minute,
+			enqueued: map[string]time.Duration{PriorityRank: time.Minute, PriorityBackfill: 11 * time.Minute},
+			want:     PriorityBackfill,
+		},
+		{
+			name:     "多个超时取等待最久的",
+			maxWait:  10 * time.Minute,
+			enqueued: map[string]time.Duration{PriorityRank: 12 * time.Minute, PriorityDetail: 15 * time.Minute, PriorityBackfill: 11 * time.Minute},
+			want:     PriorityDetail,
+		},
+		{
+			name:     "maxWait 为 0 不做饥饿保护",
+			maxWait:  0,
+			enqueued: map[string]time.Duration{PriorityRank: time.Minute, PriorityBackfill: time.Hour},
+			want:     PriorityRank,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTaskQueue(100, nil, tt.maxWait)
+			for priority, waited := range tt.enqueued {
+				q.push(queueTask(priority, 0))
+				q.byName[priority].tasks[0].enqueued = now.Add(-waited)
+			}
+			q.mu.Lock()
+			got := q.next(now)
+			q.mu.Unlock()
+			if got.name != tt.want {
+				t.Errorf("取出 %s，期望 %s", got.name, tt.want)
+			}
+		})
+	}
+}
+
+// TestTaskQueueStarvedBackfillProgresses 大量高优先级任务持续入队时，超时的回填任务仍会被取出
+func TestTaskQueueStarvedBackfillProgresses(t *testing.T) {
+	q := newTaskQueue(1000, map[string]int{PriorityRank: 100, PriorityBackfill: 1}, time.Minute)
+	q.push(queueTask(PriorityBackfill, 0))
+	q.byName[PriorityBackfill].tasks[0].enqueued = time.Now().Add(-2 * time.Minute)
+	for i := 0; i < 500; i++ {
+		q.push(queueTask(PriorityRank, i))
+	}
+	task, _ := q.pop(nil)
+	if task.Priority != PriorityBackfill {
+		t.Errorf("取出 %s，期望超时的 backfill 任务", task.Priority)
+	}
+}
+
+func TestTaskQueueCloseAndDrain(t *testing.T) {
+	q := newTaskQueue(10, nil, 0)
+	q.push(queueTask(PriorityList, 0))
+	q.push(queueTask(PriorityList, 1))
+	q.drain()
+	if q.push(queueTask(PriorityList, 2)) {
+		t.Error("排空中不应再加入任务")
+	}
+	for i := 0; i < 2; i++ {
+		if _, ok := q.pop(nil); !ok {
+			t.Fatalf("排空中应继续取出剩余任务")
+		}
+	}
+	if _, ok := q.pop(nil); ok {
+		t.Error("剩余任务取完后应返回 false")
+	}
+
+	q = newTaskQueue(10, nil, 0)
+	q.push(queueTask(PriorityList, 0))
+	q.close()
+	if _, ok := q.pop(nil); ok {
+		t.Error("关闭后不应再取出任务")
+	}
+}
+
+func TestTaskQueuePopWaitsForPush(t *testing.T) {
+	q := newTaskQueue(10, nil, 0)
+	popped := make(chan *Task, 1)
+	go func() {
+		task, _ := q.pop(nil)
+		popped <- task
+	}()
+	select {
+	case <-popped:
+		t.Fatal("队列为空时 pop 应等待")
+	case <-time.After(50 * time.Millisecond):
+	}
+	q.push(queueTask(PriorityRank, 0))
+	select {
+	case task := <-popped:
+		if task.Priority != PriorityRank {
+			t.Errorf("取出 %s，期望 rank", task.Priority)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("加入任务后 pop 应返回")
+	}
+}
+
+func TestTaskQueueFullBlocksUntilPop(t *testing.T) {
+	q := newTaskQueue(1, nil, 0)
+	q.push(queueTask(PriorityList, 0))
+	pushed := make(chan bool, 1)
+	go func() { pushed <- q.push(queueTask(PriorityList, 1)) }()
+	select {
+	case <-pushed:
+		t.Fatal("队列已满时 push 应等待")
+	case <-time.After(50 * time.Millisecond):
+	}
+	q.pop(nil)
+	select {
+	case ok := <-pushed:
+		if !ok {
+			t.Error("取出任务后 push 应成功")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("取出任务后 push 应返回")
+	}
+}
+
+// fakeGate 按任务地址判断能否执行的准入控制
+type fakeGate struct {
+	slots   int             // 空闲的并发名额
+	blocked map[string]bool // 达到接口限制的地址
+	wait    time.Duration   // 达到频率限制时返回的等待时间
+}
+
+func (g *fakeGate) enter() bool {
+	if g.slots == 0 {
+		return false
+	}
+	g.slots--
+	return true
+}
+
+func (g *fakeGate) leave() {
+	g.slots++
+}
+
+func (g *fakeGate) admit(task *Task) (bool, time.Duration) {
+	if g.blocked[task.URL] {
+		return false, g.wait
+	}
+	return true, 0
+}
+
+func TestTaskQueueSkipsLimitedEndpoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		pushed  []*Task
+		blocked []int  // 达到接口限制的任务下标
+		want    string // 取出的任务ID，为空表示没有可以执行的任务
+	}{
+		{
+			name:   "队首可以执行",
+			pushed: []*Task{queueTask(PriorityRank, 0), queueTask(PriorityRank, 1)},
+			want:   "rank-0",
+		},
+		{
+			name:    "跳过同一优先级中受限的任务",
+			pushed:  []*Task{queueTask(PriorityRank, 0), queueTask(PriorityRank, 1), queueTask(PriorityRank, 2)},
+			blocked: []int{0, 1},
+			want:    "rank-2",
+		},
+		{
+			name:    "高优先级全部受限时取低优先级",
+			pushed:  []*Task{queueTask(PriorityRank, 0), queueTask(PriorityBackfill, 0)},
+			blocked: []int{0},
+			want:    "backfill-0",
+		},
+		{
+			name:    "全部受限",
+			pushed:  []*Task{queueTask(PriorityRank, 0), queueTask(PriorityList, 0)},
+			blocked: []int{0, 1},
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTaskQueue(100, nil, 0)
+			gate := &fakeGate{slots: 1, blocked: make(map[string]bool)}
+			for _, task := range tt.pushed {
+				q.push(task)
+			}
+			for _, i := range tt.blocked {
+				gate.blocked[tt.pushed[i].URL] = true
+			}
+
+			q.mu.Lock()
+			task, _ := q.take(time.Now(), gate)
+			q.mu.Unlock()
+			got := ""
+			if task != nil {
+				got = task.ID
+			}
+			if got != tt.want {
+				t.Errorf("取出 %q，期望 %q", got, tt.want)
+			}
+			wantSlots := 0
+			if task == nil {
+				wantSlots = 1 // 没有可以执行的任务时归还并发名额
+			}
+			if gate.slots != wantSlots {
+				t.Errorf("剩余并发名额 %d，期望 %d", gate.slots, wantSlots)
+			}
+		})
+	}
+}
+
+func TestTaskQueueSkippedTasksKeepOrder(t *testing.T) {
+	q := newTaskQueue(100, nil, 0)
+	for i := 0; i < 4; i++ {
+		q.push(queueTask(PriorityList, i))
+	}
+	gate := &fakeGate{slots: 10, blocked: map[string]bool{queueTask(PriorityList, 0).URL: true}}
+	for _, want := range []string{"list-1", "list-2", "list-3"} {
+		if task, _ := q.pop(gate); task.ID != want {
+			t.Fatalf("取出 %s，期望 %s", task.ID, want)
+		}
+	}
+	delete(gate.blocked, queueTask(PriorityList, 0).URL)
+	if task, _ := q.pop(gate); task.ID != "list-0" {
+		t.Errorf("限制解除后取出 %s，期望 list-0", task.ID)
+	}
+}
+
+func TestTaskQueuePopWaitsForLimit(t *testing.T) {
+	q := newTaskQueue(10, nil, 0)
+	task := queueTask(PriorityRank, 0)
+	q.push(task)
+
+	var mu sync.Mutex
+	gate := &lockedGate{mu: &mu, gate: &fakeGate{slots: 1, blocked: map[string]bool{task.URL: true}, wait: 50 * time.Millisecond}}
+	popped := make(chan *Task, 1)
+	go func() {
+		task, _ := q.pop(gate)
+		popped <- task
+	}()
+	select {
+	case <-popped:
+		t.Fatal("达到接口限制时 pop 应等待")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// 频率限制到期后由定时器唤醒
+	mu.Lock()
+	delete(gate.gate.blocked, task.URL)
+	mu.Unlock()
+	select {
+	case got := <-popped:
+		if got != task {
+			t.Errorf("取出 %s，期望 %s", got.ID, task.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("频率限制到期后 pop 应返回")
+	}
+}
+
+// lockedGate 测试中在其他协程修改限制时加锁
+type lockedGate struct {
+	mu   *sync.Mutex
+	gate *fakeGate
+}
+
+func (g *lockedGate) enter() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.gate.enter()
+}
+
+func (g *lockedGate) leave() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.gate.leave()
+}
+
+func (g *lockedGate) admit(task *Task) (bool, time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.gate.admit(task)
+}
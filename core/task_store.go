@@ -0,0 +1,218 @@
+package core
+
+import (
+	"collyDemo/mongodb"
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// taskStoreTimeout 读写持久化任务的超时时间
+const taskStoreTimeout = 5 * time.Second
+
+// taskClaimBatch 每次接管的最大任务数
+const taskClaimBatch = 500
+
+// maxTaskAttempts 持久化任务最多开始执行的次数，超过后认为任务会导致进程崩溃，不再恢复
+const maxTaskAttempts = 5
+
+// taskStore 将任务队列持久化到 task_queue 集合：
+// 任务入队时保存，执行结束后删除；实例持有所有入队任务的租约并每隔 visibility/3 续约，
+// 实例崩溃或停止后租约到期，其他实例或重启后的进程接管剩余任务并重新入队
+type taskStore struct {
+	dao        *mongodb.TaskQueueDAO
+	instance   string        // 实例标识
+	owner      string        // 持有租约的进程，由实例标识和本次启动生成的ID组成，重启后不同
+	visibility time.Duration // 租约有效期
+}
+
+// newTaskStore 创建持久化队列，每次启动生成新的租约持有者，启动时只释放之前的进程遗留的任务
+func newTaskStore(dao *mongodb.TaskQueueDAO, instance string, visibility time.Duration) *taskStore {
+	return &taskStore{
+		dao:        dao,
+		instance:   instance,
+		owner:      instance + "/" + primitive.NewObjectID().Hex(),
+		visibility: visibility,
+	}
+}
+
+// leaseUntil 从现在起计算的租约到期时间
+func (s *taskStore) leaseUntil() time.Time {
+	return time.Now().Add(s.visibility)
+}
+
+// save 保存新任务，处理器未按名称注册的任务无法恢复，不保存
+func (s *taskStore) save(task *Task) {
+	if s == nil || task.HandlerName == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), taskStoreTimeout)
+	defer cancel()
+	err := s.dao.Insert(ctx, &mongodb.QueuedTask{
+		ID:         task.ID,
+		Handler:    task.HandlerName,
+		Endpoint:   task.Endpoint,
+		URL:        task.URL,
+		Method:     task.Method,
+		Headers:    task.Headers,
+		Body:       task.Body,
+		Priority:   task.Priority,
+		Meta:       task.Meta,
+		DedupTTL:   task.DedupTTL,
+		Retry:      task.Retry.record(),
+		Instance:   s.instance,
+		Owner:      s.owner,
+		LeaseUntil: s.leaseUntil(),
+	})
+	if err != nil {
+		log.Printf("保存任务到持久化队列失败，任务只保存在内存中: %s, 错误: %v", task.URL, err)
+	}
+}
+
+// start 标记任务开始执行，任务已被其他实例接管时返回 false，数据库异常时仍然执行
+func (s *taskStore) start(task *Task) bool {
+	if s == nil || task.HandlerName == "" {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), taskStoreTimeout)
+	defer cancel()
+	started, err := s.dao.Start(ctx, task.ID, s.owner, s.leaseUntil())
+	if err != nil {
+		return true
+	}
+	if !started {
+		log.Printf("任务已完成或已被其他实例接管，跳过: %s", task.URL)
+	}
+	return started
+}
+
+// complete 删除执行结束的任务
+func (s *taskStore) complete(task *Task) {
+	if s == nil || task.HandlerName == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), taskStoreTimeout)
+	defer cancel()
+	s.dao.Complete(ctx, task.ID, s.owner)
+}
+
+// renew 延长本实例持有的所有任务的租约
+func (s *taskStore) renew() {
+	ctx, cancel := context.WithTimeout(context.Background(), taskStoreTimeout)
+	defer cancel()
+	s.dao.Renew(ctx, s.owner, s.leaseUntil())
+}
+
+// releaseStale 释放同一实例标识下之前的进程遗留的任务，本进程已入队的任务不受影响
+func (s *taskStore) releaseStale() {
+	ctx, cancel := context.WithTimeout(context.Background(), taskStoreTimeout)
+	defer cancel()
+	s.dao.ReleaseInstance(ctx, s.instance, s.owner)
+}
+
+// release 释放本进程持有的所有任务
+func (s *taskStore) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), taskStoreTimeout)
+	defer cancel()
+	if err := s.dao.Release(ctx, s.owner); err == nil {
+		log.Printf("已释放持久化队列中的任务，由其他实例或下次启动时恢复")
+	}
+}
+
+// claim 接管一批租约已过期的任务
+func (s *taskStore) claim() ([]*mongodb.QueuedTask, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), taskStoreTimeout)
+	defer cancel()
+	return s.dao.Claim(ctx, s.instance, s.owner, s.leaseUntil(), taskClaimBatch)
+}
+
+// drop 删除无法恢复的任务
+func (s *taskStore) drop(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), taskStoreTimeout)
+	defer cancel()
+	s.dao.Delete(ctx, id)
+}
+
+// runStore 定期续约并接管租约过期的任务，启动时先释放同一实例标识下之前的进程遗留的任务再接管，
+// 使用固定实例标识重启时无需等待租约过期
+func (d *TaskDispatcher) runStore() {
+	if d.store == nil {
+		return
+	}
+	if d.handlers == nil {
+		log.Printf("未创建任务配置调度器，无法按处理器名称恢复任务，不接管持久化队列中的任务")
+		return
+	}
+	d.store.releaseStale()
+	d.resumeTasks()
+
+	ticker := time.NewTicker(d.store.visibility / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.store.renew()
+			d.resumeTasks()
+		}
+	}
+}
+
+// resumeTasks 接管所有租约过期的任务并加入内存队列。续约失败时本进程的任务也会被重新接管，
+// 已在内存队列中或执行中的任务只接管租约，不重复入队
+func (d *TaskDispatcher) resumeTasks() {
+	resumed := 0
+	for {
+		records, err := d.store.claim()
+		if err != nil || len(records) == 0 {
+			break
+		}
+		for _, record := range records {
+			if _, held := d.held.Load(record.ID); held {
+				continue
+			}
+			if task := d.restoreTask(record); task != nil && d.enqueue(task) {
+				resumed++
+			}
+		}
+		if len(records) < taskClaimBatch {
+			break
+		}
+	}
+	if resumed > 0 {
+		log.Printf("从持久化队列恢复任务: %d 个", resumed)
+	}
+}
+
+// restoreTask 根据处理器名称还原任务，处理器不存在或执行次数过多时删除任务
+func (d *TaskDispatcher) restoreTask(record *mongodb.QueuedTask) *Task {
+	if record.Attempts >= maxTaskAttempts {
+		log.Printf("任务已开始执行 %d 次仍未完成，不再恢复: %s", record.Attempts, record.URL)
+		d.store.drop(record.ID)
+		return nil
+	}
+	handler, err := d.handlers(record.Handler)
+	if err != nil {
+		log.Printf("恢复任务失败: %s, 错误: %v", record.URL, err)
+		d.store.drop(record.ID)
+		return nil
+	}
+	return &Task{
+		ID:          record.ID,
+		URL:         record.URL,
+		Method:      record.Method,
+		Headers:     record.Headers,
+		Body:        record.Body,
+		Handler:     handler,
+		HandlerName: record.Handler,
+		Endpoint:    record.Endpoint,
+		Priority:    record.Priority,
+		DedupTTL:    record.DedupTTL,
+		Retry:       retryFromRecord(record.Retry),
+		Meta:        record.Meta,
+	}
+}
@@ -1,9 +1,14 @@
 package core
 
 import (
-	"github.com/gocolly/colly/v2"
+	"collyDemo/pkg/utils"
+	"context"
+	"fmt"
+	"math/rand"
 	"sync"
 	"time"
+
+	"github.com/gocolly/colly/v2"
 )
 
 // 账号结构
@@ -20,6 +25,19 @@ type Account struct {
 	mu        sync.Mutex
 }
 
+// String 返回脱敏后的账号标识，日志与状态输出中只使用该形式
+func (a *Account) String() string {
+	return fmt.Sprintf("%s(%s)", a.ID, utils.MaskSecret(a.UserName))
+}
+
+// requiredDelay 本次使用前需要与上次使用间隔的时间，在 MinDelay 与 MaxDelay 之间随机
+func (a *Account) requiredDelay() time.Duration {
+	if a.MaxDelay <= a.MinDelay {
+		return a.MinDelay
+	}
+	return a.MinDelay + time.Duration(rand.Int63n(int64(a.MaxDelay-a.MinDelay)))
+}
+
 /*// 分页响应结构
 type PagedResponse struct {
 	Page     int `json:"page"`
@@ -48,10 +66,28 @@ type API2Response struct {
 
 // 任务结构
 type Task struct {
-	URL     string
-	Method  string
-	Headers map[string]string
-	Body    []byte
-	Handler func(*colly.Response, *Account, *TaskDispatcher) error
-	Meta    map[string]interface{}
+	ID          string // 任务ID，加入任务队列时生成
+	URL         string
+	Method      string
+	Headers     map[string]string
+	Body        []byte
+	Handler     func(context.Context, *colly.Response, *Account, *TaskDispatcher) error
+	HandlerName string         // 处理器注册名称，为空的任务不保存到持久化队列
+	Endpoint    string         // 所属接口名称，对应接口目录中的 name
+	Priority    string         // 优先级: rank、list、detail 或 backfill，为空时按 list 处理
+	DedupTTL    time.Duration  // 去重时间，相同请求在该时间内只入队一次，0 表示不去重
+	Retry       *RetryOverride // 接口目录中配置的重试策略，只覆盖已填写的字段，为 nil 时使用默认策略
+	Meta        map[string]interface{}
+}
+
+// taskContextKey colly 请求上下文中保存当前任务的键
+const taskContextKey = "task"
+
+// TaskFromResponse 获取响应对应的任务，处理器用它创建分页和详情任务
+func TaskFromResponse(r *colly.Response) *Task {
+	if r == nil || r.Ctx == nil {
+		return nil
+	}
+	task, _ := r.Ctx.GetAny(taskContextKey).(*Task)
+	return task
 }
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"collyDemo/config"
+	"collyDemo/core"
+	"collyDemo/mongodb"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// runDeadLetterCommand 死信管理命令，重新入队的任务写入持久化队列，由运行中的实例接管执行
+//
+//	collyDemo deadletter list -endpoint live -class auth
+//	collyDemo deadletter show -id <死信ID>
+//	collyDemo deadletter replay -id <死信ID>
+//	collyDemo deadletter replay -endpoint live -class transport
+func runDeadLetterCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("用法: collyDemo deadletter <list|show|replay> [参数]")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "list":
+		listDeadLetters(args[1:])
+	case "show":
+		showDeadLetter(args[1:])
+	case "replay":
+		replayDeadLetters(args[1:])
+	default:
+		fmt.Printf("未知的死信命令: %s\n", args[0])
+		os.Exit(2)
+	}
+}
+
+// deadLetterFlags 死信过滤参数
+func deadLetterFlags(fs *flag.FlagSet) (configPath *string, filter func() mongodb.DeadLetterFilter) {
+	configPath = fs.String("config", "", "配置文件路径")
+	id := fs.String("id", "", "死信ID")
+	endpoint := fs.String("endpoint", "", "接口名称")
+	class := fs.String("class", "", "错误分类: transport、rate_limited、auth、payload、storage、unknown")
+	return configPath, func() mongodb.DeadLetterFilter {
+		return mongodb.DeadLetterFilter{ID: *id, Endpoint: *endpoint, ErrorClass: *class}
+	}
+}
+
+// listDeadLetters 按失败时间倒序输出死信
+func listDeadLetters(args []string) {
+	fs := flag.NewFlagSet("deadletter list", flag.ExitOnError)
+	configPath, filterFlags := deadLetterFlags(fs)
+	status := fs.String("status", mongodb.DeadLetterDead, "状态: dead、replayed，为空表示全部")
+	limit := fs.Int64("limit", 50, "最多输出条数，0 表示不限制")
+	fs.Parse(args)
+
+	filter := filterFlags()
+	filter.Status = *status
+
+	scheduleConfig := loadConfig(config.ResolveConfigPath(*configPath))
+	db := connectMongo(scheduleConfig)
+	defer mongodb.Disconnect(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	letters, err := mongodb.NewDeadLetterDAO(db).List(ctx, filter, *limit)
+	if err != nil {
+		log.Fatalf("读取死信失败: %v", err)
+	}
+	for _, letter := range letters {
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\t尝试=%d\t%s\t%s\n",
+			letter.ID, letter.FailedAt.Format("2006-01-02 15:04:05"), letter.Endpoint, letter.ErrorClass, letter.Status,
+			len(letter.Attempts), letter.URL, truncate(letter.LastError, 120))
+	}
+}
+
+// showDeadLetter 输出单个死信的尝试记录和响应内容
+func showDeadLetter(args []string) {
+	fs := flag.NewFlagSet("deadletter show", flag.ExitOnError)
+	configPath := fs.String("config", "", "配置文件路径")
+	id := fs.String("id", "", "死信ID")
+	fs.Parse(args)
+
+	if *id == "" {
+		log.Fatal("请通过 -id 指定死信")
+	}
+
+	scheduleConfig := loadConfig(config.ResolveConfigPath(*configPath))
+	db := connectMongo(scheduleConfig)
+	defer mongodb.Disconnect(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	letters, err := mongodb.NewDeadLetterDAO(db).List(ctx, mongodb.DeadLetterFilter{ID: *id}, 1)
+	if err != nil {
+		log.Fatalf("读取死信失败: %v", err)
+	}
+	if len(letters) == 0 {
+		log.Fatalf("死信不存在: %s", *id)
+	}
+	letter := letters[0]
+	fmt.Printf("ID: %s\n任务ID: %s\n接口: %s\n处理器: %s\n请求: %s %s\n", letter.ID, letter.TaskID, letter.Endpoint, letter.Handler, letter.Method, letter.URL)
+	if len(letter.Body) > 0 {
+		fmt.Printf("请求体: %s\n", letter.Body)
+	}
+	fmt.Printf("Meta: %v\n账号: %s\n状态: %s\n失败时间: %s\n", letter.Meta, strings.Join(letter.Accounts, ", "), letter.Status, letter.FailedAt.Format("2006-01-02 15:04:05"))
+	if letter.Status == mongodb.DeadLetterReplayed {
+		fmt.Printf("重新入队: %s, 任务ID: %s\n", letter.ReplayedAt.Format("2006-01-02 15:04:05"), letter.ReplayTaskID)
+	}
+	fmt.Println("尝试记录:")
+	for i, attempt := range letter.Attempts {
+		fmt.Printf("  %d. %s\t%s\t账号=%s\t状态码=%d\t耗时=%v\t%s\n", i+1, attempt.Time.Format("15:04:05"),
+			attempt.ErrorClass, attempt.Account, attempt.StatusCode, attempt.Duration.Round(time.Millisecond), attempt.Error)
+	}
+	fmt.Printf("最后错误: %s (%s, 状态码=%d)\n", letter.LastError, letter.ErrorClass, letter.StatusCode)
+	if letter.Response != "" {
+		fmt.Printf("响应内容: %s\n", letter.Response)
+	}
+}
+
+// replayDeadLetters 将符合条件的死信重新入队，需要开启持久化队列
+func replayDeadLetters(args []string) {
+	fs := flag.NewFlagSet("deadletter replay", flag.ExitOnError)
+	configPath, filterFlags := deadLetterFlags(fs)
+	limit := fs.Int64("limit", 0, "最多重新入队条数，0 表示不限制")
+	dryRun := fs.Bool("dry-run", false, "只输出符合条件的死信，不重新入队")
+	fs.Parse(args)
+
+	filter := filterFlags()
+	if filter.ID == "" && filter.Endpoint == "" && filter.ErrorClass == "" {
+		log.Fatal("请通过 -id、-endpoint 或 -class 指定要重新入队的死信")
+	}
+	filter.Status = mongodb.DeadLetterDead
+
+	scheduleConfig := loadConfig(config.ResolveConfigPath(*configPath))
+	if !scheduleConfig.Queue.Persistent {
+		log.Fatal("未开启持久化队列 (queue.persistent)，无法将死信交给运行中的实例执行")
+	}
+	db := connectMongo(scheduleConfig)
+	defer mongodb.Disconnect(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	dead := mongodb.NewDeadLetterDAO(db)
+	letters, err := dead.List(ctx, filter, *limit)
+	if err != nil {
+		log.Fatalf("读取死信失败: %v", err)
+	}
+	if len(letters) == 0 {
+		log.Printf("没有符合条件的死信")
+		return
+	}
+
+	queue := mongodb.NewTaskQueueDAO(db)
+	replayed := 0
+	for _, letter := range letters {
+		if *dryRun {
+			fmt.Printf("%s\t%s\t%s\t%s\n", letter.ID, letter.Endpoint, letter.ErrorClass, letter.URL)
+			continue
+		}
+		taskID, err := core.ReplayDeadLetter(ctx, dead, queue, letter)
+		if err != nil {
+			log.Printf("重新入队失败: %s, 错误: %v", letter.ID, err)
+			continue
+		}
+		replayed++
+		log.Printf("已重新入队: %s -> 任务 %s, %s", letter.ID, taskID, letter.URL)
+	}
+	if !*dryRun {
+		log.Printf("重新入队完成: %d/%d", replayed, len(letters))
+	}
+}
+
+// truncate 截断过长的文本
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "..."
+}
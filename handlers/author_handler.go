@@ -6,7 +6,6 @@ import (
 	"collyDemo/pkg/utils"
 	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 
 	"github.com/gocolly/colly/v2"
@@ -19,7 +18,7 @@ type AuthorResult struct {
 	Sort        Sort              `json:"sort"`
 }
 
-func AuthorHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) AuthorHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理达人列表: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -34,53 +33,29 @@ func AuthorHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher)
 	}
 
 	if result.IsAuthority == false {
-		return nil
-	}
-	headers := map[string]string{
-		"accept":             "*/*",
-		"accept-language":    "zh-HK,zh-CN;q=0.9,zh;q=0.8,zh-TW;q=0.7",
-		"authorization":      acc.Token,
-		"origin":             "https://www.kaogujia.com",
-		"priority":           "u=1, i",
-		"referer":            "https://www.kaogujia.com/",
-		"sec-ch-ua":          `"Google Chrome";v="137", "Chromium";v="137", "Not/A)Brand";v="24"`,
-		"sec-ch-ua-mobile":   "?0",
-		"sec-ch-ua-platform": `"Windows"`,
-		"sec-fetch-dest":     "empty",
-		"sec-fetch-mode":     "cors",
-		"sec-fetch-site":     "same-site",
-		"user-agent":         "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/137.0.0.0 Safari/537.36",
-		"version_code":       "3.1",
-		"content-type":       "application/json",
+		return noAuthority()
 	}
 
 	//  插入列表数据
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewAuthorDAO(db)
+	dao := mongodb.NewAuthorDAO(h.db)
 	var docs []interface{}
 	for _, author := range result.Items {
 		docs = append(docs, author)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create author error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	// 处理分页
+	task := core.TaskFromResponse(r)
 	if result.Pagination.TotalCount > result.Pagination.Page*result.Pagination.Limit {
 		// 创建下一页任务
-		listTask := &core.Task{
-			URL:     fmt.Sprintf("https://service.kaogujia.com/api/author/search?limit=%v&page=%v&sort_field=gmv&sort=0", result.Pagination.Limit, result.Pagination.Page+1),
-			Method:  "POST",
-			Headers: headers,
-			Body:    []byte(`{"sort_field":"gmv","sort":0,"limit":50,"page":1}`),
-			Handler: AuthorHandler,
-			Meta: map[string]interface{}{
-				"page":  result.Pagination.Page + 1,
-				"limit": result.Pagination.Limit,
-			},
+		listTask, err := h.tasks.NextPageTask(task, result.Pagination.Page+1, result.Pagination.Limit)
+		if err != nil {
+			return err
 		}
 		d.AddTask(listTask)
 	}
@@ -89,14 +64,11 @@ func AuthorHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher)
 	for _, item := range result.Items {
 		log.Printf("处理达人详情: ID=%s, Name=%s", item.UID, item.NickName)
 		// 创建详情任务
-		infoTask := &core.Task{
-			URL:     fmt.Sprintf("https://service.kaogujia.com/api/author/detail/%s", item.UID),
-			Method:  "GET",
-			Headers: headers,
-			Handler: AuthorInfoHandler,
-			Meta: map[string]interface{}{
-				"uid": item.UID,
-			},
+		infoTask, err := h.tasks.NewDetailTask(task, item.UID, map[string]interface{}{
+			"uid": item.UID,
+		})
+		if err != nil {
+			return err
 		}
 		d.AddTask(infoTask)
 	}
@@ -105,7 +77,7 @@ func AuthorHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher)
 	return nil
 }
 
-func AuthorInfoHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) AuthorInfoHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理达人详情: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -119,14 +91,13 @@ func AuthorInfoHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatc
 		return err
 	}
 	//  插入详情数据
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewAuthorInfo(db)
-	err = dao.Create(context.Background(), result)
+	dao := mongodb.NewAuthorInfo(h.db)
+	err = dao.Create(ctx, result)
 	if err != nil {
 		log.Printf("Create author info error: %v", err)
 		return err
 	}
+	d.RecordStored(r, 1)
 
 	log.Printf("达人详情处理完成: %s", r.Request.URL.String())
 	return nil
@@ -1,11 +1,25 @@
 package handlers
 
 import (
+	"collyDemo/core"
 	"collyDemo/pkg/utils"
 	"encoding/json"
+	"errors"
 	"github.com/gocolly/colly/v2"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// Handlers 数据处理器集合，持有注入的数据库句柄和用于创建分页、详情任务的任务调度器
+type Handlers struct {
+	db    *mongo.Database
+	tasks *core.TaskScheduler
+}
+
+// New 创建数据处理器集合
+func New(db *mongo.Database, tasks *core.TaskScheduler) *Handlers {
+	return &Handlers{db: db, tasks: tasks}
+}
+
 type Result struct {
 	Code    int
 	Message string
@@ -24,18 +38,26 @@ type Sort struct {
 	Sort      int    `json:"sort"`
 }
 
+// errNoAuthority 接口返回 is_authority=false，当前账号无权查看数据
+var errNoAuthority = errors.New("账号无权限 (is_authority=false)")
+
+// noAuthority 账号无权限的错误，按认证失败换用其他账号重试
+func noAuthority() error {
+	return core.NewTaskError(core.ErrorAuth, errNoAuthority)
+}
+
 func Handler(r *colly.Response) (string, error) {
 	result := new(Result)
 	err := json.Unmarshal(r.Body, result)
 	if err != nil {
 		// todo 记录日志
-		return "", err
+		return "", core.NewTaskError(core.ErrorPayload, err)
 	}
 
 	str, err := utils.Decrypt(r.Request.URL.Path, result.Data)
 	if err != nil {
 		// todo 记录日志
-		return "", err
+		return "", core.NewTaskError(core.ErrorPayload, err)
 	}
 
 	return str, nil
@@ -19,7 +19,7 @@ type AuthorFansIncreaseRankResult struct {
 	Sort        Sort                              `json:"sort"`
 }
 
-func AuthorFansIncreaseRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) AuthorFansIncreaseRankHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理达人涨粉榜: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -33,21 +33,20 @@ func AuthorFansIncreaseRankHandler(r *colly.Response, acc *core.Account, d *core
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewAuthorFansIncreaseRankDAO(db)
+	dao := mongodb.NewAuthorFansIncreaseRankDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create author fans increase rank error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("达人涨粉榜处理完成: %s", r.Request.URL.String())
 	return nil
@@ -61,7 +60,7 @@ type AuthorFansDecreaseRankResult struct {
 	Sort        Sort                              `json:"sort"`
 }
 
-func AuthorFansDecreaseRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) AuthorFansDecreaseRankHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理达人掉粉榜: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -75,21 +74,20 @@ func AuthorFansDecreaseRankHandler(r *colly.Response, acc *core.Account, d *core
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewAuthorFansDecreaseRankDAO(db)
+	dao := mongodb.NewAuthorFansDecreaseRankDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create author fans decrease rank error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("达人掉粉榜处理完成: %s", r.Request.URL.String())
 	return nil
@@ -103,7 +101,7 @@ type AuthorPotentialRankResult struct {
 	Sort        Sort                           `json:"sort"`
 }
 
-func AuthorPotentialRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) AuthorPotentialRankHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理达人带货潜力榜: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -117,21 +115,20 @@ func AuthorPotentialRankHandler(r *colly.Response, acc *core.Account, d *core.Ta
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewAuthorPotentialRankDAO(db)
+	dao := mongodb.NewAuthorPotentialRankDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create author potential rank error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("达人带货潜力榜处理完成: %s", r.Request.URL.String())
 	return nil
@@ -145,7 +142,7 @@ type ProductHotSaleRankResult struct {
 	Sort        Sort                          `json:"sort"`
 }
 
-func ProductHotSaleRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) ProductHotSaleRankHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理商品热销榜: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -159,21 +156,20 @@ func ProductHotSaleRankHandler(r *colly.Response, acc *core.Account, d *core.Tas
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewProductHotSaleRankDAO(db)
+	dao := mongodb.NewProductHotSaleRankDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create product hot sale rank error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("商品热销榜处理完成: %s", r.Request.URL.String())
 	return nil
@@ -187,7 +183,7 @@ type ProductRealTimeSalesRankResult struct {
 	Sort        Sort                                `json:"sort"`
 }
 
-func ProductRealTimeSalesRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) ProductRealTimeSalesRankHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理商品实时销量榜: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -201,21 +197,20 @@ func ProductRealTimeSalesRankHandler(r *colly.Response, acc *core.Account, d *co
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewProductRealTimeSalesRankDAO(db)
+	dao := mongodb.NewProductRealTimeSalesRankDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create product real time sales rank error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("商品实时销量榜处理完成: %s", r.Request.URL.String())
 	return nil
@@ -229,7 +224,7 @@ type LiveAuthorSalesRankResult struct {
 	Sort        Sort                           `json:"sort"`
 }
 
-func LiveAuthorSalesRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) LiveAuthorSalesRankHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理直播达人带货榜: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -243,21 +238,20 @@ func LiveAuthorSalesRankHandler(r *colly.Response, acc *core.Account, d *core.Ta
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewLiveAuthorSalesRankDAO(db)
+	dao := mongodb.NewLiveAuthorSalesRankDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create live author sales rank error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("直播达人带货榜处理完成: %s", r.Request.URL.String())
 	return nil
@@ -271,7 +265,7 @@ type LiveHotPushRankResult struct {
 	Sort        Sort                       `json:"sort"`
 }
 
-func LiveHotPushRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) LiveHotPushRankHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理直播热推榜: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -285,21 +279,20 @@ func LiveHotPushRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDi
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewLiveHotPushRankDAO(db)
+	dao := mongodb.NewLiveHotPushRankDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create live hot push rank error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("直播热推榜处理完成: %s", r.Request.URL.String())
 	return nil
@@ -313,7 +306,7 @@ type HotVideoRankResult struct {
 	Sort        Sort                    `json:"sort"`
 }
 
-func HotVideoRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) HotVideoRankHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理热门视频榜: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -327,21 +320,20 @@ func HotVideoRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDispa
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewHotVideoRankDAO(db)
+	dao := mongodb.NewHotVideoRankDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create hot video rank error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("热门视频榜处理完成: %s", r.Request.URL.String())
 	return nil
@@ -355,7 +347,7 @@ type EcommerceVideoRankResult struct {
 	Sort        Sort                          `json:"sort"`
 }
 
-func EcommerceVideoRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) EcommerceVideoRankHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理电商视频榜: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -369,21 +361,20 @@ func EcommerceVideoRankHandler(r *colly.Response, acc *core.Account, d *core.Tas
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewEcommerceVideoRankDAO(db)
+	dao := mongodb.NewEcommerceVideoRankDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create ecommerce video rank error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("电商视频榜处理完成: %s", r.Request.URL.String())
 	return nil
@@ -397,7 +388,7 @@ type VideoHotPushResult struct {
 	Sort        Sort                    `json:"sort"`
 }
 
-func VideoHotPushHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) VideoHotPushHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理视频热推: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -411,21 +402,20 @@ func VideoHotPushHandler(r *colly.Response, acc *core.Account, d *core.TaskDispa
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewVideoHotPushDAO(db)
+	dao := mongodb.NewVideoHotPushDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create video hot push error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("视频热推处理完成: %s", r.Request.URL.String())
 	return nil
@@ -439,7 +429,7 @@ type HotSaleShopResult struct {
 	Sort        Sort                   `json:"sort"`
 }
 
-func HotSaleShopHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) HotSaleShopHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理热销小店: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -453,21 +443,20 @@ func HotSaleShopHandler(r *colly.Response, acc *core.Account, d *core.TaskDispat
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewHotSaleShopDAO(db)
+	dao := mongodb.NewHotSaleShopDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create hot sale shop error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("热销小店处理完成: %s", r.Request.URL.String())
 	return nil
@@ -481,7 +470,7 @@ type SiteHourlyRankResult struct {
 	Sort        Sort                      `json:"sort"`
 }
 
-func SiteHourlyRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) SiteHourlyRankHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理全站小时榜: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -495,21 +484,20 @@ func SiteHourlyRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDis
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewSiteHourlyRankDAO(db)
+	dao := mongodb.NewSiteHourlyRankDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create site hourly rank error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("全站小时榜处理完成: %s", r.Request.URL.String())
 	return nil
@@ -523,7 +511,7 @@ type SalesHourlyRankResult struct {
 	Sort        Sort                       `json:"sort"`
 }
 
-func SalesHourlyRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) SalesHourlyRankHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理带货小时榜: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -537,21 +525,20 @@ func SalesHourlyRankHandler(r *colly.Response, acc *core.Account, d *core.TaskDi
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewSalesHourlyRankDAO(db)
+	dao := mongodb.NewSalesHourlyRankDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create sales hourly rank error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("带货小时榜处理完成: %s", r.Request.URL.String())
 	return nil
@@ -565,7 +552,7 @@ type RealTimeHotSpotResult struct {
 	Sort        Sort                       `json:"sort"`
 }
 
-func RealTimeHotSpotHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) RealTimeHotSpotHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理实时热点: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -579,21 +566,20 @@ func RealTimeHotSpotHandler(r *colly.Response, acc *core.Account, d *core.TaskDi
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewRealTimeHotSpotDAO(db)
+	dao := mongodb.NewRealTimeHotSpotDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create real time hot spot error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("实时热点处理完成: %s", r.Request.URL.String())
 	return nil
@@ -607,7 +593,7 @@ type SoaringHotSpotResult struct {
 	Sort        Sort                      `json:"sort"`
 }
 
-func SoaringHotSpotHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) SoaringHotSpotHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理飙升热点: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -621,21 +607,20 @@ func SoaringHotSpotHandler(r *colly.Response, acc *core.Account, d *core.TaskDis
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewSoaringHotSpotDAO(db)
+	dao := mongodb.NewSoaringHotSpotDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create soaring hot spot error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("飙升热点处理完成: %s", r.Request.URL.String())
 	return nil
@@ -649,7 +634,7 @@ type ExploreHotBurstResult struct {
 	Sort        Sort                       `json:"sort"`
 }
 
-func ExploreHotBurstHandler(r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
+func (h *Handlers) ExploreHotBurstHandler(ctx context.Context, r *colly.Response, acc *core.Account, d *core.TaskDispatcher) error {
 	log.Printf("处理探测爆款: %s", r.Request.URL.String())
 	str, err := Handler(r)
 	if err != nil {
@@ -663,21 +648,20 @@ func ExploreHotBurstHandler(r *colly.Response, acc *core.Account, d *core.TaskDi
 		return err
 	}
 	if result.IsAuthority == false {
-		return nil
+		return noAuthority()
 	}
 
-	client := mongodb.GetMongo()
-	db := client.Database("kaogujia")
-	dao := mongodb.NewExploreHotBurstDAO(db)
+	dao := mongodb.NewExploreHotBurstDAO(h.db)
 	var docs []interface{}
 	for _, item := range result.Items {
 		docs = append(docs, item)
 	}
-	err = dao.BatchCreate(context.Background(), docs)
+	err = dao.BatchCreate(ctx, docs)
 	if err != nil {
 		log.Printf("Create explore hot burst error: %v", err)
 		return err
 	}
+	d.RecordStored(r, len(docs))
 
 	log.Printf("探测爆款处理完成: %s", r.Request.URL.String())
 	return nil
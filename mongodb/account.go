@@ -0,0 +1,51 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+)
+
+// Account 采集账号实体
+type Account struct {
+	ID        string `json:"id" bson:"id"`
+	UserName  string `json:"username" bson:"username"`
+	Password  string `json:"password" bson:"password"`
+	Token     string `json:"token" bson:"token"`
+	Proxy     string `json:"proxy" bson:"proxy"`
+	RateLimit int    `json:"rate_limit" bson:"rate_limit"` // 每分钟最大请求数
+	MinDelay  string `json:"min_delay" bson:"min_delay"`   // 两次使用之间的最小间隔，如 "2s"
+	MaxDelay  string `json:"max_delay" bson:"max_delay"`   // 两次使用之间的最大间隔，如 "5s"
+	Disabled  bool   `json:"disabled" bson:"disabled"`
+}
+
+// AccountDAO 账号数据访问对象
+type AccountDAO struct {
+	collection *mongo.Collection
+}
+
+// NewAccountDAO 创建账号数据访问对象
+func NewAccountDAO(db *mongo.Database, collection string) *AccountDAO {
+	return &AccountDAO{
+		collection: db.Collection(collection),
+	}
+}
+
+// ListEnabled 获取所有启用的账号，按ID排序
+func (dao *AccountDAO) ListEnabled(ctx context.Context) ([]*Account, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "id", Value: 1}})
+	cursor, err := dao.collection.Find(ctx, bson.M{"disabled": bson.M{"$ne": true}}, findOptions)
+	if err != nil {
+		log.Printf("List accounts error: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	accounts := make([]*Account, 0)
+	if err = cursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
@@ -88,8 +88,8 @@ func NewAuthorDAO(db *mongo.Database) *AuthorDAO {
 }
 
 // Create 创建作者
-func (dao *AuthorDAO) Create(author *Author) error {
-	_, err := dao.collection.InsertOne(context.TODO(), author)
+func (dao *AuthorDAO) Create(ctx context.Context, author *Author) error {
+	_, err := dao.collection.InsertOne(ctx, author)
 	if err != nil {
 		log.Printf("Create author error: %v", err)
 	}
@@ -137,7 +137,7 @@ func (dao *AuthorDAO) GetByID(ctx context.Context, uid string) (*Author, error)
 }
 
 // Update 更新作者信息
-func (dao *AuthorDAO) Update(authorID string, updateData *Author) error {
+func (dao *AuthorDAO) Update(ctx context.Context, authorID string, updateData *Author) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal author error: %v", err)
@@ -153,7 +153,7 @@ func (dao *AuthorDAO) Update(authorID string, updateData *Author) error {
 	filter := bson.M{"uid": authorID} // 注意：根据结构体应使用 uid 而非 author_id
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update author error: %v", err)
 	}
@@ -161,9 +161,9 @@ func (dao *AuthorDAO) Update(authorID string, updateData *Author) error {
 }
 
 // Delete 删除作者
-func (dao *AuthorDAO) Delete(authorID string) error {
+func (dao *AuthorDAO) Delete(ctx context.Context, authorID string) error {
 	filter := bson.M{"author_id": authorID}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete author error: %v", err)
 	}
@@ -193,7 +193,7 @@ func (dao *AuthorDAO) ListAll(ctx context.Context, filter bson.M, page, limit in
 		log.Printf("List authors error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	authors := make([]Author, 0)
 	if err = cursor.All(ctx, &authors); err != nil {
@@ -38,8 +38,8 @@ func NewAuthorFansDecreaseRankDAO(db *mongo.Database) *AuthorFansDecreaseRankDAO
 }
 
 // Create 创建达人掉粉排名
-func (dao *AuthorFansDecreaseRankDAO) Create(authorFansDecreaseRank *AuthorFansDecreaseRank) error {
-	_, err := dao.collection.InsertOne(context.TODO(), authorFansDecreaseRank)
+func (dao *AuthorFansDecreaseRankDAO) Create(ctx context.Context, authorFansDecreaseRank *AuthorFansDecreaseRank) error {
+	_, err := dao.collection.InsertOne(ctx, authorFansDecreaseRank)
 	if err != nil {
 		log.Printf("Create author fans decrease rank error: %v", err)
 	}
@@ -82,7 +82,7 @@ func (dao *AuthorFansDecreaseRankDAO) GetByID(ctx context.Context, uid string, m
 }
 
 // Update 更新达人掉粉排名信息
-func (dao *AuthorFansDecreaseRankDAO) Update(uid string, minTime, maxTime int, updateData *AuthorFansDecreaseRank) error {
+func (dao *AuthorFansDecreaseRankDAO) Update(ctx context.Context, uid string, minTime, maxTime int, updateData *AuthorFansDecreaseRank) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal author fans decrease rank error: %v", err)
@@ -98,7 +98,7 @@ func (dao *AuthorFansDecreaseRankDAO) Update(uid string, minTime, maxTime int, u
 	filter := bson.M{"uid": uid, "min_time": minTime, "max_time": maxTime}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update author fans decrease rank error: %v", err)
 	}
@@ -106,9 +106,9 @@ func (dao *AuthorFansDecreaseRankDAO) Update(uid string, minTime, maxTime int, u
 }
 
 // Delete 删除达人掉粉排名
-func (dao *AuthorFansDecreaseRankDAO) Delete(uid string, minTime, maxTime int) error {
+func (dao *AuthorFansDecreaseRankDAO) Delete(ctx context.Context, uid string, minTime, maxTime int) error {
 	filter := bson.M{"uid": uid, "min_time": minTime, "max_time": maxTime}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete author fans decrease rank error: %v", err)
 	}
@@ -138,7 +138,7 @@ func (dao *AuthorFansDecreaseRankDAO) ListAll(ctx context.Context, filter bson.M
 		log.Printf("List author fans decrease ranks error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	authorFansDecreaseRanks := make([]AuthorFansDecreaseRank, 0)
 	if err = cursor.All(ctx, &authorFansDecreaseRanks); err != nil {
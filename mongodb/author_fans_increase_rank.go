@@ -38,8 +38,8 @@ func NewAuthorFansIncreaseRankDAO(db *mongo.Database) *AuthorFansIncreaseRankDAO
 }
 
 // Create 创建达人涨粉排名
-func (dao *AuthorFansIncreaseRankDAO) Create(authorFansIncreaseRank *AuthorFansIncreaseRank) error {
-	_, err := dao.collection.InsertOne(context.TODO(), authorFansIncreaseRank)
+func (dao *AuthorFansIncreaseRankDAO) Create(ctx context.Context, authorFansIncreaseRank *AuthorFansIncreaseRank) error {
+	_, err := dao.collection.InsertOne(ctx, authorFansIncreaseRank)
 	if err != nil {
 		log.Printf("Create author fans increase rank error: %v", err)
 	}
@@ -82,7 +82,7 @@ func (dao *AuthorFansIncreaseRankDAO) GetByID(ctx context.Context, uid string, m
 }
 
 // Update 更新达人涨粉排名信息
-func (dao *AuthorFansIncreaseRankDAO) Update(uid string, minTime, maxTime int, updateData *AuthorFansIncreaseRank) error {
+func (dao *AuthorFansIncreaseRankDAO) Update(ctx context.Context, uid string, minTime, maxTime int, updateData *AuthorFansIncreaseRank) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal author fans increase rank error: %v", err)
@@ -98,7 +98,7 @@ func (dao *AuthorFansIncreaseRankDAO) Update(uid string, minTime, maxTime int, u
 	filter := bson.M{"uid": uid, "min_time": minTime, "max_time": maxTime}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update author fans increase rank error: %v", err)
 	}
@@ -106,9 +106,9 @@ func (dao *AuthorFansIncreaseRankDAO) Update(uid string, minTime, maxTime int, u
 }
 
 // Delete 删除达人涨粉排名
-func (dao *AuthorFansIncreaseRankDAO) Delete(uid string, minTime, maxTime int) error {
+func (dao *AuthorFansIncreaseRankDAO) Delete(ctx context.Context, uid string, minTime, maxTime int) error {
 	filter := bson.M{"uid": uid, "min_time": minTime, "max_time": maxTime}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete author fans increase rank error: %v", err)
 	}
@@ -138,7 +138,7 @@ func (dao *AuthorFansIncreaseRankDAO) ListAll(ctx context.Context, filter bson.M
 		log.Printf("List author fans increase ranks error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	authorFansIncreaseRanks := make([]AuthorFansIncreaseRank, 0)
 	if err = cursor.All(ctx, &authorFansIncreaseRanks); err != nil {
@@ -39,8 +39,8 @@ func NewAuthorPotentialRankDAO(db *mongo.Database) *AuthorPotentialRankDAO {
 }
 
 // Create 创建达人带货潜力排名
-func (dao *AuthorPotentialRankDAO) Create(authorPotentialRank *AuthorPotentialRank) error {
-	_, err := dao.collection.InsertOne(context.TODO(), authorPotentialRank)
+func (dao *AuthorPotentialRankDAO) Create(ctx context.Context, authorPotentialRank *AuthorPotentialRank) error {
+	_, err := dao.collection.InsertOne(ctx, authorPotentialRank)
 	if err != nil {
 		log.Printf("Create author potential rank error: %v", err)
 	}
@@ -83,7 +83,7 @@ func (dao *AuthorPotentialRankDAO) GetByID(ctx context.Context, uid string, minT
 }
 
 // Update 更新达人带货潜力排名信息
-func (dao *AuthorPotentialRankDAO) Update(uid string, minTime, maxTime int, updateData *AuthorPotentialRank) error {
+func (dao *AuthorPotentialRankDAO) Update(ctx context.Context, uid string, minTime, maxTime int, updateData *AuthorPotentialRank) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal author potential rank error: %v", err)
@@ -99,7 +99,7 @@ func (dao *AuthorPotentialRankDAO) Update(uid string, minTime, maxTime int, upda
 	filter := bson.M{"uid": uid, "min_time": minTime, "max_time": maxTime}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update author potential rank error: %v", err)
 	}
@@ -107,9 +107,9 @@ func (dao *AuthorPotentialRankDAO) Update(uid string, minTime, maxTime int, upda
 }
 
 // Delete 删除达人带货潜力排名
-func (dao *AuthorPotentialRankDAO) Delete(uid string, minTime, maxTime int) error {
+func (dao *AuthorPotentialRankDAO) Delete(ctx context.Context, uid string, minTime, maxTime int) error {
 	filter := bson.M{"uid": uid, "min_time": minTime, "max_time": maxTime}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete author potential rank error: %v", err)
 	}
@@ -139,7 +139,7 @@ func (dao *AuthorPotentialRankDAO) ListAll(ctx context.Context, filter bson.M, p
 		log.Printf("List author potential ranks error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	authorPotentialRanks := make([]AuthorPotentialRank, 0)
 	if err = cursor.All(ctx, &authorPotentialRanks); err != nil {
@@ -0,0 +1,124 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+// 回填任务及窗口状态
+const (
+	BackfillPending   = "pending"   // 等待执行
+	BackfillRunning   = "running"   // 执行中
+	BackfillDone      = "done"      // 已完成
+	BackfillFailed    = "failed"    // 窗口采集失败
+	BackfillCancelled = "cancelled" // 已取消
+)
+
+// BackfillJob 历史数据回填任务，按窗口逐个采集，已完成的窗口作为断点保存
+type BackfillJob struct {
+	ID        string           `json:"id" bson:"_id"`                // 任务ID
+	Endpoint  string           `json:"endpoint" bson:"endpoint"`     // 接口名称
+	From      string           `json:"from" bson:"from"`             // 开始日期 YYYY-MM-DD
+	To        string           `json:"to" bson:"to"`                 // 结束日期 YYYY-MM-DD
+	Step      string           `json:"step" bson:"step"`             // 窗口粒度: day 或 week
+	Status    string           `json:"status" bson:"status"`         // 任务状态
+	Next      int              `json:"next" bson:"next"`             // 下一个待采集窗口的下标
+	Windows   []BackfillWindow `json:"windows" bson:"windows"`       // 所有窗口
+	CreatedAt time.Time        `json:"created_at" bson:"created_at"` // 创建时间
+	UpdatedAt time.Time        `json:"updated_at" bson:"updated_at"` // 更新时间
+}
+
+// BackfillWindow 回填窗口，起止日期均包含在内
+type BackfillWindow struct {
+	Start      string    `json:"start" bson:"start"`             // 开始日期 YYYY-MM-DD
+	End        string    `json:"end" bson:"end"`                 // 结束日期 YYYY-MM-DD
+	Status     string    `json:"status" bson:"status"`           // 窗口状态
+	RunID      string    `json:"run_id" bson:"run_id"`           // 采集批次ID
+	Items      int       `json:"items" bson:"items"`             // 入库的数据条数
+	FinishedAt time.Time `json:"finished_at" bson:"finished_at"` // 完成时间
+}
+
+// BackfillJobDAO 回填任务数据访问对象
+type BackfillJobDAO struct {
+	collection *mongo.Collection
+}
+
+// NewBackfillJobDAO 创建回填任务数据访问对象
+func NewBackfillJobDAO(db *mongo.Database) *BackfillJobDAO {
+	return &BackfillJobDAO{
+		collection: db.Collection("backfill_jobs"), // 集合名
+	}
+}
+
+// Create 创建回填任务
+func (dao *BackfillJobDAO) Create(ctx context.Context, job *BackfillJob) error {
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = job.CreatedAt
+	_, err := dao.collection.InsertOne(ctx, job)
+	if err != nil {
+		log.Printf("Create backfill job error: %v", err)
+	}
+	return err
+}
+
+// SaveProgress 保存回填进度，任务已被取消时不覆盖并返回 false
+func (dao *BackfillJobDAO) SaveProgress(ctx context.Context, job *BackfillJob) (bool, error) {
+	job.UpdatedAt = time.Now()
+	filter := bson.M{"_id": job.ID, "status": bson.M{"$ne": BackfillCancelled}}
+	result, err := dao.collection.ReplaceOne(ctx, filter, job)
+	if err != nil {
+		log.Printf("Save backfill job error: %v", err)
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// List 按创建时间获取所有回填任务
+func (dao *BackfillJobDAO) List(ctx context.Context) ([]*BackfillJob, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := dao.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		log.Printf("List backfill jobs error: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	jobs := make([]*BackfillJob, 0)
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// NextActive 获取最早创建的未完成回填任务，没有时返回 nil
+func (dao *BackfillJobDAO) NextActive(ctx context.Context) (*BackfillJob, error) {
+	filter := bson.M{"status": bson.M{"$in": bson.A{BackfillPending, BackfillRunning}}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	job := new(BackfillJob)
+	err := dao.collection.FindOne(ctx, filter, opts).Decode(job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		log.Printf("Get backfill job error: %v", err)
+		return nil, err
+	}
+	return job, nil
+}
+
+// Cancel 取消未完成的回填任务，任务不存在或已结束时返回 false
+func (dao *BackfillJobDAO) Cancel(ctx context.Context, id string) (bool, error) {
+	filter := bson.M{"_id": id, "status": bson.M{"$in": bson.A{BackfillPending, BackfillRunning}}}
+	update := bson.M{"$set": bson.M{"status": BackfillCancelled, "updated_at": time.Now()}}
+	result, err := dao.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Printf("Cancel backfill job error: %v", err)
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
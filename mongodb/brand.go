@@ -145,7 +145,7 @@ func (dao *BrandDAO) ListAll(ctx context.Context, filter bson.M, page, limit int
 		log.Printf("List authors error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	brands := make([]Brand, 0)
 	if err = cursor.All(ctx, &brands); err != nil {
@@ -0,0 +1,68 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+// 采集批次状态
+const (
+	CrawlRunRunning     = "running"     // 执行中
+	CrawlRunSucceeded   = "succeeded"   // 所有任务成功
+	CrawlRunFailed      = "failed"      // 存在失败的任务
+	CrawlRunInterrupted = "interrupted" // 程序关闭时尚未完成
+	CrawlRunStalled     = "stalled"     // 长时间没有任务完成，不再等待
+)
+
+// CrawlRun 一次定时触发及其派生的全部分页、详情任务的执行记录
+type CrawlRun struct {
+	ID         string            `json:"id" bson:"_id"`                  // 批次ID
+	Job        string            `json:"job" bson:"job"`                 // 触发的定时任务ID
+	Endpoint   string            `json:"endpoint" bson:"endpoint"`       // 接口名称
+	Status     string            `json:"status" bson:"status"`           // 批次状态
+	StartedAt  time.Time         `json:"started_at" bson:"started_at"`   // 开始时间
+	FinishedAt time.Time         `json:"finished_at" bson:"finished_at"` // 结束时间
+	Tasks      int               `json:"tasks" bson:"tasks"`             // 任务总数
+	Pending    int               `json:"pending" bson:"pending"`         // 未完成的任务数
+	Succeeded  int               `json:"succeeded" bson:"succeeded"`     // 成功的任务数
+	Failed     int               `json:"failed" bson:"failed"`           // 失败的任务数
+	HandedOff  int               `json:"handed_off" bson:"handed_off"`   // 已由其他实例接管或已完成、本实例未执行的任务数
+	Pages      int               `json:"pages" bson:"pages"`             // 成功的列表页数
+	Details    int               `json:"details" bson:"details"`         // 成功的详情数
+	Items      int               `json:"items" bson:"items"`             // 入库的数据条数
+	Failures   []CrawlRunFailure `json:"failures" bson:"failures"`       // 失败的任务，最多保留前若干条
+	Accounts   []string          `json:"accounts" bson:"accounts"`       // 使用过的账号ID
+}
+
+// CrawlRunFailure 失败任务
+type CrawlRunFailure struct {
+	URL      string    `json:"url" bson:"url"`
+	Error    string    `json:"error" bson:"error"`
+	Accounts []string  `json:"accounts" bson:"accounts"` // 任务尝试过的账号ID
+	Time     time.Time `json:"time" bson:"time"`
+}
+
+// CrawlRunDAO 采集批次数据访问对象
+type CrawlRunDAO struct {
+	collection *mongo.Collection
+}
+
+// NewCrawlRunDAO 创建采集批次数据访问对象
+func NewCrawlRunDAO(db *mongo.Database) *CrawlRunDAO {
+	return &CrawlRunDAO{
+		collection: db.Collection("crawl_runs"), // 集合名
+	}
+}
+
+// Save 保存批次记录，不存在时创建
+func (dao *CrawlRunDAO) Save(ctx context.Context, run *CrawlRun) error {
+	_, err := dao.collection.ReplaceOne(ctx, bson.M{"_id": run.ID}, run, options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Printf("Save crawl run error: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+// 死信状态
+const (
+	DeadLetterDead     = "dead"     // 等待处理
+	DeadLetterReplayed = "replayed" // 已重新入队
+)
+
+// DeadLetter 重试后最终失败的任务，保存请求信息和每次尝试的错误，修复原因后可重新入队
+type DeadLetter struct {
+	ID           string                 `json:"id" bson:"_id"`
+	TaskID       string                 `json:"task_id" bson:"task_id"`               // 原任务ID
+	Handler      string                 `json:"handler" bson:"handler"`               // 处理器名称
+	Endpoint     string                 `json:"endpoint" bson:"endpoint"`             // 所属接口
+	URL          string                 `json:"url" bson:"url"`                       // 请求地址
+	Method       string                 `json:"method" bson:"method"`                 // 请求方法
+	Headers      map[string]string      `json:"headers" bson:"headers"`               // 请求头
+	Body         []byte                 `json:"body" bson:"body"`                     // 请求体
+	Priority     string                 `json:"priority" bson:"priority"`             // 优先级
+	Meta         map[string]interface{} `json:"meta" bson:"meta"`                     // 任务 meta
+	DedupTTL     time.Duration          `json:"dedup_ttl" bson:"dedup_ttl"`           // 去重时间
+	Retry        *QueuedRetry           `json:"retry" bson:"retry,omitempty"`         // 接口目录中配置的重试策略，为空时使用默认策略
+	Accounts     []string               `json:"accounts" bson:"accounts"`             // 尝试过的账号
+	Attempts     []DeadLetterAttempt    `json:"attempts" bson:"attempts"`             // 每次尝试的记录
+	ErrorClass   string                 `json:"error_class" bson:"error_class"`       // 最后一次错误的分类
+	LastError    string                 `json:"last_error" bson:"last_error"`         // 最后一次错误
+	StatusCode   int                    `json:"status_code" bson:"status_code"`       // 最后一次响应的状态码
+	Response     string                 `json:"response" bson:"response"`             // 最后一次响应内容的开头部分
+	Status       string                 `json:"status" bson:"status"`                 // dead 或 replayed
+	FailedAt     time.Time              `json:"failed_at" bson:"failed_at"`           // 最终失败时间
+	ReplayedAt   time.Time              `json:"replayed_at" bson:"replayed_at"`       // 重新入队时间
+	ReplayTaskID string                 `json:"replay_task_id" bson:"replay_task_id"` // 重新入队的任务ID
+}
+
+// DeadLetterAttempt 一次尝试的记录
+type DeadLetterAttempt struct {
+	Account    string        `json:"account" bson:"account"`         // 使用的账号
+	ErrorClass string        `json:"error_class" bson:"error_class"` // 错误分类
+	Error      string        `json:"error" bson:"error"`             // 错误信息
+	StatusCode int           `json:"status_code" bson:"status_code"` // 响应状态码，未收到响应为 0
+	Time       time.Time     `json:"time" bson:"time"`               // 开始时间
+	Duration   time.Duration `json:"duration" bson:"duration"`       // 耗时
+}
+
+// DeadLetterFilter 死信查询条件，为空的字段不参与过滤
+type DeadLetterFilter struct {
+	ID         string
+	Endpoint   string
+	ErrorClass string
+	Status     string
+}
+
+func (f DeadLetterFilter) bson() bson.M {
+	filter := bson.M{}
+	if f.ID != "" {
+		filter["_id"] = f.ID
+	}
+	if f.Endpoint != "" {
+		filter["endpoint"] = f.Endpoint
+	}
+	if f.ErrorClass != "" {
+		filter["error_class"] = f.ErrorClass
+	}
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	return filter
+}
+
+// DeadLetterDAO 死信数据访问对象
+type DeadLetterDAO struct {
+	collection *mongo.Collection
+}
+
+// NewDeadLetterDAO 创建死信数据访问对象
+func NewDeadLetterDAO(db *mongo.Database) *DeadLetterDAO {
+	return &DeadLetterDAO{
+		collection: db.Collection("dead_letters"), // 集合名
+	}
+}
+
+// EnsureIndexes 创建按接口和错误分类查询使用的索引
+func (dao *DeadLetterDAO) EnsureIndexes(ctx context.Context) error {
+	_, err := dao.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "endpoint", Value: 1}, {Key: "error_class", Value: 1}}},
+		{Keys: bson.D{{Key: "failed_at", Value: -1}}},
+	})
+	if err != nil {
+		log.Printf("Create dead letter indexes error: %v", err)
+	}
+	return err
+}
+
+// Create 保存死信
+func (dao *DeadLetterDAO) Create(ctx context.Context, letter *DeadLetter) error {
+	letter.Status = DeadLetterDead
+	letter.FailedAt = time.Now()
+	_, err := dao.collection.InsertOne(ctx, letter)
+	if err != nil {
+		log.Printf("Create dead letter error: %v", err)
+	}
+	return err
+}
+
+// List 按失败时间倒序查询死信，limit 为 0 表示不限制
+func (dao *DeadLetterDAO) List(ctx context.Context, filter DeadLetterFilter, limit int64) ([]*DeadLetter, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "failed_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := dao.collection.Find(ctx, filter.bson(), opts)
+	if err != nil {
+		log.Printf("List dead letters error: %v", err)
+		return nil, err
+	}
+	var letters []*DeadLetter
+	if err = cursor.All(ctx, &letters); err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+// MarkReplayed 标记死信已重新入队，死信不存在或已重新入队时返回 false
+func (dao *DeadLetterDAO) MarkReplayed(ctx context.Context, id, taskID string) (bool, error) {
+	filter := bson.M{"_id": id, "status": DeadLetterDead}
+	update := bson.M{"$set": bson.M{"status": DeadLetterReplayed, "replayed_at": time.Now(), "replay_task_id": taskID}}
+	result, err := dao.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Printf("Mark dead letter replayed error: %v", err)
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Unmark 重新入队失败时恢复为等待处理
+func (dao *DeadLetterDAO) Unmark(ctx context.Context, id string) error {
+	update := bson.M{"$set": bson.M{"status": DeadLetterDead, "replay_task_id": ""}}
+	_, err := dao.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		log.Printf("Unmark dead letter error: %v", err)
+	}
+	return err
+}
@@ -41,8 +41,8 @@ func NewEcommerceVideoRankDAO(db *mongo.Database) *EcommerceVideoRankDAO {
 }
 
 // Create 创建电商视频榜
-func (dao *EcommerceVideoRankDAO) Create(ecommerceVideoRank *EcommerceVideoRank) error {
-	_, err := dao.collection.InsertOne(context.TODO(), ecommerceVideoRank)
+func (dao *EcommerceVideoRankDAO) Create(ctx context.Context, ecommerceVideoRank *EcommerceVideoRank) error {
+	_, err := dao.collection.InsertOne(ctx, ecommerceVideoRank)
 	if err != nil {
 		log.Printf("Create ecommerce video rank error: %v", err)
 	}
@@ -85,7 +85,7 @@ func (dao *EcommerceVideoRankDAO) GetByID(ctx context.Context, awemeID string, d
 }
 
 // Update 更新电商视频榜信息
-func (dao *EcommerceVideoRankDAO) Update(awemeID string, dateCode int, updateData *EcommerceVideoRank) error {
+func (dao *EcommerceVideoRankDAO) Update(ctx context.Context, awemeID string, dateCode int, updateData *EcommerceVideoRank) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal ecommerce video rank error: %v", err)
@@ -101,7 +101,7 @@ func (dao *EcommerceVideoRankDAO) Update(awemeID string, dateCode int, updateDat
 	filter := bson.M{"aweme_id": awemeID, "date_code": dateCode}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update ecommerce video rank error: %v", err)
 	}
@@ -109,9 +109,9 @@ func (dao *EcommerceVideoRankDAO) Update(awemeID string, dateCode int, updateDat
 }
 
 // Delete 删除电商视频榜
-func (dao *EcommerceVideoRankDAO) Delete(awemeID string, dateCode int) error {
+func (dao *EcommerceVideoRankDAO) Delete(ctx context.Context, awemeID string, dateCode int) error {
 	filter := bson.M{"aweme_id": awemeID, "date_code": dateCode}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete ecommerce video rank error: %v", err)
 	}
@@ -141,7 +141,7 @@ func (dao *EcommerceVideoRankDAO) ListAll(ctx context.Context, filter bson.M, pa
 		log.Printf("List ecommerce video ranks error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	ecommerceVideoRanks := make([]EcommerceVideoRank, 0)
 	if err = cursor.All(ctx, &ecommerceVideoRanks); err != nil {
@@ -53,8 +53,8 @@ func NewExploreHotBurstDAO(db *mongo.Database) *ExploreHotBurstDAO {
 }
 
 // Create 创建探测爆款
-func (dao *ExploreHotBurstDAO) Create(exploreHotBurst *ExploreHotBurst) error {
-	_, err := dao.collection.InsertOne(context.TODO(), exploreHotBurst)
+func (dao *ExploreHotBurstDAO) Create(ctx context.Context, exploreHotBurst *ExploreHotBurst) error {
+	_, err := dao.collection.InsertOne(ctx, exploreHotBurst)
 	if err != nil {
 		log.Printf("Create explore hot burst error: %v", err)
 	}
@@ -97,7 +97,7 @@ func (dao *ExploreHotBurstDAO) GetByID(ctx context.Context, shopID string) (*Exp
 }
 
 // Update 更新探测爆款信息
-func (dao *ExploreHotBurstDAO) Update(shopID string, updateData *ExploreHotBurst) error {
+func (dao *ExploreHotBurstDAO) Update(ctx context.Context, shopID string, updateData *ExploreHotBurst) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal explore hot burst error: %v", err)
@@ -113,7 +113,7 @@ func (dao *ExploreHotBurstDAO) Update(shopID string, updateData *ExploreHotBurst
 	filter := bson.M{"shop_id": shopID}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update explore hot burst error: %v", err)
 	}
@@ -121,9 +121,9 @@ func (dao *ExploreHotBurstDAO) Update(shopID string, updateData *ExploreHotBurst
 }
 
 // Delete 删除探测爆款
-func (dao *ExploreHotBurstDAO) Delete(shopID string) error {
+func (dao *ExploreHotBurstDAO) Delete(ctx context.Context, shopID string) error {
 	filter := bson.M{"shop_id": shopID}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete explore hot burst error: %v", err)
 	}
@@ -153,7 +153,7 @@ func (dao *ExploreHotBurstDAO) ListAll(ctx context.Context, filter bson.M, page,
 		log.Printf("List explore hot bursts error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	exploreHotBursts := make([]ExploreHotBurst, 0)
 	if err = cursor.All(ctx, &exploreHotBursts); err != nil {
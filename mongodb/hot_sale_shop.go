@@ -37,8 +37,8 @@ func NewHotSaleShopDAO(db *mongo.Database) *HotSaleShopDAO {
 }
 
 // Create 创建热销小店
-func (dao *HotSaleShopDAO) Create(hotSaleShop *HotSaleShop) error {
-	_, err := dao.collection.InsertOne(context.TODO(), hotSaleShop)
+func (dao *HotSaleShopDAO) Create(ctx context.Context, hotSaleShop *HotSaleShop) error {
+	_, err := dao.collection.InsertOne(ctx, hotSaleShop)
 	if err != nil {
 		log.Printf("Create hot sale shop error: %v", err)
 	}
@@ -81,7 +81,7 @@ func (dao *HotSaleShopDAO) GetByID(ctx context.Context, shopID string) (*HotSale
 }
 
 // Update 更新热销小店信息
-func (dao *HotSaleShopDAO) Update(shopID string, updateData *HotSaleShop) error {
+func (dao *HotSaleShopDAO) Update(ctx context.Context, shopID string, updateData *HotSaleShop) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal hot sale shop error: %v", err)
@@ -97,7 +97,7 @@ func (dao *HotSaleShopDAO) Update(shopID string, updateData *HotSaleShop) error
 	filter := bson.M{"shop_id": shopID}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update hot sale shop error: %v", err)
 	}
@@ -105,9 +105,9 @@ func (dao *HotSaleShopDAO) Update(shopID string, updateData *HotSaleShop) error
 }
 
 // Delete 删除热销小店
-func (dao *HotSaleShopDAO) Delete(shopID string) error {
+func (dao *HotSaleShopDAO) Delete(ctx context.Context, shopID string) error {
 	filter := bson.M{"shop_id": shopID}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete hot sale shop error: %v", err)
 	}
@@ -137,7 +137,7 @@ func (dao *HotSaleShopDAO) ListAll(ctx context.Context, filter bson.M, page, lim
 		log.Printf("List hot sale shops error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	hotSaleShops := make([]HotSaleShop, 0)
 	if err = cursor.All(ctx, &hotSaleShops); err != nil {
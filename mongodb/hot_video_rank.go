@@ -41,8 +41,8 @@ func NewHotVideoRankDAO(db *mongo.Database) *HotVideoRankDAO {
 }
 
 // Create 创建热门视频榜
-func (dao *HotVideoRankDAO) Create(hotVideoRank *HotVideoRank) error {
-	_, err := dao.collection.InsertOne(context.TODO(), hotVideoRank)
+func (dao *HotVideoRankDAO) Create(ctx context.Context, hotVideoRank *HotVideoRank) error {
+	_, err := dao.collection.InsertOne(ctx, hotVideoRank)
 	if err != nil {
 		log.Printf("Create hot video rank error: %v", err)
 	}
@@ -85,7 +85,7 @@ func (dao *HotVideoRankDAO) GetByID(ctx context.Context, awemeID string, dateCod
 }
 
 // Update 更新热门视频榜信息
-func (dao *HotVideoRankDAO) Update(awemeID string, dateCode int, updateData *HotVideoRank) error {
+func (dao *HotVideoRankDAO) Update(ctx context.Context, awemeID string, dateCode int, updateData *HotVideoRank) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal hot video rank error: %v", err)
@@ -101,7 +101,7 @@ func (dao *HotVideoRankDAO) Update(awemeID string, dateCode int, updateData *Hot
 	filter := bson.M{"aweme_id": awemeID, "date_code": dateCode}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update hot video rank error: %v", err)
 	}
@@ -109,9 +109,9 @@ func (dao *HotVideoRankDAO) Update(awemeID string, dateCode int, updateData *Hot
 }
 
 // Delete 删除热门视频榜
-func (dao *HotVideoRankDAO) Delete(awemeID string, dateCode int) error {
+func (dao *HotVideoRankDAO) Delete(ctx context.Context, awemeID string, dateCode int) error {
 	filter := bson.M{"aweme_id": awemeID, "date_code": dateCode}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete hot video rank error: %v", err)
 	}
@@ -141,7 +141,7 @@ func (dao *HotVideoRankDAO) ListAll(ctx context.Context, filter bson.M, page, li
 		log.Printf("List hot video ranks error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	hotVideoRanks := make([]HotVideoRank, 0)
 	if err = cursor.All(ctx, &hotVideoRanks); err != nil {
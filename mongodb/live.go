@@ -147,7 +147,7 @@ func (dao *LiveDAO) ListAll(ctx context.Context, filter bson.M, page, limit int6
 		log.Printf("List authors error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	lives := make([]Live, 0)
 	if err = cursor.All(ctx, &lives); err != nil {
@@ -39,8 +39,8 @@ func NewLiveAuthorSalesRankDAO(db *mongo.Database) *LiveAuthorSalesRankDAO {
 }
 
 // Create 创建直播达人带货排名
-func (dao *LiveAuthorSalesRankDAO) Create(liveAuthorSalesRank *LiveAuthorSalesRank) error {
-	_, err := dao.collection.InsertOne(context.TODO(), liveAuthorSalesRank)
+func (dao *LiveAuthorSalesRankDAO) Create(ctx context.Context, liveAuthorSalesRank *LiveAuthorSalesRank) error {
+	_, err := dao.collection.InsertOne(ctx, liveAuthorSalesRank)
 	if err != nil {
 		log.Printf("Create live author sales rank error: %v", err)
 	}
@@ -83,7 +83,7 @@ func (dao *LiveAuthorSalesRankDAO) GetByID(ctx context.Context, uid string, minT
 }
 
 // Update 更新直播达人带货排名信息
-func (dao *LiveAuthorSalesRankDAO) Update(uid string, minTime, maxTime int, updateData *LiveAuthorSalesRank) error {
+func (dao *LiveAuthorSalesRankDAO) Update(ctx context.Context, uid string, minTime, maxTime int, updateData *LiveAuthorSalesRank) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal live author sales rank error: %v", err)
@@ -99,7 +99,7 @@ func (dao *LiveAuthorSalesRankDAO) Update(uid string, minTime, maxTime int, upda
 	filter := bson.M{"uid": uid, "min_time": minTime, "max_time": maxTime}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update live author sales rank error: %v", err)
 	}
@@ -107,9 +107,9 @@ func (dao *LiveAuthorSalesRankDAO) Update(uid string, minTime, maxTime int, upda
 }
 
 // Delete 删除直播达人带货排名
-func (dao *LiveAuthorSalesRankDAO) Delete(uid string, minTime, maxTime int) error {
+func (dao *LiveAuthorSalesRankDAO) Delete(ctx context.Context, uid string, minTime, maxTime int) error {
 	filter := bson.M{"uid": uid, "min_time": minTime, "max_time": maxTime}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete live author sales rank error: %v", err)
 	}
@@ -139,7 +139,7 @@ func (dao *LiveAuthorSalesRankDAO) ListAll(ctx context.Context, filter bson.M, p
 		log.Printf("List live author sales ranks error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	liveAuthorSalesRanks := make([]LiveAuthorSalesRank, 0)
 	if err = cursor.All(ctx, &liveAuthorSalesRanks); err != nil {
@@ -39,8 +39,8 @@ func NewLiveHotPushRankDAO(db *mongo.Database) *LiveHotPushRankDAO {
 }
 
 // Create 创建直播热推榜
-func (dao *LiveHotPushRankDAO) Create(liveHotPushRank *LiveHotPushRank) error {
-	_, err := dao.collection.InsertOne(context.TODO(), liveHotPushRank)
+func (dao *LiveHotPushRankDAO) Create(ctx context.Context, liveHotPushRank *LiveHotPushRank) error {
+	_, err := dao.collection.InsertOne(ctx, liveHotPushRank)
 	if err != nil {
 		log.Printf("Create live hot push rank error: %v", err)
 	}
@@ -83,7 +83,7 @@ func (dao *LiveHotPushRankDAO) GetByID(ctx context.Context, productID string) (*
 }
 
 // Update 更新直播热推榜信息
-func (dao *LiveHotPushRankDAO) Update(productID string, updateData *LiveHotPushRank) error {
+func (dao *LiveHotPushRankDAO) Update(ctx context.Context, productID string, updateData *LiveHotPushRank) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal live hot push rank error: %v", err)
@@ -99,7 +99,7 @@ func (dao *LiveHotPushRankDAO) Update(productID string, updateData *LiveHotPushR
 	filter := bson.M{"product_id": productID}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update live hot push rank error: %v", err)
 	}
@@ -107,9 +107,9 @@ func (dao *LiveHotPushRankDAO) Update(productID string, updateData *LiveHotPushR
 }
 
 // Delete 删除直播热推榜
-func (dao *LiveHotPushRankDAO) Delete(productID string) error {
+func (dao *LiveHotPushRankDAO) Delete(ctx context.Context, productID string) error {
 	filter := bson.M{"product_id": productID}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete live hot push rank error: %v", err)
 	}
@@ -139,7 +139,7 @@ func (dao *LiveHotPushRankDAO) ListAll(ctx context.Context, filter bson.M, page,
 		log.Printf("List live hot push ranks error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	liveHotPushRanks := make([]LiveHotPushRank, 0)
 	if err = cursor.All(ctx, &liveHotPushRanks); err != nil {
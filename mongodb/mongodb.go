@@ -2,41 +2,158 @@ package mongodb
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 	"log"
+	"os"
+	"strconv"
 	"time"
 )
 
-var MongoClient *mongo.Client
+// Options MongoDB 连接配置
+type Options struct {
+	URI                    string
+	Database               string
+	Username               string
+	Password               string
+	AuthSource             string
+	MaxPoolSize            uint64
+	MinPoolSize            uint64
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+	ReadConcern            string // local、majority、available、linearizable、snapshot，为空使用服务端默认
+	WriteConcern           string // majority 或确认节点数，为空使用服务端默认
+	TLS                    bool
+	TLSCAFile              string
+	TLSInsecure            bool
+	ConnectRetries         int           // 最大连接尝试次数，0 表示一直重试
+	RetryBackoff           time.Duration // 首次重试等待时间，之后翻倍
+	MaxRetryBackoff        time.Duration // 重试等待时间上限
+}
 
-func InitMongo() error {
+// Connect 连接 MongoDB 并返回配置的数据库，连接失败时按指数退避重试，直到成功、达到重试次数或 ctx 结束
+func Connect(ctx context.Context, opts Options) (*mongo.Database, error) {
+	clientOptions, err := clientOptions(opts)
+	if err != nil {
+		return nil, err
+	}
 
-	clientOptions := options.Client().ApplyURI("mongodb://192.168.232.133:27017")
+	backoff := opts.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		client, err := connect(ctx, clientOptions, opts.ConnectTimeout)
+		if err == nil {
+			log.Printf("Connected to MongoDB! database: %s", opts.Database)
+			return client.Database(opts.Database), nil
+		}
 
-	// 连接 MongoDB（带超时控制）
+		if opts.ConnectRetries > 0 && attempt >= opts.ConnectRetries {
+			return nil, fmt.Errorf("连接 MongoDB 失败，已尝试 %d 次: %w", attempt, err)
+		}
+		log.Printf("连接 MongoDB 失败 (第 %d 次): %v, %v 后重试", attempt, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if opts.MaxRetryBackoff > 0 && backoff > opts.MaxRetryBackoff {
+			backoff = opts.MaxRetryBackoff
+		}
+	}
+}
+
+// Disconnect 断开数据库连接
+func Disconnect(db *mongo.Database) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if err := db.Client().Disconnect(ctx); err != nil {
+		log.Printf("Disconnect MongoDB error: %v", err)
+	}
+}
+
+func connect(ctx context.Context, clientOptions *options.ClientOptions, timeout time.Duration) (*mongo.Client, error) {
+	// 连接 MongoDB（带超时控制）
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		log.Fatal(err)
-		return err
+		return nil, err
 	}
-	//defer client.Disconnect(ctx) // 确保关闭连接
 
 	// 检查连接
-	err = client.Ping(ctx, nil)
-	if err != nil {
-		log.Fatal("Ping failed: ", err)
-		return err
+	if err = client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("ping failed: %w", err)
 	}
-	MongoClient = client
-	fmt.Println("Connected to MongoDB!")
-	return nil
+	return client, nil
 }
 
-func GetMongo() *mongo.Client {
-	return MongoClient
+func clientOptions(opts Options) (*options.ClientOptions, error) {
+	if opts.URI == "" {
+		return nil, errors.New("MongoDB URI 不能为空")
+	}
+	clientOptions := options.Client().ApplyURI(opts.URI)
+
+	if opts.Username != "" {
+		clientOptions.SetAuth(options.Credential{
+			Username:   opts.Username,
+			Password:   opts.Password,
+			AuthSource: opts.AuthSource,
+		})
+	}
+	if opts.MaxPoolSize > 0 {
+		clientOptions.SetMaxPoolSize(opts.MaxPoolSize)
+	}
+	if opts.MinPoolSize > 0 {
+		clientOptions.SetMinPoolSize(opts.MinPoolSize)
+	}
+	if opts.ConnectTimeout > 0 {
+		clientOptions.SetConnectTimeout(opts.ConnectTimeout)
+	}
+	if opts.ServerSelectionTimeout > 0 {
+		clientOptions.SetServerSelectionTimeout(opts.ServerSelectionTimeout)
+	}
+	if opts.ReadConcern != "" {
+		clientOptions.SetReadConcern(&readconcern.ReadConcern{Level: opts.ReadConcern})
+	}
+	if opts.WriteConcern != "" {
+		clientOptions.SetWriteConcern(writeConcern(opts.WriteConcern))
+	}
+
+	if opts.TLS {
+		tlsConfig := &tls.Config{InsecureSkipVerify: opts.TLSInsecure}
+		if opts.TLSCAFile != "" {
+			pem, err := os.ReadFile(opts.TLSCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("读取 TLS CA 文件失败: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("TLS CA 文件无有效证书: %s", opts.TLSCAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		clientOptions.SetTLSConfig(tlsConfig)
+	}
+
+	return clientOptions, nil
+}
+
+func writeConcern(value string) *writeconcern.WriteConcern {
+	if w, err := strconv.Atoi(value); err == nil {
+		return &writeconcern.WriteConcern{W: w}
+	}
+	if value == "majority" {
+		return writeconcern.Majority()
+	}
+	return &writeconcern.WriteConcern{W: value}
 }
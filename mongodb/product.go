@@ -156,7 +156,7 @@ func (dao *ProductDAO) ListAll(ctx context.Context, filter bson.M, page, limit i
 		log.Printf("List authors error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	products := make([]Product, 0)
 	if err = cursor.All(ctx, &products); err != nil {
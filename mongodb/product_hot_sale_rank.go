@@ -37,8 +37,8 @@ func NewProductHotSaleRankDAO(db *mongo.Database) *ProductHotSaleRankDAO {
 }
 
 // Create 创建商品热销排名
-func (dao *ProductHotSaleRankDAO) Create(productHotSaleRank *ProductHotSaleRank) error {
-	_, err := dao.collection.InsertOne(context.TODO(), productHotSaleRank)
+func (dao *ProductHotSaleRankDAO) Create(ctx context.Context, productHotSaleRank *ProductHotSaleRank) error {
+	_, err := dao.collection.InsertOne(ctx, productHotSaleRank)
 	if err != nil {
 		log.Printf("Create product hot sale rank error: %v", err)
 	}
@@ -81,7 +81,7 @@ func (dao *ProductHotSaleRankDAO) GetByID(ctx context.Context, promotionID strin
 }
 
 // Update 更新商品热销排名信息
-func (dao *ProductHotSaleRankDAO) Update(promotionID string, updateData *ProductHotSaleRank) error {
+func (dao *ProductHotSaleRankDAO) Update(ctx context.Context, promotionID string, updateData *ProductHotSaleRank) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal product hot sale rank error: %v", err)
@@ -97,7 +97,7 @@ func (dao *ProductHotSaleRankDAO) Update(promotionID string, updateData *Product
 	filter := bson.M{"promotion_id": promotionID}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update product hot sale rank error: %v", err)
 	}
@@ -105,9 +105,9 @@ func (dao *ProductHotSaleRankDAO) Update(promotionID string, updateData *Product
 }
 
 // Delete 删除商品热销排名
-func (dao *ProductHotSaleRankDAO) Delete(promotionID string) error {
+func (dao *ProductHotSaleRankDAO) Delete(ctx context.Context, promotionID string) error {
 	filter := bson.M{"promotion_id": promotionID}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete product hot sale rank error: %v", err)
 	}
@@ -137,7 +137,7 @@ func (dao *ProductHotSaleRankDAO) ListAll(ctx context.Context, filter bson.M, pa
 		log.Printf("List product hot sale ranks error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	productHotSaleRanks := make([]ProductHotSaleRank, 0)
 	if err = cursor.All(ctx, &productHotSaleRanks); err != nil {
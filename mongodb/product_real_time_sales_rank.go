@@ -39,8 +39,8 @@ func NewProductRealTimeSalesRankDAO(db *mongo.Database) *ProductRealTimeSalesRan
 }
 
 // Create 创建商品实时销量排名
-func (dao *ProductRealTimeSalesRankDAO) Create(productRealTimeSalesRank *ProductRealTimeSalesRank) error {
-	_, err := dao.collection.InsertOne(context.TODO(), productRealTimeSalesRank)
+func (dao *ProductRealTimeSalesRankDAO) Create(ctx context.Context, productRealTimeSalesRank *ProductRealTimeSalesRank) error {
+	_, err := dao.collection.InsertOne(ctx, productRealTimeSalesRank)
 	if err != nil {
 		log.Printf("Create product real time sales rank error: %v", err)
 	}
@@ -83,7 +83,7 @@ func (dao *ProductRealTimeSalesRankDAO) GetByID(ctx context.Context, promotionID
 }
 
 // Update 更新商品实时销量排名信息
-func (dao *ProductRealTimeSalesRankDAO) Update(promotionID string, updateData *ProductRealTimeSalesRank) error {
+func (dao *ProductRealTimeSalesRankDAO) Update(ctx context.Context, promotionID string, updateData *ProductRealTimeSalesRank) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal product real time sales rank error: %v", err)
@@ -99,7 +99,7 @@ func (dao *ProductRealTimeSalesRankDAO) Update(promotionID string, updateData *P
 	filter := bson.M{"promotion_id": promotionID}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update product real time sales rank error: %v", err)
 	}
@@ -107,9 +107,9 @@ func (dao *ProductRealTimeSalesRankDAO) Update(promotionID string, updateData *P
 }
 
 // Delete 删除商品实时销量排名
-func (dao *ProductRealTimeSalesRankDAO) Delete(promotionID string) error {
+func (dao *ProductRealTimeSalesRankDAO) Delete(ctx context.Context, promotionID string) error {
 	filter := bson.M{"promotion_id": promotionID}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete product real time sales rank error: %v", err)
 	}
@@ -139,7 +139,7 @@ func (dao *ProductRealTimeSalesRankDAO) ListAll(ctx context.Context, filter bson
 		log.Printf("List product real time sales ranks error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	productRealTimeSalesRanks := make([]ProductRealTimeSalesRank, 0)
 	if err = cursor.All(ctx, &productRealTimeSalesRanks); err != nil {
@@ -36,8 +36,8 @@ func NewRealTimeHotSpotDAO(db *mongo.Database) *RealTimeHotSpotDAO {
 }
 
 // Create 创建实时热点
-func (dao *RealTimeHotSpotDAO) Create(realTimeHotSpot *RealTimeHotSpot) error {
-	_, err := dao.collection.InsertOne(context.TODO(), realTimeHotSpot)
+func (dao *RealTimeHotSpotDAO) Create(ctx context.Context, realTimeHotSpot *RealTimeHotSpot) error {
+	_, err := dao.collection.InsertOne(ctx, realTimeHotSpot)
 	if err != nil {
 		log.Printf("Create real time hot spot error: %v", err)
 	}
@@ -80,7 +80,7 @@ func (dao *RealTimeHotSpotDAO) GetByID(ctx context.Context, name string, createT
 }
 
 // Update 更新实时热点信息
-func (dao *RealTimeHotSpotDAO) Update(name string, createTime int64, updateData *RealTimeHotSpot) error {
+func (dao *RealTimeHotSpotDAO) Update(ctx context.Context, name string, createTime int64, updateData *RealTimeHotSpot) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal real time hot spot error: %v", err)
@@ -96,7 +96,7 @@ func (dao *RealTimeHotSpotDAO) Update(name string, createTime int64, updateData
 	filter := bson.M{"name": name, "create_time": createTime}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update real time hot spot error: %v", err)
 	}
@@ -104,9 +104,9 @@ func (dao *RealTimeHotSpotDAO) Update(name string, createTime int64, updateData
 }
 
 // Delete 删除实时热点
-func (dao *RealTimeHotSpotDAO) Delete(name string, createTime int64) error {
+func (dao *RealTimeHotSpotDAO) Delete(ctx context.Context, name string, createTime int64) error {
 	filter := bson.M{"name": name, "create_time": createTime}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete real time hot spot error: %v", err)
 	}
@@ -136,7 +136,7 @@ func (dao *RealTimeHotSpotDAO) ListAll(ctx context.Context, filter bson.M, page,
 		log.Printf("List real time hot spots error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	realTimeHotSpots := make([]RealTimeHotSpot, 0)
 	if err = cursor.All(ctx, &realTimeHotSpots); err != nil {
@@ -41,8 +41,8 @@ func NewSalesHourlyRankDAO(db *mongo.Database) *SalesHourlyRankDAO {
 }
 
 // Create 创建带货小时榜
-func (dao *SalesHourlyRankDAO) Create(salesHourlyRank *SalesHourlyRank) error {
-	_, err := dao.collection.InsertOne(context.TODO(), salesHourlyRank)
+func (dao *SalesHourlyRankDAO) Create(ctx context.Context, salesHourlyRank *SalesHourlyRank) error {
+	_, err := dao.collection.InsertOne(ctx, salesHourlyRank)
 	if err != nil {
 		log.Printf("Create sales hourly rank error: %v", err)
 	}
@@ -85,7 +85,7 @@ func (dao *SalesHourlyRankDAO) GetByID(ctx context.Context, roomID string, dateC
 }
 
 // Update 更新带货小时榜信息
-func (dao *SalesHourlyRankDAO) Update(roomID string, dateCode int, updateData *SalesHourlyRank) error {
+func (dao *SalesHourlyRankDAO) Update(ctx context.Context, roomID string, dateCode int, updateData *SalesHourlyRank) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal sales hourly rank error: %v", err)
@@ -101,7 +101,7 @@ func (dao *SalesHourlyRankDAO) Update(roomID string, dateCode int, updateData *S
 	filter := bson.M{"room_id": roomID, "date_code": dateCode}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update sales hourly rank error: %v", err)
 	}
@@ -109,9 +109,9 @@ func (dao *SalesHourlyRankDAO) Update(roomID string, dateCode int, updateData *S
 }
 
 // Delete 删除带货小时榜
-func (dao *SalesHourlyRankDAO) Delete(roomID string, dateCode int) error {
+func (dao *SalesHourlyRankDAO) Delete(ctx context.Context, roomID string, dateCode int) error {
 	filter := bson.M{"room_id": roomID, "date_code": dateCode}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete sales hourly rank error: %v", err)
 	}
@@ -141,7 +141,7 @@ func (dao *SalesHourlyRankDAO) ListAll(ctx context.Context, filter bson.M, page,
 		log.Printf("List sales hourly ranks error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	salesHourlyRanks := make([]SalesHourlyRank, 0)
 	if err = cursor.All(ctx, &salesHourlyRanks); err != nil {
@@ -0,0 +1,75 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+// SchedulerLease 主节点租约，同一时间只有一个实例持有未过期的租约
+type SchedulerLease struct {
+	ID         string    `json:"id" bson:"_id"`                  // 租约名称
+	Holder     string    `json:"holder" bson:"holder"`           // 持有租约的实例
+	Token      int64     `json:"token" bson:"token"`             // 防护令牌，每次易主加一
+	AcquiredAt time.Time `json:"acquired_at" bson:"acquired_at"` // 获得租约的时间
+	ExpiresAt  time.Time `json:"expires_at" bson:"expires_at"`   // 租约过期时间
+}
+
+// SchedulerLeaseDAO 主节点租约数据访问对象
+type SchedulerLeaseDAO struct {
+	collection *mongo.Collection
+}
+
+// NewSchedulerLeaseDAO 创建主节点租约数据访问对象
+func NewSchedulerLeaseDAO(db *mongo.Database) *SchedulerLeaseDAO {
+	return &SchedulerLeaseDAO{
+		collection: db.Collection("scheduler_leases"), // 集合名
+	}
+}
+
+// Acquire 租约不存在或已过期时获得租约并递增防护令牌，租约被其他实例持有时返回 nil
+func (dao *SchedulerLeaseDAO) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*SchedulerLease, error) {
+	now := time.Now()
+	filter := bson.M{"_id": name, "expires_at": bson.M{"$lt": now}}
+	update := bson.M{
+		"$set": bson.M{"holder": holder, "acquired_at": now, "expires_at": now.Add(ttl)},
+		"$inc": bson.M{"token": int64(1)},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	lease := new(SchedulerLease)
+	err := dao.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(lease)
+	if err != nil {
+		// 租约未过期时 upsert 插入同名文档产生主键冲突，表示租约被其他实例持有
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, nil
+		}
+		log.Printf("Acquire scheduler lease error: %v", err)
+		return nil, err
+	}
+	return lease, nil
+}
+
+// Renew 续约，租约已被其他实例接管时返回 false
+func (dao *SchedulerLeaseDAO) Renew(ctx context.Context, name, holder string, token int64, ttl time.Duration) (bool, error) {
+	filter := bson.M{"_id": name, "holder": holder, "token": token}
+	result, err := dao.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"expires_at": time.Now().Add(ttl)}})
+	if err != nil {
+		log.Printf("Renew scheduler lease error: %v", err)
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Release 释放租约，使备用实例可以立即接管
+func (dao *SchedulerLeaseDAO) Release(ctx context.Context, name, holder string, token int64) error {
+	filter := bson.M{"_id": name, "holder": holder, "token": token}
+	_, err := dao.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"expires_at": time.Time{}}})
+	if err != nil {
+		log.Printf("Release scheduler lease error: %v", err)
+	}
+	return err
+}
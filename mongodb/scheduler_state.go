@@ -0,0 +1,75 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+// SchedulerState 定时任务运行状态，用于重启后恢复执行计划
+type SchedulerState struct {
+	ID        string    `json:"id" bson:"_id"`                // 定时任务ID
+	Schedule  string    `json:"schedule" bson:"schedule"`     // 保存状态时的执行频率
+	LastRun   time.Time `json:"last_run" bson:"last_run"`     // 上次执行时间
+	NextRun   time.Time `json:"next_run" bson:"next_run"`     // 下次执行时间
+	Paused    bool      `json:"paused" bson:"paused"`         // 是否手动暂停
+	Token     int64     `json:"token" bson:"token"`           // 保存状态时主节点租约的防护令牌，单实例运行时为0
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"` // 更新时间
+}
+
+// ErrStaleToken 状态已被持有更新防护令牌的主节点保存，当前实例已失去主节点身份
+var ErrStaleToken = errors.New("防护令牌已过期")
+
+// SchedulerStateDAO 定时任务状态数据访问对象
+type SchedulerStateDAO struct {
+	collection *mongo.Collection
+}
+
+// NewSchedulerStateDAO 创建定时任务状态数据访问对象
+func NewSchedulerStateDAO(db *mongo.Database) *SchedulerStateDAO {
+	return &SchedulerStateDAO{
+		collection: db.Collection("scheduler_states"), // 集合名
+	}
+}
+
+// Save 保存任务状态，不存在时创建。
+// 防护令牌不为0时只覆盖令牌不大于当前令牌的状态，已被新主节点保存过的状态返回 ErrStaleToken。
+func (dao *SchedulerStateDAO) Save(ctx context.Context, state *SchedulerState) error {
+	state.UpdatedAt = time.Now()
+	filter := bson.M{"_id": state.ID}
+	if state.Token > 0 {
+		filter["$or"] = bson.A{
+			bson.M{"token": bson.M{"$lte": state.Token}},
+			bson.M{"token": bson.M{"$exists": false}},
+		}
+	}
+	_, err := dao.collection.ReplaceOne(ctx, filter, state, options.Replace().SetUpsert(true))
+	if err != nil {
+		// 令牌条件不满足时 upsert 插入同名文档产生主键冲突
+		if state.Token > 0 && mongo.IsDuplicateKeyError(err) {
+			return ErrStaleToken
+		}
+		log.Printf("Save scheduler state error: %v", err)
+	}
+	return err
+}
+
+// List 获取所有任务状态
+func (dao *SchedulerStateDAO) List(ctx context.Context) ([]*SchedulerState, error) {
+	cursor, err := dao.collection.Find(ctx, bson.M{})
+	if err != nil {
+		log.Printf("List scheduler states error: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	states := make([]*SchedulerState, 0)
+	if err = cursor.All(ctx, &states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
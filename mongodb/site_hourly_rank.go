@@ -43,8 +43,8 @@ func NewSiteHourlyRankDAO(db *mongo.Database) *SiteHourlyRankDAO {
 }
 
 // Create 创建全站小时榜
-func (dao *SiteHourlyRankDAO) Create(siteHourlyRank *SiteHourlyRank) error {
-	_, err := dao.collection.InsertOne(context.TODO(), siteHourlyRank)
+func (dao *SiteHourlyRankDAO) Create(ctx context.Context, siteHourlyRank *SiteHourlyRank) error {
+	_, err := dao.collection.InsertOne(ctx, siteHourlyRank)
 	if err != nil {
 		log.Printf("Create site hourly rank error: %v", err)
 	}
@@ -87,7 +87,7 @@ func (dao *SiteHourlyRankDAO) GetByID(ctx context.Context, roomID string, dateCo
 }
 
 // Update 更新全站小时榜信息
-func (dao *SiteHourlyRankDAO) Update(roomID string, dateCode int, updateData *SiteHourlyRank) error {
+func (dao *SiteHourlyRankDAO) Update(ctx context.Context, roomID string, dateCode int, updateData *SiteHourlyRank) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal site hourly rank error: %v", err)
@@ -103,7 +103,7 @@ func (dao *SiteHourlyRankDAO) Update(roomID string, dateCode int, updateData *Si
 	filter := bson.M{"room_id": roomID, "date_code": dateCode}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update site hourly rank error: %v", err)
 	}
@@ -111,9 +111,9 @@ func (dao *SiteHourlyRankDAO) Update(roomID string, dateCode int, updateData *Si
 }
 
 // Delete 删除全站小时榜
-func (dao *SiteHourlyRankDAO) Delete(roomID string, dateCode int) error {
+func (dao *SiteHourlyRankDAO) Delete(ctx context.Context, roomID string, dateCode int) error {
 	filter := bson.M{"room_id": roomID, "date_code": dateCode}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete site hourly rank error: %v", err)
 	}
@@ -143,7 +143,7 @@ func (dao *SiteHourlyRankDAO) ListAll(ctx context.Context, filter bson.M, page,
 		log.Printf("List site hourly ranks error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	siteHourlyRanks := make([]SiteHourlyRank, 0)
 	if err = cursor.All(ctx, &siteHourlyRanks); err != nil {
@@ -31,8 +31,8 @@ func NewSoaringHotSpotDAO(db *mongo.Database) *SoaringHotSpotDAO {
 }
 
 // Create 创建飙升热点
-func (dao *SoaringHotSpotDAO) Create(soaringHotSpot *SoaringHotSpot) error {
-	_, err := dao.collection.InsertOne(context.TODO(), soaringHotSpot)
+func (dao *SoaringHotSpotDAO) Create(ctx context.Context, soaringHotSpot *SoaringHotSpot) error {
+	_, err := dao.collection.InsertOne(ctx, soaringHotSpot)
 	if err != nil {
 		log.Printf("Create soaring hot spot error: %v", err)
 	}
@@ -75,7 +75,7 @@ func (dao *SoaringHotSpotDAO) GetByID(ctx context.Context, name string, createTi
 }
 
 // Update 更新飙升热点信息
-func (dao *SoaringHotSpotDAO) Update(name string, createTime int64, updateData *SoaringHotSpot) error {
+func (dao *SoaringHotSpotDAO) Update(ctx context.Context, name string, createTime int64, updateData *SoaringHotSpot) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal soaring hot spot error: %v", err)
@@ -91,7 +91,7 @@ func (dao *SoaringHotSpotDAO) Update(name string, createTime int64, updateData *
 	filter := bson.M{"name": name, "create_time": createTime}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update soaring hot spot error: %v", err)
 	}
@@ -99,9 +99,9 @@ func (dao *SoaringHotSpotDAO) Update(name string, createTime int64, updateData *
 }
 
 // Delete 删除飙升热点
-func (dao *SoaringHotSpotDAO) Delete(name string, createTime int64) error {
+func (dao *SoaringHotSpotDAO) Delete(ctx context.Context, name string, createTime int64) error {
 	filter := bson.M{"name": name, "create_time": createTime}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete soaring hot spot error: %v", err)
 	}
@@ -131,7 +131,7 @@ func (dao *SoaringHotSpotDAO) ListAll(ctx context.Context, filter bson.M, page,
 		log.Printf("List soaring hot spots error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	soaringHotSpots := make([]SoaringHotSpot, 0)
 	if err = cursor.All(ctx, &soaringHotSpots); err != nil {
@@ -95,7 +95,7 @@ func (dao *StoreDAO) ListAll(ctx context.Context, filter bson.M, page, limit int
 		log.Printf("List authors error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	stores := make([]Store, 0)
 	if err = cursor.All(ctx, &stores); err != nil {
@@ -0,0 +1,198 @@
+package mongodb
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+// 持久化任务状态
+const (
+	QueuedTaskQueued  = "queued"  // 排队中
+	QueuedTaskRunning = "running" // 执行中
+)
+
+// QueuedTask 持久化的请求任务，处理器按名称保存，恢复时重新查找
+type QueuedTask struct {
+	ID         string                 `json:"id" bson:"_id"`
+	Handler    string                 `json:"handler" bson:"handler"`         // 处理器名称
+	Endpoint   string                 `json:"endpoint" bson:"endpoint"`       // 所属接口
+	URL        string                 `json:"url" bson:"url"`                 // 请求地址
+	Method     string                 `json:"method" bson:"method"`           // 请求方法
+	Headers    map[string]string      `json:"headers" bson:"headers"`         // 请求头
+	Body       []byte                 `json:"body" bson:"body"`               // 请求体
+	Priority   string                 `json:"priority" bson:"priority"`       // 优先级
+	Meta       map[string]interface{} `json:"meta" bson:"meta"`               // 任务 meta
+	DedupTTL   time.Duration          `json:"dedup_ttl" bson:"dedup_ttl"`     // 去重时间，最终失败时据此清除去重记录
+	Retry      *QueuedRetry           `json:"retry" bson:"retry,omitempty"`   // 接口目录中配置的重试策略，为空时使用默认策略
+	Status     string                 `json:"status" bson:"status"`           // queued 或 running
+	Instance   string                 `json:"instance" bson:"instance"`       // 持有租约的实例标识
+	Owner      string                 `json:"owner" bson:"owner"`             // 持有租约的进程，由实例标识和每次启动生成的ID组成
+	LeaseUntil time.Time              `json:"lease_until" bson:"lease_until"` // 租约到期时间，到期后其他实例可以接管
+	Attempts   int                    `json:"attempts" bson:"attempts"`       // 开始执行的次数
+	EnqueuedAt time.Time              `json:"enqueued_at" bson:"enqueued_at"` // 入队时间
+	StartedAt  time.Time              `json:"started_at" bson:"started_at"`   // 最近一次开始执行的时间
+	Batch      string                 `json:"batch" bson:"batch"`             // 最近一次接管的批次
+}
+
+// QueuedRetry 持久化任务的重试策略，未保存的字段表示未填写，使用默认策略
+type QueuedRetry struct {
+	MaxAttempts  *int           `json:"max_attempts,omitempty" bson:"max_attempts,omitempty"`
+	InitialDelay *time.Duration `json:"initial_delay,omitempty" bson:"initial_delay,omitempty"`
+	MaxDelay     *time.Duration `json:"max_delay,omitempty" bson:"max_delay,omitempty"`
+	Jitter       *float64       `json:"jitter,omitempty" bson:"jitter,omitempty"`
+	MaxElapsed   *time.Duration `json:"max_elapsed,omitempty" bson:"max_elapsed,omitempty"`
+}
+
+// TaskQueueDAO 持久化任务队列数据访问对象
+type TaskQueueDAO struct {
+	collection *mongo.Collection
+}
+
+// NewTaskQueueDAO 创建持久化任务队列数据访问对象
+func NewTaskQueueDAO(db *mongo.Database) *TaskQueueDAO {
+	return &TaskQueueDAO{
+		collection: db.Collection("task_queue"), // 集合名
+	}
+}
+
+// EnsureIndexes 创建续约和接管使用的索引
+func (dao *TaskQueueDAO) EnsureIndexes(ctx context.Context) error {
+	_, err := dao.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner", Value: 1}}},
+		{Keys: bson.D{{Key: "instance", Value: 1}}},
+		{Keys: bson.D{{Key: "lease_until", Value: 1}, {Key: "enqueued_at", Value: 1}}},
+		{Keys: bson.D{{Key: "batch", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("Create task queue indexes error: %v", err)
+	}
+	return err
+}
+
+// Insert 保存新任务，任务由 owner 持有到 leaseUntil
+func (dao *TaskQueueDAO) Insert(ctx context.Context, task *QueuedTask) error {
+	task.Status = QueuedTaskQueued
+	task.EnqueuedAt = time.Now()
+	_, err := dao.collection.InsertOne(ctx, task)
+	if err != nil {
+		log.Printf("Insert queued task error: %v", err)
+	}
+	return err
+}
+
+// Start 标记任务开始执行并递增执行次数，任务已完成或已被其他实例接管时返回 false
+func (dao *TaskQueueDAO) Start(ctx context.Context, id, owner string, leaseUntil time.Time) (bool, error) {
+	filter := bson.M{"_id": id, "owner": owner}
+	update := bson.M{
+		"$set": bson.M{"status": QueuedTaskRunning, "started_at": time.Now(), "lease_until": leaseUntil},
+		"$inc": bson.M{"attempts": 1},
+	}
+	result, err := dao.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Printf("Start queued task error: %v", err)
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Complete 删除执行结束的任务
+func (dao *TaskQueueDAO) Complete(ctx context.Context, id, owner string) error {
+	_, err := dao.collection.DeleteOne(ctx, bson.M{"_id": id, "owner": owner})
+	if err != nil {
+		log.Printf("Complete queued task error: %v", err)
+	}
+	return err
+}
+
+// Renew 延长实例持有的所有任务的租约
+func (dao *TaskQueueDAO) Renew(ctx context.Context, owner string, leaseUntil time.Time) error {
+	_, err := dao.collection.UpdateMany(ctx, bson.M{"owner": owner}, bson.M{"$set": bson.M{"lease_until": leaseUntil}})
+	if err != nil {
+		log.Printf("Renew queued tasks error: %v", err)
+	}
+	return err
+}
+
+// Release 释放进程持有的所有任务，其他实例或重启后的进程可以立即接管
+func (dao *TaskQueueDAO) Release(ctx context.Context, owner string) error {
+	_, err := dao.collection.UpdateMany(ctx, bson.M{"owner": owner}, bson.M{"$set": bson.M{"lease_until": time.Time{}}})
+	if err != nil {
+		log.Printf("Release queued tasks error: %v", err)
+	}
+	return err
+}
+
+// ReleaseInstance 释放同一实例标识下之前的进程遗留的任务，不影响当前进程 owner 持有的任务；
+// 没有 instance 字段的旧任务按 owner 等于实例标识匹配
+func (dao *TaskQueueDAO) ReleaseInstance(ctx context.Context, instance, owner string) error {
+	filter := bson.M{
+		"$or":   bson.A{bson.M{"instance": instance}, bson.M{"owner": instance}},
+		"owner": bson.M{"$ne": owner},
+	}
+	_, err := dao.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"lease_until": time.Time{}}})
+	if err != nil {
+		log.Printf("Release instance queued tasks error: %v", err)
+	}
+	return err
+}
+
+// Claim 按入队顺序接管最多 limit 个租约已过期的任务，接管后状态重置为排队中
+func (dao *TaskQueueDAO) Claim(ctx context.Context, instance, owner string, leaseUntil time.Time, limit int) ([]*QueuedTask, error) {
+	now := time.Now()
+	opts := options.Find().
+		SetSort(bson.D{{Key: "enqueued_at", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"_id": 1})
+	cursor, err := dao.collection.Find(ctx, bson.M{"lease_until": bson.M{"$lt": now}}, opts)
+	if err != nil {
+		log.Printf("Find expired queued tasks error: %v", err)
+		return nil, err
+	}
+	var expired []struct {
+		ID string `bson:"_id"`
+	}
+	if err = cursor.All(ctx, &expired); err != nil {
+		return nil, err
+	}
+	if len(expired) == 0 {
+		return nil, nil
+	}
+	ids := make(bson.A, len(expired))
+	for i, task := range expired {
+		ids[i] = task.ID
+	}
+
+	// 多个实例同时接管时，只有租约仍然过期的任务会被本批次更新
+	batch := primitive.NewObjectID().Hex()
+	filter := bson.M{"_id": bson.M{"$in": ids}, "lease_until": bson.M{"$lt": now}}
+	update := bson.M{"$set": bson.M{"instance": instance, "owner": owner, "lease_until": leaseUntil, "status": QueuedTaskQueued, "batch": batch}}
+	if _, err = dao.collection.UpdateMany(ctx, filter, update); err != nil {
+		log.Printf("Claim queued tasks error: %v", err)
+		return nil, err
+	}
+
+	cursor, err = dao.collection.Find(ctx, bson.M{"batch": batch}, options.Find().SetSort(bson.D{{Key: "enqueued_at", Value: 1}}))
+	if err != nil {
+		log.Printf("Find claimed tasks error: %v", err)
+		return nil, err
+	}
+	tasks := make([]*QueuedTask, 0, len(expired))
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// Delete 删除无法恢复的任务
+func (dao *TaskQueueDAO) Delete(ctx context.Context, id string) error {
+	_, err := dao.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		log.Printf("Delete queued task error: %v", err)
+	}
+	return err
+}
@@ -38,8 +38,8 @@ func NewVideoHotPushDAO(db *mongo.Database) *VideoHotPushDAO {
 }
 
 // Create 创建视频热推
-func (dao *VideoHotPushDAO) Create(videoHotPush *VideoHotPush) error {
-	_, err := dao.collection.InsertOne(context.TODO(), videoHotPush)
+func (dao *VideoHotPushDAO) Create(ctx context.Context, videoHotPush *VideoHotPush) error {
+	_, err := dao.collection.InsertOne(ctx, videoHotPush)
 	if err != nil {
 		log.Printf("Create video hot push error: %v", err)
 	}
@@ -82,7 +82,7 @@ func (dao *VideoHotPushDAO) GetByID(ctx context.Context, productID string) (*Vid
 }
 
 // Update 更新视频热推信息
-func (dao *VideoHotPushDAO) Update(productID string, updateData *VideoHotPush) error {
+func (dao *VideoHotPushDAO) Update(ctx context.Context, productID string, updateData *VideoHotPush) error {
 	bsonData, err := bson.Marshal(updateData)
 	if err != nil {
 		log.Printf("Marshal video hot push error: %v", err)
@@ -98,7 +98,7 @@ func (dao *VideoHotPushDAO) Update(productID string, updateData *VideoHotPush) e
 	filter := bson.M{"product_id": productID}
 	update := bson.M{"$set": updateDoc}
 
-	_, err = dao.collection.UpdateOne(context.TODO(), filter, update)
+	_, err = dao.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		log.Printf("Update video hot push error: %v", err)
 	}
@@ -106,9 +106,9 @@ func (dao *VideoHotPushDAO) Update(productID string, updateData *VideoHotPush) e
 }
 
 // Delete 删除视频热推
-func (dao *VideoHotPushDAO) Delete(productID string) error {
+func (dao *VideoHotPushDAO) Delete(ctx context.Context, productID string) error {
 	filter := bson.M{"product_id": productID}
-	_, err := dao.collection.DeleteOne(context.TODO(), filter)
+	_, err := dao.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		log.Printf("Delete video hot push error: %v", err)
 	}
@@ -138,7 +138,7 @@ func (dao *VideoHotPushDAO) ListAll(ctx context.Context, filter bson.M, page, li
 		log.Printf("List video hot pushes error: %v", err)
 		return result, err
 	}
-	defer cursor.Close(context.TODO())
+	defer cursor.Close(ctx)
 
 	videoHotPushes := make([]VideoHotPush, 0)
 	if err = cursor.All(ctx, &videoHotPushes); err != nil {
@@ -0,0 +1,372 @@
+// Package schedule 解析定时任务的执行计划，支持 cron 表达式、固定间隔和预定义关键字
+package schedule
+
+import (
+	"collyDemo/pkg/utils"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Off 表示停用任务，不是有效的执行计划，由调用方单独处理
+const Off = "off"
+
+// Schedule 执行计划
+type Schedule interface {
+	// Next 返回 from 之后的下一次执行时间，没有可执行时间时返回零值
+	Next(from time.Time) time.Time
+}
+
+// Parse 解析执行计划，支持：
+//
+//	cron 表达式   5段 "分 时 日 月 周" 或 6段 "秒 分 时 日 月 周"，如 "5 * * * *"
+//	时区前缀      "CRON_TZ=Asia/Shanghai 0 8 * * *"，未指定时使用 Asia/Shanghai
+//	关键字        hourly、daily、weekly、monthly（可带 @ 前缀），按整点、零点、周日零点、每月1日零点对齐
+//	固定间隔      Go 时间间隔，支持 d 表示天，如 "30m"、"2h"、"1d"、"@every 1d12h"，从上次执行时间起算
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, errors.New("执行频率不能为空")
+	}
+	if spec == Off {
+		return nil, errors.New("off 表示停用任务，不是执行计划")
+	}
+
+	loc := utils.ShanghaiLocation()
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		prefix, rest, _ := strings.Cut(spec, " ")
+		name := prefix[strings.Index(prefix, "=")+1:]
+		parsed, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("无效的时区 %q: %v", name, err)
+		}
+		loc = parsed
+		spec = strings.TrimSpace(rest)
+	}
+
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		return parseInterval(strings.TrimSpace(interval))
+	}
+	switch strings.TrimPrefix(spec, "@") {
+	case "hourly":
+		spec = "0 * * * *"
+	case "daily", "midnight":
+		spec = "0 0 * * *"
+	case "weekly":
+		spec = "0 0 * * 0"
+	case "monthly":
+		spec = "0 0 1 * *"
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 1:
+		return parseInterval(spec)
+	case 5:
+		return parseCron(append([]string{"0"}, fields...), spec, loc)
+	case 6:
+		return parseCron(fields, spec, loc)
+	default:
+		return nil, fmt.Errorf("无法解析执行频率 %q: cron 表达式需要5段或6段", spec)
+	}
+}
+
+// Interval 固定间隔执行
+type Interval time.Duration
+
+// Next 返回 from 加上间隔
+func (i Interval) Next(from time.Time) time.Time {
+	return from.Add(time.Duration(i))
+}
+
+var dayPattern = regexp.MustCompile(`^(\d+)d(.*)$`)
+
+// parseInterval 解析时间间隔，在 time.ParseDuration 的基础上支持 d（天）
+func parseInterval(spec string) (Schedule, error) {
+	var total time.Duration
+	rest := spec
+	if match := dayPattern.FindStringSubmatch(spec); match != nil {
+		days, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("无法解析执行频率 %q", spec)
+		}
+		total = time.Duration(days) * 24 * time.Hour
+		rest = match[2]
+	}
+	if rest != "" {
+		duration, err := time.ParseDuration(rest)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析执行频率 %q", spec)
+		}
+		total += duration
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("执行频率必须大于0: %q", spec)
+	}
+	return Interval(total), nil
+}
+
+// Cron cron 表达式执行计划，按所在时区的挂钟时间对齐
+type Cron struct {
+	second, minute, hour, dom, month, dow uint64
+	domStar, dowStar                      bool
+	loc                                   *time.Location
+}
+
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+// all 字段取值范围内所有值的位集合
+func (b bounds) all() uint64 {
+	var bits uint64
+	for v := b.min; v <= b.max; v++ {
+		bits |= 1 << v
+	}
+	return bits
+}
+
+var (
+	secondBounds = bounds{0, 59, nil}
+	minuteBounds = bounds{0, 59, nil}
+	hourBounds   = bounds{0, 23, nil}
+	domBounds    = bounds{1, 31, nil}
+	monthBounds  = bounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = bounds{0, 7, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// parseCron 解析6段 cron 表达式，spec 为原始表达式，用于错误信息
+func parseCron(fields []string, spec string, loc *time.Location) (Schedule, error) {
+	c := &Cron{loc: loc}
+	var err error
+	parse := func(name, field string, b bounds, target *uint64) {
+		if err != nil {
+			return
+		}
+		if *target, err = parseField(field, b); err != nil {
+			err = fmt.Errorf("%s字段 %q: %w", name, field, err)
+		}
+	}
+	parse("秒", fields[0], secondBounds, &c.second)
+	parse("分", fields[1], minuteBounds, &c.minute)
+	parse("时", fields[2], hourBounds, &c.hour)
+	parse("日", fields[3], domBounds, &c.dom)
+	parse("月", fields[4], monthBounds, &c.month)
+	parse("周", fields[5], dowBounds, &c.dow)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析 cron 表达式 %q: %w", spec, err)
+	}
+
+	// 周日可写作 0 或 7
+	if c.dow&(1<<7) != 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+	c.domStar = isWildcard(fields[3])
+	c.dowStar = isWildcard(fields[5])
+	if c.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("cron 表达式 %q 永远不会触发", spec)
+	}
+	return c, nil
+}
+
+func isWildcard(field string) bool {
+	return field == "*" || field == "?"
+}
+
+// parseField 解析单个字段，支持 *、?、列表 a,b、范围 a-b 和步长 */n、a-b/n、a/n
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := uint(1)
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("无效的步长 %q", stepPart)
+			}
+			step = uint(n)
+		}
+
+		var start, end uint
+		switch {
+		case isWildcard(rangePart):
+			start, end = b.min, b.max
+		case strings.Contains(rangePart, "-"):
+			lo, hi, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = parseValue(lo, b); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(hi, b); err != nil {
+				return 0, err
+			}
+		default:
+			value, err := parseValue(rangePart, b)
+			if err != nil {
+				return 0, err
+			}
+			start, end = value, value
+			if hasStep {
+				end = b.max
+			}
+		}
+		if start > end {
+			return 0, fmt.Errorf("范围起点 %d 大于终点 %d", start, end)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(value string, b bounds) (uint, error) {
+	if n, ok := b.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("无效的值 %q", value)
+	}
+	if n < int(b.min) || n > int(b.max) {
+		return 0, fmt.Errorf("值 %d 超出范围 %d-%d", n, b.min, b.max)
+	}
+	return uint(n), nil
+}
+
+// Next 返回 from 之后第一个满足表达式的时间，五年内没有匹配时返回零值。
+// 夏令时开始时不存在的时刻不触发；夏令时结束时重复的挂钟时间只触发一次，小时字段为 * 的表达式除外
+func (c *Cron) Next(from time.Time) time.Time {
+	origin := from.Location()
+	t := from.In(c.loc).Truncate(time.Second).Add(time.Second)
+	yearLimit := t.Year() + 5
+
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for c.month&(1<<uint(t.Month())) == 0 {
+		t = c.at(t.Year(), t.Month()+1, 1, 0)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+	for !c.dayMatches(t) {
+		t = c.at(t.Year(), t.Month(), t.Day()+1, 0)
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+	for c.hour&(1<<uint(t.Hour())) == 0 {
+		t = c.at(t.Year(), t.Month(), t.Day(), t.Hour()+1)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+	for c.minute&(1<<uint(t.Minute())) == 0 {
+		t = t.Truncate(time.Minute).Add(time.Minute)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+	for c.second&(1<<uint(t.Second())) == 0 {
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto WRAP
+		}
+	}
+	if end := repeatedUntil(t); !end.IsZero() && c.hour != hourBounds.all() {
+		t = end
+		goto WRAP
+	}
+	return t.In(origin)
+}
+
+// at 返回所在时区中指定日期和小时的整点，该时刻因夏令时开始而不存在时返回变更后的第一个时刻。
+// time.Date 会把不存在的时刻规范化到变更前的时区，得到更早的时间，直接使用会反复回到同一小时
+func (c *Cron) at(year int, month time.Month, day, hour int) time.Time {
+	t := time.Date(year, month, day, hour, 0, 0, 0, c.loc)
+	want := time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	if wallClock(t).Before(want) {
+		if _, end := t.ZoneBounds(); !end.IsZero() {
+			return end
+		}
+	}
+	return t
+}
+
+// repeatedUntil t 的挂钟时间在夏令时结束时第二次出现时，返回重复时段的结束时间，否则返回零值
+func repeatedUntil(t time.Time) time.Time {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return time.Time{}
+	}
+	_, before := start.Add(-time.Second).Zone()
+	_, offset := t.Zone()
+	if before <= offset {
+		return time.Time{}
+	}
+	end := start.Add(time.Duration(before-offset) * time.Second)
+	if t.Before(end) {
+		return end
+	}
+	return time.Time{}
+}
+
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// dayMatches 日和周同时受限时满足其一即可，与标准 cron 一致
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// 错过执行时间（如进程重启期间）的处理策略
+const (
+	MisfireRunOnce = "run_once" // 立即补跑一次，默认策略
+	MisfireRunAll  = "run_all"  // 逐次补跑所有错过的执行
+	MisfireSkip    = "skip"     // 不补跑，等待下一个执行时间
+)
+
+// ValidateMisfire 校验错过执行的处理策略，空值表示使用默认策略
+func ValidateMisfire(policy string) error {
+	switch policy {
+	case "", MisfireRunOnce, MisfireRunAll, MisfireSkip:
+		return nil
+	default:
+		return fmt.Errorf("misfire 只支持 %s、%s 或 %s，当前为 %q", MisfireRunOnce, MisfireRunAll, MisfireSkip, policy)
+	}
+}
+
+// 上一次执行尚未结束时再次到期的处理策略
+const (
+	OverlapForbid  = "forbid"  // 跳过本次执行，默认策略
+	OverlapQueue   = "queue"   // 排队一次，上一次结束后立即执行，多次到期只保留一次
+	OverlapReplace = "replace" // 取消上一次执行，立即开始本次执行
+)
+
+// ValidateOverlap 校验重叠执行的处理策略，空值表示使用默认策略
+func ValidateOverlap(policy string) error {
+	switch policy {
+	case "", OverlapForbid, OverlapQueue, OverlapReplace:
+		return nil
+	default:
+		return fmt.Errorf("overlap 只支持 %s、%s 或 %s，当前为 %q", OverlapForbid, OverlapQueue, OverlapReplace, policy)
+	}
+}
@@ -0,0 +1,257 @@
+package schedule
+
+import (
+	"collyDemo/pkg/utils"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("加载时区 %s 失败: %v", name, err)
+	}
+	return loc
+}
+
+// next 调用 Next 并在超时后失败，避免 Next 死循环时测试一直挂起
+func next(t *testing.T, plan Schedule, from time.Time) time.Time {
+	t.Helper()
+	result := make(chan time.Time, 1)
+	go func() { result <- plan.Next(from) }()
+	select {
+	case n := <-result:
+		return n
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Next(%s) 未在 2s 内返回", from)
+		return time.Time{}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string // 错误信息中应包含的内容
+	}{
+		{"", "不能为空"},
+		{"off", "停用任务"},
+		{"60 * * * *", "分字段"},
+		{"* 24 * * *", "时字段"},
+		{"* * 0 * *", "日字段"},
+		{"* * 32 * *", "日字段"},
+		{"* * * 0 *", "月字段"},
+		{"* * * 13 *", "月字段"},
+		{"* * * * 8", "周字段"},
+		{"60 * * * * *", "秒字段"},
+		{"*/0 * * * *", "步长"},
+		{"5-1 * * * *", "起点"},
+		{"a * * * *", "无效的值"},
+		{"0 0 30 2 *", "永远不会触发"},
+		{"1 2 3 4", "5段或6段"},
+		{"CRON_TZ=Nowhere/City 0 * * * *", "无效的时区"},
+		{"0s", "必须大于0"},
+		{"abc", "无法解析"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := Parse(tt.spec)
+			if err == nil {
+				t.Fatalf("Parse(%q) 应返回错误", tt.spec)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) 错误为 %q，应包含 %q", tt.spec, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFieldBounds(t *testing.T) {
+	tests := []struct {
+		field string
+		b     bounds
+		want  []uint
+	}{
+		{"0", minuteBounds, []uint{0}},
+		{"59", minuteBounds, []uint{59}},
+		{"23", hourBounds, []uint{23}},
+		{"1,31", domBounds, []uint{1, 31}},
+		{"jan,DEC", monthBounds, []uint{1, 12}},
+		{"mon-fri", dowBounds, []uint{1, 2, 3, 4, 5}},
+		{"*/15", minuteBounds, []uint{0, 15, 30, 45}},
+		{"10-20/5", minuteBounds, []uint{10, 15, 20}},
+		{"50/4", minuteBounds, []uint{50, 54, 58}},
+		{"*/10", domBounds, []uint{1, 11, 21, 31}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got, err := parseField(tt.field, tt.b)
+			if err != nil {
+				t.Fatalf("parseField(%q) 返回错误: %v", tt.field, err)
+			}
+			var want uint64
+			for _, v := range tt.want {
+				want |= 1 << v
+			}
+			if got != want {
+				t.Errorf("parseField(%q) = %b，期望 %b", tt.field, got, want)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	sh := utils.ShanghaiLocation()
+	at := func(year int, month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, sh)
+	}
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"5 * * * *", at(2026, 10, 16, 10, 0, 0), at(2026, 10, 16, 10, 5, 0)},
+		{"5 * * * *", at(2026, 10, 16, 10, 5, 0), at(2026, 10, 16, 11, 5, 0)},
+		{"5 * * * *", at(2026, 10, 16, 10, 4, 59), at(2026, 10, 16, 10, 5, 0)},
+		{"*/20 * * * * *", at(2026, 10, 16, 10, 0, 5), at(2026, 10, 16, 10, 0, 20)},
+		{"0 8 * * 1-5", at(2026, 10, 16, 9, 0, 0), at(2026, 10, 19, 8, 0, 0)},
+		{"0 0 * * 7", at(2026, 10, 16, 0, 0, 0), at(2026, 10, 18, 0, 0, 0)},
+		{"0 0 31 * *", at(2026, 2, 1, 0, 0, 0), at(2026, 3, 31, 0, 0, 0)},
+		{"0 0 29 2 *", at(2026, 3, 1, 0, 0, 0), at(2028, 2, 29, 0, 0, 0)},
+		{"59 23 31 12 *", at(2026, 12, 31, 23, 59, 0), at(2027, 12, 31, 23, 59, 0)},
+		// 日和周同时受限时满足其一即可
+		{"0 0 15 * 0", at(2026, 10, 2, 0, 0, 0), at(2026, 10, 4, 0, 0, 0)},
+		{"0 0 15 * 0", at(2026, 10, 11, 0, 0, 0), at(2026, 10, 15, 0, 0, 0)},
+		{"@hourly", at(2026, 10, 16, 10, 30, 0), at(2026, 10, 16, 11, 0, 0)},
+		{"daily", at(2026, 10, 16, 10, 30, 0), at(2026, 10, 17, 0, 0, 0)},
+		{"@weekly", at(2026, 10, 16, 10, 30, 0), at(2026, 10, 18, 0, 0, 0)},
+		{"@monthly", at(2026, 10, 16, 10, 30, 0), at(2026, 11, 1, 0, 0, 0)},
+		{"30m", at(2026, 10, 16, 10, 0, 7), at(2026, 10, 16, 10, 30, 7)},
+		{"1d12h", at(2026, 10, 16, 10, 0, 0), at(2026, 10, 17, 22, 0, 0)},
+		{"@every 90m", at(2026, 10, 16, 10, 0, 0), at(2026, 10, 16, 11, 30, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec+"/"+tt.from.Format(time.DateTime), func(t *testing.T) {
+			plan, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q) 返回错误: %v", tt.spec, err)
+			}
+			if got := next(t, plan, tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s，期望 %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextKeepsCallerLocation(t *testing.T) {
+	plan, err := Parse("0 8 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	got := next(t, plan, from)
+	if got.Location() != time.UTC {
+		t.Errorf("Next 返回的时区为 %s，期望与参数相同", got.Location())
+	}
+	// 默认按 Asia/Shanghai 对齐，08:00 CST 为 00:00 UTC
+	if want := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next(%s) = %s，期望 %s", from, got, want)
+	}
+}
+
+// TestCronNextDST Asia/Shanghai 没有夏令时，默认时区的任务在其他时区切换夏令时前后仍每24小时触发一次；
+// 指定有夏令时的时区时，不存在的时刻不触发，重复的挂钟时间只触发一次
+func TestCronNextDST(t *testing.T) {
+	ny := loadLocation(t, "America/New_York")
+	santiago := loadLocation(t, "America/Santiago")
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "默认时区不受纽约夏令时开始影响",
+			spec: "30 2 * * *",
+			from: time.Date(2026, 3, 7, 12, 0, 0, 0, ny),
+			want: time.Date(2026, 3, 7, 13, 30, 0, 0, ny),
+		},
+		{
+			name: "默认时区在纽约夏令时开始后仍为北京时间02:30",
+			spec: "30 2 * * *",
+			from: time.Date(2026, 3, 8, 12, 0, 0, 0, ny),
+			want: time.Date(2026, 3, 8, 14, 30, 0, 0, ny),
+		},
+		{
+			name: "夏令时开始时不存在的时刻跳过当天",
+			spec: "CRON_TZ=America/New_York 30 2 * * *",
+			from: time.Date(2026, 3, 7, 12, 0, 0, 0, ny),
+			want: time.Date(2026, 3, 9, 2, 30, 0, 0, ny),
+		},
+		{
+			name: "夏令时开始时每小时任务跳过不存在的小时",
+			spec: "CRON_TZ=America/New_York 0 * * * *",
+			from: time.Date(2026, 3, 8, 1, 30, 0, 0, ny),
+			want: time.Date(2026, 3, 8, 3, 0, 0, 0, ny),
+		},
+		{
+			name: "夏令时在零点开始时不会死循环",
+			spec: "CRON_TZ=America/Santiago 0 0 * * *",
+			from: time.Date(2026, 9, 5, 12, 0, 0, 0, santiago),
+			want: time.Date(2026, 9, 7, 0, 0, 0, 0, santiago),
+		},
+		{
+			name: "夏令时结束时第一次出现的挂钟时间触发",
+			spec: "CRON_TZ=America/New_York 30 1 * * *",
+			from: time.Date(2026, 11, 1, 0, 0, 0, 0, ny),
+			want: time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "夏令时结束时重复的挂钟时间不再触发",
+			spec: "CRON_TZ=America/New_York 30 1 * * *",
+			from: time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC),
+			want: time.Date(2026, 11, 2, 1, 30, 0, 0, ny),
+		},
+		{
+			name: "夏令时结束时每小时任务在重复的小时仍触发",
+			spec: "CRON_TZ=America/New_York 0 * * * *",
+			from: time.Date(2026, 11, 1, 5, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q) 返回错误: %v", tt.spec, err)
+			}
+			if got := next(t, plan, tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s，期望 %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMisfire(t *testing.T) {
+	for _, policy := range []string{"", MisfireRunOnce, MisfireRunAll, MisfireSkip} {
+		if err := ValidateMisfire(policy); err != nil {
+			t.Errorf("ValidateMisfire(%q) 返回错误: %v", policy, err)
+		}
+	}
+	if err := ValidateMisfire("always"); err == nil {
+		t.Error("ValidateMisfire(\"always\") 应返回错误")
+	}
+}
+
+func TestValidateOverlap(t *testing.T) {
+	for _, policy := range []string{"", OverlapForbid, OverlapQueue, OverlapReplace} {
+		if err := ValidateOverlap(policy); err != nil {
+			t.Errorf("ValidateOverlap(%q) 返回错误: %v", policy, err)
+		}
+	}
+	if err := ValidateOverlap("parallel"); err == nil {
+		t.Error("ValidateOverlap(\"parallel\") 应返回错误")
+	}
+}
@@ -0,0 +1,222 @@
+package schedule
+
+import (
+	"collyDemo/pkg/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxConstraintDays 查找下一个允许执行时间的最大天数，超过后视为永远不会执行
+const maxConstraintDays = 366 * 5
+
+// Constraints 执行时间限制，包括每天允许执行的时间段和不执行的日期，按 Asia/Shanghai 时区判断。
+// nil 表示没有限制。
+type Constraints struct {
+	windows   []minuteRange // 每天允许执行的时间段，为空表示全天
+	blackouts []dateRange   // 不执行的日期
+	loc       *time.Location
+}
+
+// minuteRange 一天中的时间段 [start, end)，单位为分钟
+type minuteRange struct {
+	start, end int
+}
+
+// dateRange 日期范围，起止日期均包含在内，格式为 20060102 的整数便于比较
+type dateRange struct {
+	start, end int
+}
+
+// ParseConstraints 解析执行时间限制：
+//
+//	windows    每天允许执行的时间段，如 "08:00-24:00"，结束时间早于开始时间表示跨零点，如 "22:00-06:00"
+//	blackouts  不执行的日期，如 "2026-02-17"，或日期范围 "2026-02-15~2026-02-21"
+//
+// 两者都为空时返回 nil。
+func ParseConstraints(windows, blackouts []string) (*Constraints, error) {
+	if len(windows) == 0 && len(blackouts) == 0 {
+		return nil, nil
+	}
+
+	c := &Constraints{loc: utils.ShanghaiLocation()}
+	for _, window := range windows {
+		start, end, ok := strings.Cut(window, "-")
+		if !ok {
+			return nil, fmt.Errorf("无效的时间段 %q，格式应为 HH:MM-HH:MM", window)
+		}
+		from, err := parseClock(start)
+		if err != nil {
+			return nil, fmt.Errorf("无效的时间段 %q: %w", window, err)
+		}
+		to, err := parseClock(end)
+		if err != nil {
+			return nil, fmt.Errorf("无效的时间段 %q: %w", window, err)
+		}
+		switch {
+		case from == to:
+			return nil, fmt.Errorf("无效的时间段 %q: 开始时间与结束时间相同", window)
+		case from < to:
+			c.windows = append(c.windows, minuteRange{from, to})
+		default:
+			c.windows = append(c.windows, minuteRange{from, 24 * 60}, minuteRange{0, to})
+		}
+	}
+
+	for _, blackout := range blackouts {
+		start, end, isRange := strings.Cut(blackout, "~")
+		if !isRange {
+			end = start
+		}
+		from, err := parseDate(start, c.loc)
+		if err != nil {
+			return nil, fmt.Errorf("无效的停止日期 %q: %w", blackout, err)
+		}
+		to, err := parseDate(end, c.loc)
+		if err != nil {
+			return nil, fmt.Errorf("无效的停止日期 %q: %w", blackout, err)
+		}
+		if to < from {
+			return nil, fmt.Errorf("无效的停止日期 %q: 结束日期早于开始日期", blackout)
+		}
+		c.blackouts = append(c.blackouts, dateRange{from, to})
+	}
+	return c, nil
+}
+
+// parseClock 解析 HH:MM，允许 24:00 表示一天结束
+func parseClock(value string) (int, error) {
+	hour, minute, ok := strings.Cut(strings.TrimSpace(value), ":")
+	if !ok {
+		return 0, fmt.Errorf("时间 %q 格式应为 HH:MM", value)
+	}
+	h, err := strconv.Atoi(hour)
+	if err != nil {
+		return 0, fmt.Errorf("无效的小时 %q", hour)
+	}
+	m, err := strconv.Atoi(minute)
+	if err != nil || m < 0 || m > 59 {
+		return 0, fmt.Errorf("无效的分钟 %q", minute)
+	}
+	if h < 0 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("时间 %q 超出范围 00:00-24:00", value)
+	}
+	return h*60 + m, nil
+}
+
+func parseDate(value string, loc *time.Location) (int, error) {
+	day, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(value), loc)
+	if err != nil {
+		return 0, fmt.Errorf("日期 %q 格式应为 YYYY-MM-DD", value)
+	}
+	return dateKey(day), nil
+}
+
+func dateKey(t time.Time) int {
+	return t.Year()*10000 + int(t.Month())*100 + t.Day()
+}
+
+// Allowed 判断 t 是否允许执行
+func (c *Constraints) Allowed(t time.Time) bool {
+	if c == nil {
+		return true
+	}
+	t = t.In(c.loc)
+	if c.blackedOut(t) {
+		return false
+	}
+	if len(c.windows) == 0 {
+		return true
+	}
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range c.windows {
+		if minute >= w.start && minute < w.end {
+			return true
+		}
+	}
+	return false
+}
+
+// NextAllowed 返回不早于 t 的第一个允许执行的时间，五年内没有时返回零值
+func (c *Constraints) NextAllowed(t time.Time) time.Time {
+	if c.Allowed(t) {
+		return t
+	}
+	origin := t.Location()
+	t = t.In(c.loc)
+	for i := 0; i < maxConstraintDays; i++ {
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.loc)
+		if !c.blackedOut(day) {
+			if len(c.windows) == 0 {
+				return maxTime(t, day).In(origin)
+			}
+			minute := t.Hour()*60 + t.Minute()
+			if t.Before(day) || t.Equal(day) {
+				minute = 0
+			}
+			best := -1
+			for _, w := range c.windows {
+				if w.start >= minute && (best < 0 || w.start < best) {
+					best = w.start
+				}
+			}
+			if best >= 0 {
+				return day.Add(time.Duration(best) * time.Minute).In(origin)
+			}
+		}
+		t = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+func (c *Constraints) blackedOut(t time.Time) bool {
+	key := dateKey(t)
+	for _, b := range c.blackouts {
+		if key >= b.start && key <= b.end {
+			return true
+		}
+	}
+	return false
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// Constrain 返回只在允许时间内触发的执行计划：
+// cron 表达式跳过不允许执行的触发时间，固定间隔任务在不允许执行期间到期时推迟到下一个允许执行的时间。
+func Constrain(plan Schedule, c *Constraints) Schedule {
+	if c == nil {
+		return plan
+	}
+	return &constrained{plan: plan, constraints: c}
+}
+
+type constrained struct {
+	plan        Schedule
+	constraints *Constraints
+}
+
+// Next 返回 from 之后第一个允许执行的触发时间
+func (s *constrained) Next(from time.Time) time.Time {
+	next := s.plan.Next(from)
+	for i := 0; i < maxConstraintDays && !next.IsZero(); i++ {
+		if s.constraints.Allowed(next) {
+			return next
+		}
+		allowed := s.constraints.NextAllowed(next)
+		if allowed.IsZero() {
+			return time.Time{}
+		}
+		if _, ok := s.plan.(Interval); ok {
+			return allowed
+		}
+		// cron 表达式从允许执行时间的前一秒重新查找，触发时间正好落在窗口开始时也不会被跳过
+		next = s.plan.Next(allowed.Add(-time.Second))
+	}
+	return time.Time{}
+}
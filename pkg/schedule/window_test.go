@@ -0,0 +1,164 @@
+package schedule
+
+import (
+	"collyDemo/pkg/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConstraints(t *testing.T) {
+	tests := []struct {
+		name      string
+		windows   []string
+		blackouts []string
+		want      string // 错误信息中应包含的内容，为空表示解析成功
+	}{
+		{name: "未配置", want: ""},
+		{name: "全天", windows: []string{"00:00-24:00"}},
+		{name: "跨零点", windows: []string{"22:00-06:00"}},
+		{name: "日期范围", blackouts: []string{"2026-02-15~2026-02-21"}},
+		{name: "缺少分隔符", windows: []string{"08:00"}, want: "HH:MM-HH:MM"},
+		{name: "开始与结束相同", windows: []string{"08:00-08:00"}, want: "相同"},
+		{name: "小时超出范围", windows: []string{"08:00-25:00"}, want: "超出范围"},
+		{name: "24点后有分钟", windows: []string{"08:00-24:30"}, want: "超出范围"},
+		{name: "分钟超出范围", windows: []string{"08:60-09:00"}, want: "无效的分钟"},
+		{name: "日期格式错误", blackouts: []string{"2026/02/17"}, want: "YYYY-MM-DD"},
+		{name: "结束日期早于开始日期", blackouts: []string{"2026-02-21~2026-02-15"}, want: "早于"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConstraints(tt.windows, tt.blackouts)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("ParseConstraints 返回错误: %v", err)
+				}
+				if len(tt.windows) == 0 && len(tt.blackouts) == 0 && c != nil {
+					t.Error("未配置时应返回 nil")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseConstraints 错误为 %v，应包含 %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConstraintsAllowed(t *testing.T) {
+	sh := utils.ShanghaiLocation()
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, 2, day, hour, min, 0, 0, sh)
+	}
+	tests := []struct {
+		name      string
+		windows   []string
+		blackouts []string
+		t         time.Time
+		want      bool
+	}{
+		{"nil 不限制", nil, nil, at(1, 3, 0), true},
+		{"窗口内", []string{"08:00-18:00"}, nil, at(1, 8, 0), true},
+		{"窗口结束时刻不包含", []string{"08:00-18:00"}, nil, at(1, 18, 0), false},
+		{"窗口前", []string{"08:00-18:00"}, nil, at(1, 7, 59), false},
+		{"到24点", []string{"08:00-24:00"}, nil, at(1, 23, 59), true},
+		{"跨零点的前半段", []string{"22:00-06:00"}, nil, at(1, 23, 0), true},
+		{"跨零点的后半段", []string{"22:00-06:00"}, nil, at(2, 5, 59), true},
+		{"跨零点的窗口外", []string{"22:00-06:00"}, nil, at(2, 6, 0), false},
+		{"多个窗口", []string{"01:00-02:00", "12:00-13:00"}, nil, at(1, 12, 30), true},
+		{"停止日期", nil, []string{"2026-02-17"}, at(17, 12, 0), false},
+		{"停止日期次日", nil, []string{"2026-02-17"}, at(18, 0, 0), true},
+		{"停止日期范围内", nil, []string{"2026-02-15~2026-02-21"}, at(21, 23, 59), false},
+		{"停止日期优先于窗口", []string{"00:00-24:00"}, []string{"2026-02-17"}, at(17, 12, 0), false},
+		{"按上海时区判断", []string{"08:00-09:00"}, nil, time.Date(2026, 2, 1, 0, 30, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConstraints(tt.windows, tt.blackouts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Allowed(tt.t); got != tt.want {
+				t.Errorf("Allowed(%s) = %v，期望 %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConstraintsNextAllowed(t *testing.T) {
+	sh := utils.ShanghaiLocation()
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, 2, day, hour, min, 0, 0, sh)
+	}
+	tests := []struct {
+		name      string
+		windows   []string
+		blackouts []string
+		t         time.Time
+		want      time.Time
+	}{
+		{"已允许时原样返回", []string{"08:00-18:00"}, nil, at(1, 9, 15), at(1, 9, 15)},
+		{"当天窗口开始", []string{"08:00-18:00"}, nil, at(1, 6, 0), at(1, 8, 0)},
+		{"窗口结束后到次日", []string{"08:00-18:00"}, nil, at(1, 19, 0), at(2, 8, 0)},
+		{"跨零点窗口当晚开始", []string{"22:00-06:00"}, nil, at(1, 12, 0), at(1, 22, 0)},
+		{"跨零点窗口结束后到当晚", []string{"22:00-06:00"}, nil, at(2, 6, 0), at(2, 22, 0)},
+		{"跳过停止日期", nil, []string{"2026-02-15~2026-02-21"}, at(15, 10, 0), at(22, 0, 0)},
+		{"跳过停止日期后取窗口开始", []string{"08:00-18:00"}, []string{"2026-02-17"}, at(16, 19, 0), at(18, 8, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConstraints(tt.windows, tt.blackouts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.NextAllowed(tt.t); !got.Equal(tt.want) {
+				t.Errorf("NextAllowed(%s) = %s，期望 %s", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConstrain(t *testing.T) {
+	sh := utils.ShanghaiLocation()
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, 2, day, hour, min, 0, 0, sh)
+	}
+	tests := []struct {
+		name      string
+		spec      string
+		windows   []string
+		blackouts []string
+		from      time.Time
+		want      time.Time
+	}{
+		{"cron 跳过窗口外的触发时间", "0 * * * *", []string{"08:00-18:00"}, nil, at(1, 17, 30), at(2, 8, 0)},
+		{"cron 触发时间正好是窗口开始", "0 8 * * *", []string{"08:00-18:00"}, nil, at(1, 9, 0), at(2, 8, 0)},
+		{"cron 跨零点窗口", "30 * * * *", []string{"22:00-02:00"}, nil, at(2, 1, 45), at(2, 22, 30)},
+		{"cron 跳过停止日期", "0 9 * * *", nil, []string{"2026-02-15~2026-02-21"}, at(14, 10, 0), at(22, 9, 0)},
+		{"固定间隔推迟到窗口开始", "2h", []string{"08:00-18:00"}, nil, at(1, 17, 0), at(2, 8, 0)},
+		{"固定间隔在窗口内不推迟", "2h", []string{"08:00-18:00"}, nil, at(1, 9, 0), at(1, 11, 0)},
+		{"窗口外永远不触发", "0 12 * * *", []string{"08:00-10:00"}, nil, at(1, 0, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := ParseConstraints(tt.windows, tt.blackouts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := next(t, Constrain(plan, c), tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s，期望 %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConstrainNil(t *testing.T) {
+	plan := Interval(time.Hour)
+	if Constrain(plan, nil) != Schedule(plan) {
+		t.Error("没有限制时应返回原执行计划")
+	}
+}
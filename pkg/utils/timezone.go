@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	shanghai     *time.Location
+	shanghaiOnce sync.Once
+)
+
+// ShanghaiLocation 返回 Asia/Shanghai 时区，系统缺少时区数据时使用固定的 UTC+8
+func ShanghaiLocation() *time.Location {
+	shanghaiOnce.Do(func() {
+		loc, err := time.LoadLocation("Asia/Shanghai")
+		if err != nil {
+			loc = time.FixedZone("CST", 8*60*60)
+		}
+		shanghai = loc
+	})
+	return shanghai
+}